@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kbesada/flux-code-cli/internal/app"
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask [prompt]",
+	Short: "Ask a single question and print the reply",
+	Long: `Ask sends one prompt to the configured provider and prints the reply,
+without starting the TUI. Input piped to stdin is attached as context:
+
+  cat main.go | flux ask "find bugs"`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.Ask(app.Options{Debug: debug}, strings.Join(args, " "))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(askCmd)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+var forceInit bool
+
+var initCmd = &cobra.Command{
+	Use:          "init",
+	Short:        "Write a commented starter config to ~/.config/flux/config.yaml",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.Init(forceInit)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", path)
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&forceInit, "force", false, "overwrite an existing config file")
+	rootCmd.AddCommand(initCmd)
+}
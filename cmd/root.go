@@ -11,6 +11,8 @@ import (
 
 var version = "0.1.0"
 
+var debug bool
+
 var rootCmd = &cobra.Command{
 	Use:   "flux",
 	Short: "AI-powered coding assistant",
@@ -18,7 +20,7 @@ var rootCmd = &cobra.Command{
 It provides an interactive TUI for AI-powered code assistance
 using open-source AI models with your own API keys.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := app.Run(); err != nil {
+		if err := app.Run(app.Options{Debug: debug}); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -33,4 +35,5 @@ func Execute() {
 
 func init() {
 	rootCmd.Version = version
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "log AI requests and responses to ~/.config/flux/flux.log (keys redacted)")
 }
@@ -0,0 +1,435 @@
+//go:build bedrock
+
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// bedrockAnthropicVersion is the Messages API version Bedrock expects in
+// Claude request bodies.
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// bedrockDefaultMaxTokens is sent when neither the request nor the config
+// sets a limit; Claude on Bedrock requires one.
+const bedrockDefaultMaxTokens = 4096
+
+// BedrockClientConfig defines the parameters for Claude models on AWS Bedrock.
+type BedrockClientConfig struct {
+	Region      string
+	Model       string // Bedrock model ID, e.g. anthropic.claude-3-5-sonnet-20240620-v1:0
+	Credentials AWSCredentials
+	HTTPClient  *http.Client
+	Logger      *log.Logger // Optional; logs requests and responses with credentials redacted
+
+	// BaseURL replaces https://bedrock-runtime.{region}.amazonaws.com
+	BaseURL string
+
+	// Defaults for requests that don't set them; zero uses the provider default
+	Temperature float32
+	MaxTokens   int
+	Stop        []string
+}
+
+// BedrockClient calls Anthropic Claude models through the Bedrock runtime
+// API. Requests are signed with AWS Signature Version 4.
+type BedrockClient struct {
+	baseURL    string
+	model      string
+	signer     requestSigner
+	httpClient *http.Client
+
+	temperature float32
+	maxTokens   int
+	stop        []string
+}
+
+var _ Client = (*BedrockClient)(nil)
+
+// requestSigner adds authentication to an outgoing request; tests replace
+// the SigV4 signer with a fake.
+type requestSigner interface {
+	Sign(req *http.Request, body []byte, now time.Time) error
+}
+
+// NewBedrockClient creates a new Bedrock client.
+func NewBedrockClient(cfg BedrockClientConfig) (Client, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("region is required")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws credentials are required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", cfg.Region)
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 60 * time.Second}
+	}
+	hc = WithLogging(hc, cfg.Logger)
+
+	return &BedrockClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      cfg.Model,
+		signer:     &sigV4Signer{credentials: cfg.Credentials, region: cfg.Region, service: "bedrock"},
+		httpClient: hc,
+
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+		stop:        cfg.Stop,
+	}, nil
+}
+
+// newBedrockClient builds a Bedrock client from provider config. The region
+// falls back to AWS_REGION and credentials come from the environment or the
+// shared credentials file.
+func newBedrockClient(p config.Provider, hc *http.Client) (Client, error) {
+	creds, err := LoadAWSCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return NewBedrockClient(BedrockClientConfig{
+		Region:      cmp.Or(p.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+		Model:       p.Model,
+		Credentials: creds,
+		HTTPClient:  hc,
+		BaseURL:     p.BaseURL,
+
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxTokens,
+		Stop:        p.Stop,
+	})
+}
+
+func (c *BedrockClient) Model() string         { return c.model }
+func (c *BedrockClient) SetModel(model string) { c.model = model }
+func (c *BedrockClient) Provider() string      { return "bedrock" }
+
+func (c *BedrockClient) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := c.post(ctx, req, "invoke")
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return ChatResponse{}, err
+	}
+
+	var content strings.Builder
+	for _, block := range parsed.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	result := ChatResponse{
+		Content:      content.String(),
+		FinishReason: bedrockFinishReason(parsed.StopReason),
+	}
+	if strings.TrimSpace(result.Content) == "" {
+		return result, ErrEmptyResponse
+	}
+	return result, nil
+}
+
+// Stream reads InvokeModelWithResponseStream, whose body is a sequence of
+// AWS event stream frames each wrapping one Anthropic streaming event.
+func (c *BedrockClient) Stream(ctx context.Context, req ChatRequest) (<-chan StreamEvent, error) {
+	resp, err := c.post(ctx, req, "invoke-with-response-stream")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		var usage Usage
+		var finishReason string
+		reader := bufio.NewReader(resp.Body)
+
+		for {
+			frame, err := readEventFrame(reader)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					out <- StreamEvent{Type: StreamEventError, Err: streamErr(err)}
+				}
+				return
+			}
+
+			if frame.headers[":message-type"] == "exception" {
+				out <- StreamEvent{Type: StreamEventError, Err: bedrockException(frame)}
+				return
+			}
+			if frame.headers[":event-type"] != "chunk" {
+				continue
+			}
+
+			var chunk struct {
+				Bytes []byte `json:"bytes"` // base64 in the JSON
+			}
+			if err := json.Unmarshal(frame.payload, &chunk); err != nil {
+				out <- StreamEvent{Type: StreamEventError, Err: err}
+				return
+			}
+
+			var event bedrockStreamEvent
+			if err := json.Unmarshal(chunk.Bytes, &event); err != nil {
+				continue // Unknown event shapes are skipped
+			}
+			switch event.Type {
+			case "message_start":
+				usage.PromptTokens = event.Message.Usage.InputTokens
+			case "content_block_delta":
+				if event.Delta.Text != "" {
+					out <- StreamEvent{Type: StreamEventChunk, Content: event.Delta.Text}
+				}
+			case "message_delta":
+				finishReason = bedrockFinishReason(event.Delta.StopReason)
+				usage.CompletionTokens = event.Usage.OutputTokens
+			case "message_stop":
+				usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+				done := StreamEvent{Type: StreamEventDone, FinishReason: finishReason}
+				if usage.TotalTokens > 0 {
+					done.Usage = &usage
+				}
+				out <- done
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// ListModels is not supported: listing foundation models is a separate
+// control-plane API.
+func (c *BedrockClient) ListModels(ctx context.Context) ([]string, error) {
+	return nil, fmt.Errorf("bedrock: %w", ErrNotSupported)
+}
+
+// post signs and sends a request to the model's invoke action and checks
+// the status.
+func (c *BedrockClient) post(ctx context.Context, req ChatRequest, action string) (*http.Response, error) {
+	body, err := json.Marshal(c.toPayload(req))
+	if err != nil {
+		return nil, err
+	}
+
+	model := cmp.Or(req.Model, c.model)
+	// Model IDs contain ":", which Bedrock expects percent-encoded
+	endpoint := fmt.Sprintf("%s/model/%s/%s", c.baseURL, awsURIEscape(model), action)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if err := c.signer.Sign(httpReq, body, time.Now()); err != nil {
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		detail := strings.TrimSpace(string(b))
+		if isModelNotFound(resp.StatusCode, detail) {
+			return nil, &ModelNotFoundError{Model: model, Provider: c.Provider(), Detail: detail}
+		}
+		return nil, responseError(resp, c.Provider(), detail)
+	}
+
+	return resp, nil
+}
+
+// toPayload builds a Claude Messages API body. System messages become the
+// system prompt and consecutive turns from the same role are merged, since
+// Claude requires user and assistant turns to alternate.
+func (c *BedrockClient) toPayload(req ChatRequest) bedrockRequest {
+	payload := bedrockRequest{
+		AnthropicVersion: bedrockAnthropicVersion,
+		MaxTokens:        cmp.Or(req.MaxTokens, c.maxTokens, bedrockDefaultMaxTokens),
+		Temperature:      temperature(req.Temperature, c.temperature),
+		StopSequences:    req.Stop,
+	}
+	if len(payload.StopSequences) == 0 {
+		payload.StopSequences = c.stop
+	}
+
+	var system []string
+	for _, m := range req.Messages {
+		if m.Role == "system" || m.Role == "developer" {
+			system = append(system, m.Content)
+			continue
+		}
+
+		role := "user"
+		if m.Role == "assistant" {
+			role = "assistant"
+		}
+		if n := len(payload.Messages); n > 0 && payload.Messages[n-1].Role == role {
+			payload.Messages[n-1].Content += "\n\n" + m.Content
+			continue
+		}
+		payload.Messages = append(payload.Messages, bedrockMessage{Role: role, Content: m.Content})
+	}
+	payload.System = strings.Join(system, "\n\n")
+
+	return payload
+}
+
+// bedrockFinishReason maps Claude's stop reasons onto the OpenAI names.
+func bedrockFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return FinishReasonLength
+	default:
+		return reason
+	}
+}
+
+// bedrockException turns an exception frame into an API error.
+func bedrockException(frame eventFrame) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(frame.payload, &body)
+	return &APIError{
+		Provider: "bedrock",
+		Type:     frame.headers[":exception-type"],
+		Message:  cmp.Or(body.Message, string(frame.payload)),
+	}
+}
+
+type bedrockRequest struct {
+	AnthropicVersion string           `json:"anthropic_version"`
+	MaxTokens        int              `json:"max_tokens"`
+	System           string           `json:"system,omitempty"`
+	Messages         []bedrockMessage `json:"messages"`
+	Temperature      *float32         `json:"temperature,omitempty"`
+	StopSequences    []string         `json:"stop_sequences,omitempty"`
+}
+
+type bedrockMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type bedrockStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// AWSCredentials are the keys used to sign AWS requests.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// LoadAWSCredentials reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN, falling back to the AWS_PROFILE (or default) profile in
+// the shared credentials file.
+func LoadAWSCredentials() (AWSCredentials, error) {
+	creds := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return AWSCredentials{}, err
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("no aws credentials in the environment or %s", path)
+	}
+
+	profile := cmp.Or(os.Getenv("AWS_PROFILE"), "default")
+	values := iniSection(string(data), profile)
+	creds = AWSCredentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return AWSCredentials{}, fmt.Errorf("profile %q in %s has no access keys", profile, path)
+	}
+	return creds, nil
+}
+
+// iniSection returns the key/value pairs of one [section] in an INI file.
+func iniSection(data, section string) map[string]string {
+	values := make(map[string]string)
+	inSection := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == section
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inSection {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,100 @@
+//go:build bedrock
+
+package ai
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventFrameSize guards against a corrupt length prefix; AWS caps event
+// stream messages at 16 MB.
+const maxEventFrameSize = 16 * 1024 * 1024
+
+// eventFrame is one message of the AWS event stream encoding used by
+// streaming Bedrock responses.
+type eventFrame struct {
+	headers map[string]string // string-valued headers only
+	payload []byte
+}
+
+// readEventFrame reads one frame: a 12-byte prelude (total length, header
+// length, prelude CRC), the headers, the payload, and a CRC of the whole
+// message. A clean end of stream returns io.EOF.
+func readEventFrame(r io.Reader) (eventFrame, error) {
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		return eventFrame{}, err
+	}
+	total := binary.BigEndian.Uint32(prelude[0:4])
+	headerLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return eventFrame{}, errors.New("event stream: prelude checksum mismatch")
+	}
+	if total > maxEventFrameSize || total < 16 || headerLen > total-16 {
+		return eventFrame{}, fmt.Errorf("event stream: invalid frame length %d", total)
+	}
+
+	message := make([]byte, total)
+	copy(message, prelude)
+	if _, err := io.ReadFull(r, message[12:]); err != nil {
+		return eventFrame{}, fmt.Errorf("event stream: %w", io.ErrUnexpectedEOF)
+	}
+	if crc32.ChecksumIEEE(message[:total-4]) != binary.BigEndian.Uint32(message[total-4:]) {
+		return eventFrame{}, errors.New("event stream: message checksum mismatch")
+	}
+
+	headers, err := parseEventHeaders(message[12 : 12+headerLen])
+	if err != nil {
+		return eventFrame{}, err
+	}
+	return eventFrame{headers: headers, payload: message[12+headerLen : total-4]}, nil
+}
+
+// parseEventHeaders decodes frame headers, keeping the string values and
+// skipping the other types.
+func parseEventHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 2+nameLen {
+			return nil, errors.New("event stream: truncated header")
+		}
+		name := string(data[1 : 1+nameLen])
+		valueType := data[1+nameLen]
+		data = data[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true, bool false
+		case 2: // byte
+			size = 1
+		case 3: // int16
+			size = 2
+		case 4: // int32
+			size = 4
+		case 5, 8: // int64, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // byte array, string
+			if len(data) < 2 {
+				return nil, errors.New("event stream: truncated header")
+			}
+			size = 2 + int(binary.BigEndian.Uint16(data))
+		default:
+			return nil, fmt.Errorf("event stream: unknown header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, errors.New("event stream: truncated header")
+		}
+		if valueType == 7 {
+			headers[name] = string(data[2:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
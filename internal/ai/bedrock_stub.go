@@ -0,0 +1,15 @@
+//go:build !bedrock
+
+package ai
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// newBedrockClient reports that Bedrock support was left out of this build.
+func newBedrockClient(config.Provider, *http.Client) (Client, error) {
+	return nil, errors.New("bedrock support is not included in this build; rebuild with -tags bedrock")
+}
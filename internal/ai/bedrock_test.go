@@ -0,0 +1,198 @@
+//go:build bedrock
+
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeSigner records the requests it signs instead of computing SigV4.
+type fakeSigner struct {
+	signed int
+}
+
+func (s *fakeSigner) Sign(req *http.Request, body []byte, now time.Time) error {
+	s.signed++
+	req.Header.Set("Authorization", "fake")
+	return nil
+}
+
+func newBedrockTestClient(t *testing.T, srv *httptest.Server, signer requestSigner) Client {
+	t.Helper()
+
+	client, err := NewBedrockClient(BedrockClientConfig{
+		Region:      "us-east-1",
+		Model:       "anthropic.claude-3-haiku-20240307-v1:0",
+		Credentials: AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		BaseURL:     srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewBedrockClient() error: %v", err)
+	}
+	client.(*BedrockClient).signer = signer
+	return client
+}
+
+func TestBedrockCompletePayload(t *testing.T) {
+	var path, auth string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"Hello"}],"stop_reason":"max_tokens"}`)
+	}))
+	defer srv.Close()
+
+	signer := &fakeSigner{}
+	resp, err := newBedrockTestClient(t, srv, signer).Complete(context.Background(), ChatRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "hi"},
+			{Role: "user", Content: "again"},
+			{Role: "assistant", Content: "hey"},
+		},
+		Stop: []string{"END"},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	if signer.signed != 1 || auth != "fake" {
+		t.Errorf("request should be signed once, signed %d, Authorization %q", signer.signed, auth)
+	}
+	if path != "/model/anthropic.claude-3-haiku-20240307-v1%3A0/invoke" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if resp.Content != "Hello" || resp.FinishReason != FinishReasonLength {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if body["anthropic_version"] != bedrockAnthropicVersion || body["system"] != "Be brief." {
+		t.Errorf("unexpected payload %v", body)
+	}
+	if body["max_tokens"] != float64(bedrockDefaultMaxTokens) {
+		t.Errorf("max_tokens should default to %d, got %v", bedrockDefaultMaxTokens, body["max_tokens"])
+	}
+	if fmt.Sprint(body["stop_sequences"]) != "[END]" {
+		t.Errorf("stop_sequences = %v", body["stop_sequences"])
+	}
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 2 {
+		t.Fatalf("consecutive user turns should be merged, got %v", body["messages"])
+	}
+	if first := messages[0].(map[string]any); first["role"] != "user" || first["content"] != "hi\n\nagain" {
+		t.Errorf("unexpected first message %v", first)
+	}
+}
+
+// eventFrameBytes encodes a frame with string headers.
+func eventFrameBytes(headers map[string]string, payload []byte) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+
+	total := 12 + h.Len() + len(payload) + 4
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, uint32(total))
+	binary.Write(&msg, binary.BigEndian, uint32(h.Len()))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(h.Bytes())
+	msg.Write(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+// chunkFrame wraps an Anthropic streaming event the way Bedrock does.
+func chunkFrame(event string) []byte {
+	payload := fmt.Sprintf(`{"bytes":%q}`, base64.StdEncoding.EncodeToString([]byte(event)))
+	return eventFrameBytes(map[string]string{":event-type": "chunk", ":message-type": "event"}, []byte(payload))
+}
+
+func TestBedrockStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/model/anthropic.claude-3-haiku-20240307-v1:0/invoke-with-response-stream" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Write(chunkFrame(`{"type":"message_start","message":{"usage":{"input_tokens":10}}}`))
+		w.Write(chunkFrame(`{"type":"content_block_delta","delta":{"type":"text_delta","text":"Hel"}}`))
+		w.Write(chunkFrame(`{"type":"content_block_delta","delta":{"type":"text_delta","text":"lo"}}`))
+		w.Write(chunkFrame(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`))
+		w.Write(chunkFrame(`{"type":"message_stop"}`))
+	}))
+	defer srv.Close()
+
+	events, err := newBedrockTestClient(t, srv, &fakeSigner{}).Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	content, last, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if content != "Hello" {
+		t.Errorf("expected 'Hello', got %q", content)
+	}
+	if last.Type != StreamEventDone || last.FinishReason != "stop" {
+		t.Errorf("expected done with stop, got %+v", last)
+	}
+	if last.Usage == nil || last.Usage.PromptTokens != 10 || last.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected usage %+v", last.Usage)
+	}
+}
+
+func TestBedrockStreamException(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(eventFrameBytes(map[string]string{
+			":message-type":   "exception",
+			":exception-type": "throttlingException",
+		}, []byte(`{"message":"Too many requests"}`)))
+	}))
+	defer srv.Close()
+
+	events, err := newBedrockTestClient(t, srv, &fakeSigner{}).Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	_, _, err = collect(t, events)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Type != "throttlingException" || apiErr.Message != "Too many requests" {
+		t.Errorf("unexpected error %#v", err)
+	}
+}
+
+// TestSigV4Vanilla checks the signer against the get-vanilla case of the
+// AWS Signature Version 4 test suite.
+func TestSigV4Vanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signer := &sigV4Signer{
+		credentials: AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		region:      "us-east-1",
+		service:     "service",
+	}
+	if err := signer.Sign(req, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
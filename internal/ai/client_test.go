@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClientsPassRequestOptions checks that every Client implementation
+// forwards temperature and max tokens from ChatRequest.
+func TestClientsPassRequestOptions(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		// Answer in both response shapes; each client ignores the other's fields
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}],"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`)
+	}))
+	defer srv.Close()
+
+	standard, _ := NewStandardClient(StandardClientConfig{BaseURL: srv.URL, Model: "m"})
+	gemini, _ := NewGeminiClient(GeminiClientConfig{BaseURL: srv.URL, APIKey: "k", Model: "m"})
+
+	tests := []struct {
+		name        string
+		client      Client
+		temperature func(map[string]any) any
+		maxTokens   func(map[string]any) any
+	}{
+		{
+			name:        "standard",
+			client:      standard,
+			temperature: func(b map[string]any) any { return b["temperature"] },
+			maxTokens:   func(b map[string]any) any { return b["max_tokens"] },
+		},
+		{
+			name:   "gemini",
+			client: gemini,
+			temperature: func(b map[string]any) any {
+				gc, _ := b["generationConfig"].(map[string]any)
+				return gc["temperature"]
+			},
+			maxTokens: func(b map[string]any) any {
+				gc, _ := b["generationConfig"].(map[string]any)
+				return gc["maxOutputTokens"]
+			},
+		},
+	}
+
+	temp := float32(0.5)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Complete(context.Background(), ChatRequest{
+				Messages:    []ChatMessage{{Role: "user", Content: "hi"}},
+				Temperature: &temp,
+				MaxTokens:   128,
+			})
+			if err != nil {
+				t.Fatalf("Complete() error: %v", err)
+			}
+			if resp.Content != "ok" {
+				t.Errorf("expected 'ok', got %q", resp.Content)
+			}
+			if got := tt.temperature(body); got != 0.5 {
+				t.Errorf("expected temperature 0.5, got %v", got)
+			}
+			if got := tt.maxTokens(body); got != float64(128) {
+				t.Errorf("expected max tokens 128, got %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package ai
+
+import "fmt"
+
+// messageOverhead approximates the per-message tokens spent on role markers
+const messageOverhead = 4
+
+// Tokenizer counts the tokens in a piece of text.
+type Tokenizer func(text string) int
+
+// EstimateTokens approximates a token count at roughly four characters per
+// token. It is deliberately rough; pass a real Tokenizer for accuracy.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// CountTokens estimates the tokens in messages, including role overhead.
+// A nil tokenizer uses EstimateTokens.
+func CountTokens(messages []ChatMessage, tokenize Tokenizer) int {
+	if tokenize == nil {
+		tokenize = EstimateTokens
+	}
+	total := 0
+	for _, m := range messages {
+		total += tokenize(m.Content) + messageOverhead
+	}
+	return total
+}
+
+// TrimHistory drops the oldest messages until the estimate fits within
+// maxTokens. Leading system messages (the system prompt) and the latest
+// message are always kept. When anything is dropped, a system note saying
+// so follows the system prompt. A maxTokens of zero or less disables
+// trimming.
+func TrimHistory(messages []ChatMessage, maxTokens int, tokenize Tokenizer) ([]ChatMessage, int) {
+	if maxTokens <= 0 || CountTokens(messages, tokenize) <= maxTokens {
+		return messages, 0
+	}
+
+	prefix := 0
+	for prefix < len(messages) && messages[prefix].Role == "system" {
+		prefix++
+	}
+	if prefix >= len(messages)-1 {
+		return messages, 0
+	}
+
+	// Reserve room for the note so adding it doesn't overshoot
+	note := ChatMessage{Role: "system", Content: truncationNote(len(messages))}
+	budget := maxTokens - CountTokens(messages[:prefix], tokenize) - CountTokens([]ChatMessage{note}, tokenize)
+
+	start := len(messages) - 1
+	used := CountTokens(messages[start:], tokenize)
+	for start > prefix {
+		cost := CountTokens(messages[start-1:start], tokenize)
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		start--
+	}
+
+	dropped := start - prefix
+	if dropped == 0 {
+		return messages, 0
+	}
+	note.Content = truncationNote(dropped)
+
+	trimmed := make([]ChatMessage, 0, prefix+1+len(messages)-start)
+	trimmed = append(trimmed, messages[:prefix]...)
+	trimmed = append(trimmed, note)
+	trimmed = append(trimmed, messages[start:]...)
+	return trimmed, dropped
+}
+
+func truncationNote(dropped int) string {
+	return fmt.Sprintf("Note: %d earlier message(s) were dropped to fit the context window.", dropped)
+}
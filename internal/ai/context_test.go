@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{strings.Repeat("x", 400), 100},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%d chars) = %d, want %d", len(tt.text), got, tt.want)
+		}
+	}
+}
+
+func TestEstimateTokensNearRealCounts(t *testing.T) {
+	// Token counts from the cl100k_base encoding used by GPT-4 class models
+	tests := []struct {
+		text string
+		real int
+	}{
+		{"Hello, world!", 4},
+		{"The quick brown fox jumps over the lazy dog.", 10},
+		{"func main() { fmt.Println(\"hi\") }", 10},
+		{"Explain what this function does and suggest a simpler version.", 11},
+	}
+	for _, tt := range tests {
+		got := EstimateTokens(tt.text)
+		if diff := got - tt.real; diff < -tt.real/2 || diff > tt.real/2 {
+			t.Errorf("EstimateTokens(%q) = %d, want within half of %d", tt.text, got, tt.real)
+		}
+	}
+}
+
+func TestTrimHistoryUnderBudget(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: "system", Content: "be helpful"},
+		{Role: "user", Content: "hi"},
+	}
+	got, dropped := TrimHistory(messages, 1000, nil)
+	if dropped != 0 || len(got) != 2 {
+		t.Errorf("expected history unchanged, got %d messages (%d dropped)", len(got), dropped)
+	}
+
+	if _, dropped := TrimHistory(messages, 0, nil); dropped != 0 {
+		t.Error("zero budget should disable trimming")
+	}
+}
+
+func TestTrimHistoryDropsOldest(t *testing.T) {
+	long := strings.Repeat("x", 400) // 100 tokens + overhead
+	messages := []ChatMessage{
+		{Role: "system", Content: "be helpful"},
+		{Role: "user", Content: "first " + long},
+		{Role: "assistant", Content: "second " + long},
+		{Role: "user", Content: "third " + long},
+		{Role: "assistant", Content: "fourth " + long},
+		{Role: "user", Content: "latest question"},
+	}
+
+	got, dropped := TrimHistory(messages, 300, nil)
+	if dropped == 0 {
+		t.Fatal("expected messages to be dropped")
+	}
+	if CountTokens(got, nil) > 300 {
+		t.Errorf("trimmed history still over budget: %d tokens", CountTokens(got, nil))
+	}
+
+	if !reflect.DeepEqual(got[0], messages[0]) {
+		t.Errorf("system prompt should be preserved, got %+v", got[0])
+	}
+	if got[1].Role != "system" || !strings.Contains(got[1].Content, "dropped") {
+		t.Errorf("expected a truncation note after the system prompt, got %+v", got[1])
+	}
+	if got[len(got)-1].Content != "latest question" {
+		t.Error("latest message should be kept")
+	}
+	for _, m := range got {
+		if strings.HasPrefix(m.Content, "first") {
+			t.Error("oldest message should be dropped first")
+		}
+	}
+	if len(got) != len(messages)-dropped+1 {
+		t.Errorf("expected %d messages, got %d", len(messages)-dropped+1, len(got))
+	}
+}
+
+func TestTrimHistoryKeepsLatestEvenOverBudget(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: "user", Content: "old"},
+		{Role: "user", Content: strings.Repeat("x", 4000)},
+	}
+	got, dropped := TrimHistory(messages, 50, nil)
+	if dropped != 1 || !reflect.DeepEqual(got[len(got)-1], messages[1]) {
+		t.Errorf("expected only the latest message kept, got %+v", got)
+	}
+}
+
+func TestTrimHistoryCustomTokenizer(t *testing.T) {
+	words := func(s string) int { return len(strings.Fields(s)) }
+	messages := []ChatMessage{
+		{Role: "user", Content: "one two three"},
+		{Role: "user", Content: "four five"},
+	}
+	if _, dropped := TrimHistory(messages, 20, words); dropped != 0 {
+		t.Errorf("expected no trimming with the word tokenizer, dropped %d", dropped)
+	}
+}
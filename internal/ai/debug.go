@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/textutil"
+)
+
+// maxLoggedBody caps how much of each request or response body is logged
+const maxLoggedBody = 64 << 10
+
+// sensitiveHeaders carry credentials and are masked in debug logs
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "Api-Key", "X-Goog-Api-Key", "X-Amz-Security-Token"}
+
+// sensitiveParams are query parameters that carry credentials (e.g. Gemini's key)
+var sensitiveParams = []string{"key", "api_key", "api-key"}
+
+// RedactHeaders returns a copy of h with credential values masked, along
+// with those of the extra headers named, such as a provider's configured
+// custom headers. An auth scheme such as "Bearer" is kept so the log still
+// shows how auth was sent.
+func RedactHeaders(h http.Header, extra ...string) http.Header {
+	out := h.Clone()
+	for _, name := range slices.Concat(sensitiveHeaders, extra) {
+		values := out.Values(name)
+		for i, v := range values {
+			if scheme, _, ok := strings.Cut(v, " "); ok {
+				values[i] = scheme + " " + textutil.Redacted
+			} else {
+				values[i] = textutil.Redacted
+			}
+		}
+	}
+	return out
+}
+
+// headerNames returns the names of a provider's custom headers, including
+// a custom auth header, for WithLogging to mask
+func headerNames(headers map[string]string, authHeader string) []string {
+	names := slices.Collect(maps.Keys(headers))
+	if authHeader != "" {
+		names = append(names, authHeader)
+	}
+	return names
+}
+
+// redactURL masks credential query parameters
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	changed := false
+	for _, name := range sensitiveParams {
+		if q.Has(name) {
+			q.Set(name, textutil.Redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
+
+// WithLogging returns a copy of hc whose requests and responses are written
+// to logger with credentials, and the values of the redact headers, masked.
+// A nil hc uses the client defaults.
+func WithLogging(hc *http.Client, logger *log.Logger, redact ...string) *http.Client {
+	if logger == nil {
+		return hc
+	}
+
+	var logged http.Client
+	if hc != nil {
+		logged = *hc
+	} else {
+		logged.Timeout = 60 * time.Second
+	}
+	base := logged.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	logged.Transport = &loggingTransport{base: base, logger: logger, redact: redact}
+	return &logged
+}
+
+// loggingTransport logs each round trip. Response bodies are logged as they
+// are read, once closed, so streaming is not held up.
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger *log.Logger
+	redact []string // extra headers to mask
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(io.LimitReader(rc, maxLoggedBody))
+			rc.Close()
+		}
+	}
+	t.logger.Printf(">>> %s %s\n%s\n%s", req.Method, redactURL(req.URL),
+		formatHeaders(RedactHeaders(req.Header, t.redact...)), textutil.RedactSecrets(string(body)))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.logger.Printf("<<< %s %s failed after %s: %v", req.Method, redactURL(req.URL), time.Since(start), err)
+		return nil, err
+	}
+
+	t.logger.Printf("<<< %s after %s\n%s", resp.Status, time.Since(start), formatHeaders(RedactHeaders(resp.Header, t.redact...)))
+	resp.Body = &loggingBody{ReadCloser: resp.Body, logger: t.logger}
+	return resp, nil
+}
+
+func formatHeaders(h http.Header) string {
+	var b bytes.Buffer
+	_ = h.WriteSubset(&b, nil)
+	return strings.TrimRight(b.String(), "\r\n")
+}
+
+// loggingBody records what the client reads and logs it on Close
+type loggingBody struct {
+	io.ReadCloser
+	logger *log.Logger
+	buf    bytes.Buffer
+}
+
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := maxLoggedBody - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *loggingBody) Close() error {
+	b.logger.Printf("<<< body (%d bytes logged)\n%s", b.buf.Len(), textutil.RedactSecrets(b.buf.String()))
+	return b.ReadCloser.Close()
+}
@@ -1,8 +1,129 @@
 package ai
 
-import "net/http"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // IsRetryableHTTP returns true for status codes that should be retried.
 func IsRetryableHTTP(status int) bool {
 	return status == http.StatusTooManyRequests || (status >= 500 && status <= 599)
 }
+
+// IsRetryable reports whether err is an APIError with a retryable status.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && IsRetryableHTTP(apiErr.StatusCode)
+}
+
+// APIError is a non-success response from a provider. Message comes from the
+// standard {"error": {...}} envelope when present, otherwise the raw body.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Type       string // e.g. "invalid_request_error"; empty if not reported
+	Code       string // provider-specific code; empty if not reported
+	Provider   string
+
+	// RetryAfter is how long the server asked clients to wait before
+	// retrying, from its Retry-After header; zero if it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("api error: %s: status %d: %s", e.Provider, e.StatusCode, msg)
+}
+
+// errorEnvelope is the error body used by OpenAI-compatible servers and Gemini
+type errorEnvelope struct {
+	Error *struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Status  string          `json:"status"` // Gemini's equivalent of type
+		Code    json.RawMessage `json:"code"`
+	} `json:"error"`
+}
+
+// responseError builds an APIError from a failed response and its body
+func responseError(resp *http.Response, provider, body string) *APIError {
+	e := parseAPIError(resp.StatusCode, provider, body)
+	e.RetryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return e
+}
+
+// ParseRetryAfter converts a Retry-After header, either delay seconds or an
+// HTTP date, to a wait from now. Dates in the past and values it can't
+// parse give zero.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// RetryAfter returns the wait requested by the server that returned err,
+// or zero.
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// parseAPIError builds an APIError from a response body, falling back to the
+// raw text when it isn't the standard error envelope.
+func parseAPIError(status int, provider, body string) *APIError {
+	e := &APIError{StatusCode: status, Provider: provider, Message: body}
+
+	var envelope errorEnvelope
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil || envelope.Error == nil || envelope.Error.Message == "" {
+		return e
+	}
+
+	e.Message = envelope.Error.Message
+	e.Type = envelope.Error.Type
+	if e.Type == "" {
+		e.Type = envelope.Error.Status
+	}
+	// code is a string for OpenAI and a number for Gemini
+	if code := strings.Trim(string(envelope.Error.Code), `"`); code != "null" {
+		e.Code = code
+	}
+	return e
+}
+
+// ModelNotFoundError is returned when the provider rejects the configured model.
+type ModelNotFoundError struct {
+	Model       string
+	Provider    string
+	Suggestions []string
+	Detail      string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "model %q was not recognized by %s", e.Model, e.Provider)
+	if len(e.Suggestions) > 0 {
+		fmt.Fprintf(&b, "; did you mean %s?", strings.Join(e.Suggestions, ", "))
+	} else {
+		b.WriteString("; check the model name in your config")
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MaxRetryWait caps how long a failed request waits for the server's
+// Retry-After before the error is returned instead.
+const MaxRetryWait = 30 * time.Second
+
+// FallbackClient sends each request to its first client and moves down the
+// list while the error is retryable, such as a 429 or 5xx. If every client
+// fails and the last asked to retry within MaxRetryWait, it waits that long
+// and runs through the list once more. Each client keeps its own model.
+// Model, SetModel, Provider and ListModels use the first client.
+type FallbackClient struct {
+	clients []Client
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+var _ Client = (*FallbackClient)(nil)
+
+// NewFallbackClient wraps clients in order of preference.
+func NewFallbackClient(clients ...Client) (Client, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("fallback needs at least one client")
+	}
+	return &FallbackClient{clients: clients, sleep: sleepContext}, nil
+}
+
+func (f *FallbackClient) Model() string         { return f.clients[0].Model() }
+func (f *FallbackClient) SetModel(model string) { f.clients[0].SetModel(model) }
+func (f *FallbackClient) Provider() string      { return f.clients[0].Provider() }
+
+func (f *FallbackClient) ListModels(ctx context.Context) ([]string, error) {
+	return f.clients[0].ListModels(ctx)
+}
+
+// Ping checks the first client's provider, when it supports pinging.
+func (f *FallbackClient) Ping(ctx context.Context) error {
+	if p, ok := f.clients[0].(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return ErrNotSupported
+}
+
+func (f *FallbackClient) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := f.complete(ctx, req)
+	if wait, ok := retryWait(err); ok {
+		if err := f.sleep(ctx, wait); err != nil {
+			return resp, err
+		}
+		resp, err = f.complete(ctx, req)
+	}
+	return resp, err
+}
+
+// Stream falls back when the stream fails to open or its first event is a
+// retryable error. Once content has arrived the stream is not switched.
+func (f *FallbackClient) Stream(ctx context.Context, req ChatRequest) (<-chan StreamEvent, error) {
+	events, err := f.stream(ctx, req)
+	if wait, ok := retryWait(err); ok {
+		if err := f.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		events, err = f.stream(ctx, req)
+	}
+	return events, err
+}
+
+func (f *FallbackClient) complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	var resp ChatResponse
+	var err error
+	for i, c := range f.clients {
+		resp, err = c.Complete(ctx, f.request(req, i))
+		if err == nil || !IsRetryable(err) {
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+// stream tries each client in turn. A retryable error from the last one is
+// returned rather than sent on the stream, so Stream can wait and retry.
+func (f *FallbackClient) stream(ctx context.Context, req ChatRequest) (<-chan StreamEvent, error) {
+	var err error
+	for i, c := range f.clients {
+		var events <-chan StreamEvent
+		events, err = c.Stream(ctx, f.request(req, i))
+		if err != nil {
+			if IsRetryable(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		first, ok := <-events
+		if !ok {
+			return events, nil
+		}
+		if first.Type == StreamEventError && IsRetryable(first.Err) {
+			go drain(events)
+			err = first.Err
+			continue
+		}
+		return prepend(first, events), nil
+	}
+	return nil, err
+}
+
+// retryWait reports how long to wait before retrying after err: a
+// retryable error whose server asked for a delay no longer than MaxRetryWait.
+func retryWait(err error) (time.Duration, bool) {
+	wait := RetryAfter(err)
+	return wait, IsRetryable(err) && wait > 0 && wait <= MaxRetryWait
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// request returns req for client i. Fallback clients ignore the request's
+// model so each uses its own.
+func (f *FallbackClient) request(req ChatRequest, i int) ChatRequest {
+	if i > 0 {
+		req.Model = ""
+	}
+	return req
+}
+
+// prepend returns a channel yielding first and then everything from events
+func prepend(first StreamEvent, events <-chan StreamEvent) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		out <- first
+		for e := range events {
+			out <- e
+		}
+	}()
+	return out
+}
+
+func drain(events <-chan StreamEvent) {
+	for range events {
+	}
+}
@@ -0,0 +1,211 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// fallbackServers returns a server that always answers 503 and one that
+// answers with "from backup", recording the model it was asked for.
+func fallbackServers(t *testing.T, backupModel *string) (down, up *httptest.Server) {
+	t.Helper()
+	down = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+	}))
+	up = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		*backupModel = body.Model
+		if body.Stream {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"from backup\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"from backup"}}]}`)
+	}))
+	t.Cleanup(down.Close)
+	t.Cleanup(up.Close)
+	return down, up
+}
+
+func TestFallbackClientComplete(t *testing.T) {
+	var backupModel string
+	down, up := fallbackServers(t, &backupModel)
+
+	primary, _ := NewStandardClient(StandardClientConfig{BaseURL: down.URL, Model: "big"})
+	backup, _ := NewStandardClient(StandardClientConfig{BaseURL: up.URL, Model: "small"})
+	client, err := NewFallbackClient(primary, backup)
+	if err != nil {
+		t.Fatalf("NewFallbackClient() error: %v", err)
+	}
+
+	resp, err := client.Complete(context.Background(), ChatRequest{Model: "big"})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if resp.Content != "from backup" {
+		t.Errorf("Content = %q, want the fallback reply", resp.Content)
+	}
+	if backupModel != "small" {
+		t.Errorf("fallback should use its own model, got %q", backupModel)
+	}
+	if client.Model() != "big" {
+		t.Errorf("Model() = %q, want the primary's model", client.Model())
+	}
+}
+
+func TestFallbackClientStream(t *testing.T) {
+	var backupModel string
+	down, up := fallbackServers(t, &backupModel)
+
+	primary, _ := NewStandardClient(StandardClientConfig{BaseURL: down.URL, Model: "big"})
+	backup, _ := NewStandardClient(StandardClientConfig{BaseURL: up.URL, Model: "small"})
+	client, _ := NewFallbackClient(primary, backup)
+
+	events, err := client.Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	content, _, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if content != "from backup" {
+		t.Errorf("content = %q, want the fallback reply", content)
+	}
+}
+
+func TestFallbackClientStopsOnPermanentError(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	primary, _ := NewStandardClient(StandardClientConfig{BaseURL: srv.URL, Model: "a"})
+	backup, _ := NewStandardClient(StandardClientConfig{BaseURL: srv.URL, Model: "b"})
+	client, _ := NewFallbackClient(primary, backup)
+
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err == nil {
+		t.Fatal("expected the 401 to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("a non-retryable error should not fall back, got %d calls", calls)
+	}
+}
+
+func TestRegistryBuildActiveFallback(t *testing.T) {
+	cfg := &config.Config{
+		Provider: "openai",
+		Fallback: []string{"openai", "ollama"},
+		Providers: map[string]config.Provider{
+			"openai": {BaseURL: "https://api.openai.com/v1", Model: "gpt-4o"},
+			"ollama": {Model: "llama3"},
+		},
+	}
+
+	client, err := NewRegistry().BuildActive(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildActive() error: %v", err)
+	}
+	fb, ok := client.(*FallbackClient)
+	if !ok || len(fb.clients) != 2 {
+		t.Fatalf("expected a fallback over openai and ollama, got %T", client)
+	}
+	if fb.clients[1].Provider() != "ollama" || fb.clients[1].Model() != "llama3" {
+		t.Errorf("unexpected fallback client %s/%s", fb.clients[1].Provider(), fb.clients[1].Model())
+	}
+
+	cfg.Fallback = nil
+	if client, _ := NewRegistry().BuildActive(cfg, nil); client.Provider() != "openai" {
+		t.Errorf("without fallbacks the primary client is used, got %s", client.Provider())
+	}
+
+	cfg.Fallback = []string{"missing"}
+	if _, err := NewRegistry().BuildActive(cfg, nil); err == nil {
+		t.Error("expected an error for an unknown fallback provider")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-3", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 11:59:00 GMT", 0}, // already passed
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFallbackClientWaitsForRetryAfter(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "2")
+			http.Error(w, `{"error":{"message":"slow down"}}`, http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	primary, _ := NewStandardClient(StandardClientConfig{BaseURL: srv.URL, Model: "m"})
+	client, _ := NewFallbackClient(primary)
+	var waited time.Duration
+	client.(*FallbackClient).sleep = func(ctx context.Context, d time.Duration) error {
+		waited += d
+		return nil
+	}
+
+	resp, err := client.Complete(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if resp.Content != "ok" || calls != 2 {
+		t.Errorf("expected a retry to succeed, got %q after %d calls", resp.Content, calls)
+	}
+	if waited != 2*time.Second {
+		t.Errorf("expected to wait the Retry-After delay, waited %v", waited)
+	}
+}
+
+func TestFallbackClientReturnsLongRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		http.Error(w, `{"error":{"message":"quota exceeded"}}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	primary, _ := NewStandardClient(StandardClientConfig{BaseURL: srv.URL, Model: "m"})
+	client, _ := NewFallbackClient(primary)
+	client.(*FallbackClient).sleep = func(ctx context.Context, d time.Duration) error {
+		t.Errorf("should not wait %v beyond MaxRetryWait", d)
+		return nil
+	}
+
+	_, err := client.Complete(context.Background(), ChatRequest{})
+	if wait := RetryAfter(err); wait < 59*time.Minute || wait > time.Hour {
+		t.Errorf("expected the error to carry the date's delay, got %v (%v)", wait, err)
+	}
+}
@@ -0,0 +1,397 @@
+package ai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultGeminiBaseURL is the public Gemini API endpoint.
+const DefaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiClientConfig defines the parameters for the Gemini generateContent API.
+type GeminiClientConfig struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	HTTPClient *http.Client
+	Logger     *log.Logger // Optional; logs requests and responses with credentials redacted
+
+	// Defaults for requests that don't set them; zero uses the provider default
+	Temperature float32
+	MaxTokens   int
+	Stop        []string
+}
+
+// GeminiClient talks to Google's Gemini API, which uses its own request
+// and response shape instead of OpenAI chat completions.
+type GeminiClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+
+	temperature float32
+	maxTokens   int
+	stop        []string
+}
+
+var _ Client = (*GeminiClient)(nil)
+
+// NewGeminiClient creates a new Gemini client.
+func NewGeminiClient(cfg GeminiClientConfig) (Client, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("api key is required")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultGeminiBaseURL
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 60 * time.Second}
+	}
+	hc = WithLogging(hc, cfg.Logger)
+
+	return &GeminiClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		model:      strings.TrimPrefix(cfg.Model, "models/"),
+		httpClient: hc,
+
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+		stop:        cfg.Stop,
+	}, nil
+}
+
+func (c *GeminiClient) Model() string         { return c.model }
+func (c *GeminiClient) SetModel(model string) { c.model = strings.TrimPrefix(model, "models/") }
+func (c *GeminiClient) Provider() string      { return "gemini" }
+
+func (c *GeminiClient) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := c.post(ctx, req, "generateContent")
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return ChatResponse{}, err
+	}
+	if len(parsed.Candidates) == 0 {
+		return ChatResponse{}, errors.New("no candidates returned")
+	}
+
+	candidate := parsed.Candidates[0]
+	result := ChatResponse{
+		Content:      candidate.text(),
+		FinishReason: geminiFinishReason(candidate.FinishReason),
+	}
+	if strings.TrimSpace(result.Content) == "" {
+		return result, ErrEmptyResponse
+	}
+	return result, nil
+}
+
+// Stream reads streamGenerateContent, which returns a JSON array whose
+// elements arrive incrementally.
+func (c *GeminiClient) Stream(ctx context.Context, req ChatRequest) (<-chan StreamEvent, error) {
+	resp, err := c.post(ctx, req, "streamGenerateContent")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		var usage *Usage
+		var finishReason string
+
+		dec := json.NewDecoder(resp.Body)
+		if _, err := dec.Token(); err != nil { // opening [
+			out <- StreamEvent{Type: StreamEventError, Err: streamErr(err)}
+			return
+		}
+
+		for dec.More() {
+			var chunk geminiResponse
+			if err := dec.Decode(&chunk); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					out <- StreamEvent{Type: StreamEventError, Err: streamErr(err)}
+				}
+				return
+			}
+
+			if u := chunk.UsageMetadata.usage(); u != nil {
+				usage = u
+			}
+			for _, candidate := range chunk.Candidates {
+				if text := candidate.text(); text != "" {
+					out <- StreamEvent{Type: StreamEventChunk, Content: text}
+				}
+				if candidate.FinishReason != "" {
+					finishReason = geminiFinishReason(candidate.FinishReason)
+				}
+			}
+		}
+
+		out <- StreamEvent{Type: StreamEventDone, Usage: usage, FinishReason: finishReason}
+	}()
+
+	return out, nil
+}
+
+// ListModels returns the models that support generateContent, without the
+// "models/" prefix.
+func (c *GeminiClient) ListModels(ctx context.Context) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/models?key=%s&pageSize=1000", c.baseURL, url.QueryEscape(c.apiKey))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, c.redact(err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, c.redact(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list models: status %d", resp.StatusCode)
+	}
+
+	var parsed struct {
+		Models []struct {
+			Name    string   `json:"name"`
+			Methods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	var models []string
+	for _, m := range parsed.Models {
+		if slices.Contains(m.Methods, "generateContent") {
+			models = append(models, strings.TrimPrefix(m.Name, "models/"))
+		}
+	}
+	return models, nil
+}
+
+// post sends a request to the given model method and checks the status.
+func (c *GeminiClient) post(ctx context.Context, req ChatRequest, method string) (*http.Response, error) {
+	body, err := json.Marshal(c.toPayload(req))
+	if err != nil {
+		return nil, err
+	}
+
+	model := req.Model
+	if model == "" {
+		model = c.model
+	}
+	endpoint := fmt.Sprintf("%s/models/%s:%s?key=%s",
+		c.baseURL, url.PathEscape(model), method, url.QueryEscape(c.apiKey))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, c.redact(err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, c.redact(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		detail := strings.TrimSpace(string(b))
+		if isModelNotFound(resp.StatusCode, detail) {
+			return nil, &ModelNotFoundError{Model: model, Provider: c.Provider(), Detail: detail}
+		}
+		return nil, responseError(resp, c.Provider(), detail)
+	}
+
+	return resp, nil
+}
+
+// redact removes the API key from errors that echo the request URL.
+func (c *GeminiClient) redact(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, url.QueryEscape(c.apiKey), "REDACTED")
+	}
+	return err
+}
+
+// toPayload maps chat messages to Gemini contents. System messages become
+// the system instruction, assistant turns use the "model" role, and
+// consecutive turns from the same role are merged.
+func (c *GeminiClient) toPayload(req ChatRequest) geminiRequest {
+	var payload geminiRequest
+	var system []geminiPart
+
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			system = append(system, geminiPart{Text: m.Content})
+			continue
+		}
+
+		role := "user"
+		if m.Role == "assistant" {
+			role = "model"
+		}
+
+		parts := []geminiPart{{Text: m.Content}}
+		for _, image := range m.Images {
+			if data := geminiInlineImage(image); data != nil {
+				parts = append(parts, geminiPart{InlineData: data})
+			}
+		}
+
+		if n := len(payload.Contents); n > 0 && payload.Contents[n-1].Role == role {
+			payload.Contents[n-1].Parts = append(payload.Contents[n-1].Parts, parts...)
+			continue
+		}
+		payload.Contents = append(payload.Contents, geminiContent{
+			Role:  role,
+			Parts: parts,
+		})
+	}
+
+	if len(system) > 0 {
+		payload.SystemInstruction = &geminiContent{Parts: system}
+	}
+	temp := temperature(req.Temperature, c.temperature)
+	maxTokens := cmp.Or(req.MaxTokens, c.maxTokens)
+	stop := req.Stop
+	if len(stop) == 0 {
+		stop = c.stop
+	}
+	if temp != nil || maxTokens != 0 || len(stop) > 0 {
+		payload.GenerationConfig = &geminiGenerationConfig{
+			Temperature:     temp,
+			MaxOutputTokens: maxTokens,
+			StopSequences:   stop,
+		}
+	}
+
+	return payload
+}
+
+// geminiInlineImage converts a base64 data URL to inline data. Gemini can't
+// fetch image URLs itself, so anything else is skipped.
+func geminiInlineImage(image string) *geminiInlineData {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(image, "data:"), ",")
+	if !ok || !strings.HasPrefix(image, "data:") {
+		return nil
+	}
+	mimeType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !isBase64 {
+		return nil
+	}
+	return &geminiInlineData{MimeType: mimeType, Data: data}
+}
+
+// geminiFinishReason maps Gemini's finish reasons onto the OpenAI names.
+func geminiFinishReason(reason string) string {
+	switch reason {
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return FinishReasonLength
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+func streamErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("stream ended unexpectedly: %w", err)
+	}
+	return err
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text       string            `json:"text,omitempty"`
+	InlineData *geminiInlineData `json:"inlineData,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     *float32 `json:"temperature,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates    []geminiCandidate    `json:"candidates"`
+	UsageMetadata *geminiUsageMetadata `json:"usageMetadata"`
+}
+
+type geminiCandidate struct {
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+// text joins the candidate's text parts
+func (c geminiCandidate) text() string {
+	var b strings.Builder
+	for _, p := range c.Content.Parts {
+		b.WriteString(p.Text)
+	}
+	return b.String()
+}
+
+type geminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+func (u *geminiUsageMetadata) usage() *Usage {
+	if u == nil {
+		return nil
+	}
+	return &Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: u.CandidatesTokenCount,
+		TotalTokens:      u.TotalTokenCount,
+	}
+}
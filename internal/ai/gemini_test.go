@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newGeminiTestClient(t *testing.T, srv *httptest.Server) Client {
+	t.Helper()
+
+	client, err := NewGeminiClient(GeminiClientConfig{
+		BaseURL: srv.URL,
+		APIKey:  "secret-key",
+		Model:   "gemini-test",
+	})
+	if err != nil {
+		t.Fatalf("NewGeminiClient() error: %v", err)
+	}
+	return client
+}
+
+func TestGeminiComplete(t *testing.T) {
+	var path, key string
+	var body geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		key = r.URL.Query().Get("key")
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"},{"text":" there"}]},"finishReason":"MAX_TOKENS"}]}`)
+	}))
+	defer srv.Close()
+
+	resp, err := newGeminiTestClient(t, srv).Complete(context.Background(), ChatRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "hi"},
+			{Role: "assistant", Content: "hey"},
+			{Role: "user", Content: "again"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	if path != "/models/gemini-test:generateContent" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if key != "secret-key" {
+		t.Errorf("expected api key as query parameter, got %q", key)
+	}
+	if resp.Content != "Hello there" {
+		t.Errorf("expected joined parts, got %q", resp.Content)
+	}
+	if resp.FinishReason != FinishReasonLength {
+		t.Errorf("expected MAX_TOKENS to map to length, got %q", resp.FinishReason)
+	}
+
+	if body.SystemInstruction == nil || body.SystemInstruction.Parts[0].Text != "Be brief." {
+		t.Errorf("system prompt should become systemInstruction, got %+v", body.SystemInstruction)
+	}
+	if len(body.Contents) != 3 || body.Contents[1].Role != "model" {
+		t.Errorf("expected user/model/user contents, got %+v", body.Contents)
+	}
+}
+
+func TestGeminiStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ":streamGenerateContent") {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "[{\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hel\"}]}}]}\n")
+		fmt.Fprint(w, ",\r\n{\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"lo\"}]},\"finishReason\":\"STOP\"}],")
+		fmt.Fprint(w, "\"usageMetadata\":{\"promptTokenCount\":4,\"candidatesTokenCount\":2,\"totalTokenCount\":6}}\n]")
+	}))
+	defer srv.Close()
+
+	events, err := newGeminiTestClient(t, srv).Stream(context.Background(), ChatRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	content, last, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if content != "Hello" {
+		t.Errorf("expected 'Hello', got %q", content)
+	}
+	if last.Type != StreamEventDone || last.FinishReason != "stop" {
+		t.Errorf("expected done event with stop reason, got %+v", last)
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 6 {
+		t.Errorf("expected usage with 6 tokens, got %+v", last.Usage)
+	}
+}
+
+func TestGeminiErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error":{"message":"API key not valid"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := newGeminiTestClient(t, srv).Stream(context.Background(), ChatRequest{})
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("expected status error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("error should not leak the api key: %v", err)
+	}
+}
+
+func TestGeminiListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.URL.Query().Get("key") != "secret-key" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"models":[
+			{"name":"models/gemini-1.5-pro","supportedGenerationMethods":["generateContent","countTokens"]},
+			{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]}
+		]}`)
+	}))
+	defer srv.Close()
+
+	models, err := newGeminiTestClient(t, srv).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error: %v", err)
+	}
+	if len(models) != 1 || models[0] != "gemini-1.5-pro" {
+		t.Errorf("expected only chat models without the prefix, got %v", models)
+	}
+}
+
+func TestGeminiPayloadImages(t *testing.T) {
+	c := &GeminiClient{model: "gemini-test"}
+	payload := c.toPayload(ChatRequest{Messages: []ChatMessage{{
+		Role:    "user",
+		Content: "what is this?",
+		Images:  []string{"data:image/png;base64,iVBOR", "https://example.com/cat.png"},
+	}}})
+
+	parts := payload.Contents[0].Parts
+	if len(parts) != 2 || parts[0].Text != "what is this?" {
+		t.Fatalf("expected text plus one inline image, got %+v", parts)
+	}
+	if data := parts[1].InlineData; data == nil || data.MimeType != "image/png" || data.Data != "iVBOR" {
+		t.Errorf("unexpected inline data %+v", data)
+	}
+}
+
+func TestGeminiPayloadZeroTemperature(t *testing.T) {
+	c := &GeminiClient{model: "gemini-test", temperature: 0.8}
+	zero := float32(0)
+
+	payload := c.toPayload(ChatRequest{Temperature: &zero})
+	if gc := payload.GenerationConfig; gc == nil || gc.Temperature == nil || *gc.Temperature != 0 {
+		t.Errorf("a requested temperature of 0 should be sent, got %+v", gc)
+	}
+
+	c.temperature = 0
+	if gc := c.toPayload(ChatRequest{}).GenerationConfig; gc != nil {
+		t.Errorf("no generation config expected without settings, got %+v", gc)
+	}
+}
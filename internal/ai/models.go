@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrNotSupported is returned for features a provider doesn't offer, such
+// as a models endpoint.
+var ErrNotSupported = errors.New("not supported by this provider")
+
+// ListModels queries the OpenAI-compatible /models endpoint.
+func (c *StandardClient) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint("/models"), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.applyHeaders(httpReq)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, fmt.Errorf("list models: %s: %w", c.provider, ErrNotSupported)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("list models: status %d", resp.StatusCode)
+	}
+
+	var parsed struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(parsed.Data))
+	for _, m := range parsed.Data {
+		if m.ID != "" {
+			models = append(models, m.ID)
+		}
+	}
+	return models, nil
+}
+
+// isModelNotFound reports whether an error response looks like the provider
+// rejected the requested model name.
+func isModelNotFound(status int, body string) bool {
+	if status != http.StatusNotFound && status != http.StatusBadRequest {
+		return false
+	}
+
+	lower := strings.ToLower(body)
+	if !strings.Contains(lower, "model") {
+		return false
+	}
+	for _, hint := range []string{"not found", "does not exist", "not exist", "unknown", "invalid", "no such", "not a valid"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestModels returns up to max entries from available that closely
+// resemble target, best matches first.
+func suggestModels(target string, available []string, max int) []string {
+	target = strings.ToLower(target)
+
+	type candidate struct {
+		name  string
+		score int
+	}
+
+	var candidates []candidate
+	for _, name := range available {
+		lower := strings.ToLower(name)
+		dist := levenshtein(target, lower)
+
+		switch {
+		case strings.Contains(lower, target) || strings.Contains(target, lower):
+			// Substring matches rank ahead of everything else
+			candidates = append(candidates, candidate{name, dist - len(target)})
+		case dist <= len(target)/3+1:
+			candidates = append(candidates, candidate{name, dist})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score < candidates[j].score
+	})
+
+	var out []string
+	for i := 0; i < len(candidates) && i < max; i++ {
+		out = append(out, candidates[i].name)
+	}
+	return out
+}
+
+// levenshtein computes the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
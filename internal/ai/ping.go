@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Pinger is implemented by clients that can check the provider is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the provider responds. It requests the configured health path,
+// or /models when none is set; any 2xx status counts as healthy.
+func (c *StandardClient) Ping(ctx context.Context) error {
+	path := c.healthPath
+	if path == "" {
+		path = "/models"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint(path), nil)
+	if err != nil {
+		return err
+	}
+	c.applyHeaders(httpReq)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("ping %s: %w", c.provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("ping %s: %s returned status %d", c.provider, path, resp.StatusCode)
+	}
+	return nil
+}
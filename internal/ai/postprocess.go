@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PostProcessor transforms completed assistant output.
+type PostProcessor func(content string) string
+
+// postProcessors holds the built-in processors by config name.
+var postProcessors = map[string]PostProcessor{
+	"strip_think":    StripThink,
+	"unwrap_fence":   UnwrapFence,
+	"trim_apologies": TrimApologies,
+	"trim":           strings.TrimSpace,
+}
+
+// Pipeline applies post-processors in order.
+type Pipeline []PostProcessor
+
+// NewPipeline builds a pipeline from built-in processor names.
+func NewPipeline(names []string) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(names))
+	for _, name := range names {
+		p, ok := postProcessors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown post-processor %q", name)
+		}
+		pipeline = append(pipeline, p)
+	}
+	return pipeline, nil
+}
+
+// Apply runs content through every processor in the pipeline.
+func (p Pipeline) Apply(content string) string {
+	for _, fn := range p {
+		content = fn(content)
+	}
+	return content
+}
+
+var thinkPattern = regexp.MustCompile(`(?s)<think>.*?(</think>|$)`)
+
+// StripThink removes <think>...</think> reasoning blocks.
+func StripThink(content string) string {
+	return strings.TrimLeft(thinkPattern.ReplaceAllString(content, ""), "\n")
+}
+
+var fencePattern = regexp.MustCompile("(?s)^```[^\n`]*\n(.*?)\n?```$")
+
+// UnwrapFence returns the body of content that is a single top-level code fence.
+func UnwrapFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	m := fencePattern.FindStringSubmatch(trimmed)
+	if m == nil || strings.Contains(m[1], "```") {
+		return content
+	}
+	return m[1]
+}
+
+var apologyPattern = regexp.MustCompile(`(?i)^(i apologi[sz]e|i'm sorry|i am sorry|sorry|apologies)\b`)
+
+// TrimApologies drops trailing paragraphs that are only apologies.
+func TrimApologies(content string) string {
+	paragraphs := strings.Split(strings.TrimRight(content, " \n"), "\n\n")
+	for len(paragraphs) > 1 && apologyPattern.MatchString(strings.TrimSpace(paragraphs[len(paragraphs)-1])) {
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
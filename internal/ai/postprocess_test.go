@@ -0,0 +1,59 @@
+package ai
+
+import "testing"
+
+func TestStripThink(t *testing.T) {
+	in := "<think>\nlet me reason\n</think>\n\nThe answer is 42."
+	if got := StripThink(in); got != "The answer is 42." {
+		t.Errorf("StripThink() = %q", got)
+	}
+
+	unterminated := "Answer first<think>never closed"
+	if got := StripThink(unterminated); got != "Answer first" {
+		t.Errorf("StripThink() unterminated = %q", got)
+	}
+}
+
+func TestUnwrapFence(t *testing.T) {
+	in := "```go\nfunc main() {}\n```"
+	if got := UnwrapFence(in); got != "func main() {}" {
+		t.Errorf("UnwrapFence() = %q", got)
+	}
+
+	mixed := "Here:\n```go\nx := 1\n```"
+	if got := UnwrapFence(mixed); got != mixed {
+		t.Errorf("UnwrapFence() should leave prose untouched, got %q", got)
+	}
+
+	two := "```\na\n```\n\n```\nb\n```"
+	if got := UnwrapFence(two); got != two {
+		t.Errorf("UnwrapFence() should leave multiple fences untouched, got %q", got)
+	}
+}
+
+func TestTrimApologies(t *testing.T) {
+	in := "Use a map.\n\nI apologize for any confusion earlier."
+	if got := TrimApologies(in); got != "Use a map." {
+		t.Errorf("TrimApologies() = %q", got)
+	}
+
+	only := "Sorry, I can't help with that."
+	if got := TrimApologies(only); got != only {
+		t.Errorf("TrimApologies() should keep a lone paragraph, got %q", got)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p, err := NewPipeline([]string{"strip_think", "trim"})
+	if err != nil {
+		t.Fatalf("NewPipeline() error: %v", err)
+	}
+
+	if got := p.Apply("<think>hmm</think>  done  \n"); got != "done" {
+		t.Errorf("Apply() = %q", got)
+	}
+
+	if _, err := NewPipeline([]string{"nope"}); err == nil {
+		t.Error("expected error for unknown post-processor")
+	}
+}
@@ -2,7 +2,10 @@ package ai
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/kbesada/flux-code-cli/internal/config"
 )
@@ -10,6 +13,12 @@ import (
 // Registry builds AI clients based on config.
 type Registry struct {
 	constructors map[string]func(cfg config.Provider, httpClient *http.Client) (Client, error)
+	logger       *log.Logger
+
+	// Pool shared by clients built without an explicit http.Client
+	transport    *http.Transport
+	transportCfg config.HTTPConfig
+	proxied      map[string]*http.Transport // by proxy_url
 }
 
 // NewRegistry creates a registry with default constructors.
@@ -23,6 +32,13 @@ func NewRegistry() *Registry {
 					Model:      p.Model,
 					Provider:   "custom",
 					HTTPClient: hc,
+
+					Temperature: p.Temperature,
+					MaxTokens:   p.MaxTokens,
+					Stop:        p.Stop,
+					HealthPath:  p.HealthPath,
+					Headers:     p.Headers,
+					Quirks:      p.Quirks,
 				})
 			},
 			"openai": func(p config.Provider, hc *http.Client) (Client, error) {
@@ -32,6 +48,13 @@ func NewRegistry() *Registry {
 					Model:      p.Model,
 					Provider:   "openai",
 					HTTPClient: hc,
+
+					Temperature: p.Temperature,
+					MaxTokens:   p.MaxTokens,
+					Stop:        p.Stop,
+					HealthPath:  p.HealthPath,
+					Headers:     p.Headers,
+					Quirks:      p.Quirks,
 				})
 			},
 			"ollama": func(p config.Provider, hc *http.Client) (Client, error) {
@@ -46,27 +69,124 @@ func NewRegistry() *Registry {
 					Model:      p.Model,
 					Provider:   "ollama",
 					HTTPClient: hc,
+
+					Temperature: p.Temperature,
+					MaxTokens:   p.MaxTokens,
+					Stop:        p.Stop,
+					HealthPath:  p.HealthPath,
+					Headers:     p.Headers,
+					Quirks:      p.Quirks,
 				})
 			},
 			"openrouter": func(p config.Provider, hc *http.Client) (Client, error) {
+				baseURL := p.BaseURL
+				if baseURL == "" {
+					baseURL = "https://openrouter.ai/api/v1"
+				}
 				return NewStandardClient(StandardClientConfig{
-					BaseURL:    "https://openrouter.ai/api/v1",
+					BaseURL:    baseURL,
 					APIKey:     p.APIKey,
 					Model:      p.Model,
 					Provider:   "openrouter",
 					HTTPClient: hc,
+
+					Temperature: p.Temperature,
+					MaxTokens:   p.MaxTokens,
+					Stop:        p.Stop,
+					HealthPath:  p.HealthPath,
+					Headers:     mergeHeaders(openRouterHeaders, p.Headers),
+					Quirks:      p.Quirks,
+				})
+			},
+			"azure":   newAzureClient,
+			"bedrock": newBedrockClient,
+			"gemini": func(p config.Provider, hc *http.Client) (Client, error) {
+				return NewGeminiClient(GeminiClientConfig{
+					BaseURL:    p.BaseURL,
+					APIKey:     p.APIKey,
+					Model:      p.Model,
+					HTTPClient: hc,
+
+					Temperature: p.Temperature,
+					MaxTokens:   p.MaxTokens,
+					Stop:        p.Stop,
 				})
 			},
 		},
 	}
 }
 
+// openRouterHeaders identify the app to OpenRouter, which recommends them
+// for attribution in its rankings.
+var openRouterHeaders = map[string]string{
+	"HTTP-Referer": "https://github.com/KBesada24/flux-code-cli",
+	"X-Title":      "flux",
+}
+
+// mergeHeaders returns defaults overlaid with configured headers. Names are
+// canonicalized first, since config keys arrive lowercased.
+func mergeHeaders(defaults, configured map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(configured))
+	for _, h := range []map[string]string{defaults, configured} {
+		for name, value := range h {
+			merged[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return merged
+}
+
+// DefaultAzureAPIVersion is used when an azure provider omits api_version.
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// newAzureClient targets an Azure OpenAI deployment. base_url is the
+// resource endpoint; requests go to {endpoint}/openai/deployments/{deployment}
+// with an api-key header and api-version query parameter.
+func newAzureClient(p config.Provider, hc *http.Client) (Client, error) {
+	if p.BaseURL == "" || p.Deployment == "" {
+		return nil, fmt.Errorf("azure requires base_url and deployment")
+	}
+
+	apiVersion := p.APIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+	model := p.Model
+	if model == "" {
+		model = p.Deployment
+	}
+
+	return NewStandardClient(StandardClientConfig{
+		BaseURL:    strings.TrimRight(p.BaseURL, "/") + "/openai/deployments/" + url.PathEscape(p.Deployment),
+		APIKey:     p.APIKey,
+		AuthHeader: "api-key",
+		Model:      model,
+		Provider:   "azure",
+		HTTPClient: hc,
+		Query:      url.Values{"api-version": {apiVersion}},
+
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxTokens,
+		Stop:        p.Stop,
+		HealthPath:  p.HealthPath,
+		Headers:     p.Headers,
+		Quirks:      p.Quirks,
+	})
+}
+
 // Register adds/overrides a constructor for a provider key.
 func (r *Registry) Register(name string, ctor func(cfg config.Provider, httpClient *http.Client) (Client, error)) {
 	r.constructors[name] = ctor
 }
 
-// Build creates a client for the given provider name using config and optional http.Client.
+// SetLogger makes clients built afterwards log their HTTP traffic, with
+// credentials redacted. A nil logger turns logging off.
+func (r *Registry) SetLogger(logger *log.Logger) {
+	r.logger = logger
+}
+
+// Build creates a client for the given provider name using config and
+// optional http.Client. Without one, clients share a pooled transport tuned
+// by cfg.HTTP.
 func (r *Registry) Build(providerName string, cfg *config.Config, hc *http.Client) (Client, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config is nil")
@@ -77,6 +197,14 @@ func (r *Registry) Build(providerName string, cfg *config.Config, hc *http.Clien
 		return nil, fmt.Errorf("provider %q not found in config", providerName)
 	}
 
+	if hc == nil {
+		var err error
+		if hc, err = r.sharedClient(cfg.HTTP, provCfg.ProxyURL); err != nil {
+			return nil, fmt.Errorf("provider %q: %w", providerName, err)
+		}
+	}
+	hc = WithLogging(hc, r.logger, headerNames(provCfg.Headers, provCfg.AuthHeader)...)
+
 	ctor, ok := r.constructors[providerName]
 	if !ok {
 		// fallback to custom if defined
@@ -88,3 +216,29 @@ func (r *Registry) Build(providerName string, cfg *config.Config, hc *http.Clien
 
 	return ctor(provCfg, hc)
 }
+
+// BuildActive builds the client for cfg.Provider, wrapped in a
+// FallbackClient that tries any providers named by cfg.Fallback in order
+// and waits out short Retry-After delays.
+func (r *Registry) BuildActive(cfg *config.Config, hc *http.Client) (Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+	primary, err := r.Build(cfg.Provider, cfg, hc)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := []Client{primary}
+	for _, name := range cfg.Fallback {
+		if name == cfg.Provider {
+			continue
+		}
+		client, err := r.Build(name, cfg, hc)
+		if err != nil {
+			return nil, fmt.Errorf("fallback %s: %w", name, err)
+		}
+		clients = append(clients, client)
+	}
+	return NewFallbackClient(clients...)
+}
@@ -0,0 +1,277 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+func TestRegistryBuildsAzureClient(t *testing.T) {
+	var path, apiVersion, apiKey, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		apiVersion = r.URL.Query().Get("api-version")
+		apiKey = r.Header.Get("api-key")
+		auth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"azure": {
+			BaseURL:    srv.URL + "/",
+			APIKey:     "azure-key",
+			Deployment: "gpt-4o-prod",
+			APIVersion: "2024-02-01",
+		},
+	}}
+
+	client, err := NewRegistry().Build("azure", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	if path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if apiVersion != "2024-02-01" {
+		t.Errorf("expected api-version query param, got %q", apiVersion)
+	}
+	if apiKey != "azure-key" || auth != "" {
+		t.Errorf("expected bare api-key header and no Authorization, got api-key=%q auth=%q", apiKey, auth)
+	}
+	if client.Model() != "gpt-4o-prod" {
+		t.Errorf("model should default to the deployment, got %q", client.Model())
+	}
+}
+
+func TestRegistryAzureRequiresDeployment(t *testing.T) {
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"azure": {BaseURL: "https://example.openai.azure.com"},
+	}}
+
+	if _, err := NewRegistry().Build("azure", cfg, nil); err == nil {
+		t.Error("expected error without a deployment")
+	}
+}
+
+func TestRegistryAppliesGenerationDefaults(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"ollama": {BaseURL: srv.URL, Model: "llama3", Temperature: 0.25, MaxTokens: 512},
+	}}
+	client, err := NewRegistry().Build("ollama", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if payload["temperature"] != 0.25 || payload["max_tokens"] != 512.0 {
+		t.Errorf("expected configured defaults in the payload, got temperature=%v max_tokens=%v",
+			payload["temperature"], payload["max_tokens"])
+	}
+
+	// Per-request values win over the configured defaults
+	if _, err := client.Complete(context.Background(), ChatRequest{MaxTokens: 20}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if payload["max_tokens"] != 20.0 || payload["temperature"] != 0.25 {
+		t.Errorf("expected max_tokens 20 with the default temperature, got %v", payload)
+	}
+}
+
+func TestRegistryOmitsUnsetGeneration(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"ollama": {BaseURL: srv.URL, Model: "llama3"},
+	}}
+	client, err := NewRegistry().Build("ollama", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if _, ok := payload["temperature"]; ok {
+		t.Error("unset temperature should be omitted so the provider default applies")
+	}
+	if _, ok := payload["max_tokens"]; ok {
+		t.Error("unset max_tokens should be omitted so the provider default applies")
+	}
+}
+
+func TestPingUsesHealthPath(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v1/healthz" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"gateway": {BaseURL: srv.URL + "/v1", Model: "m", HealthPath: "healthz"},
+		"plain":   {BaseURL: srv.URL + "/v1", Model: "m"},
+	}}
+
+	client, err := NewRegistry().Build("gateway", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if err := client.(Pinger).Ping(context.Background()); err != nil {
+		t.Errorf("a 200 from the health path should be healthy, got %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/v1/healthz" {
+		t.Errorf("expected a single request to /v1/healthz, got %v", paths)
+	}
+
+	paths = nil
+	client, err = NewRegistry().Build("plain", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if err := client.(Pinger).Ping(context.Background()); err == nil {
+		t.Error("a 404 from /models should be unhealthy")
+	}
+	if len(paths) != 1 || paths[0] != "/v1/models" {
+		t.Errorf("without health_path Ping should check /models, got %v", paths)
+	}
+}
+
+func TestRegistryOpenRouterBaseURL(t *testing.T) {
+	var path string
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		headers = r.Header.Clone()
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"openrouter": {
+			BaseURL: srv.URL + "/api/v1",
+			Model:   "anthropic/claude-3-haiku",
+			Headers: map[string]string{"x-title": "my-app"},
+		},
+	}}
+
+	client, err := NewRegistry().Build("openrouter", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	if path != "/api/v1/chat/completions" {
+		t.Errorf("expected the configured base URL to be used, got path %q", path)
+	}
+	if headers.Get("HTTP-Referer") != openRouterHeaders["HTTP-Referer"] {
+		t.Errorf("expected default HTTP-Referer, got %q", headers.Get("HTTP-Referer"))
+	}
+	if headers.Get("X-Title") != "my-app" {
+		t.Errorf("configured X-Title should override the default, got %q", headers.Get("X-Title"))
+	}
+}
+
+func TestRegistrySharesTransportAndReusesConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Provider: "local",
+		Providers: map[string]config.Provider{
+			"local":  {BaseURL: srv.URL, Model: "m"},
+			"second": {BaseURL: srv.URL, Model: "m"},
+		},
+	}
+
+	r := NewRegistry()
+	var dials atomic.Int32
+	r.sharedClient(cfg.HTTP, "")
+	dial := r.transport.DialContext
+	r.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return dial(ctx, network, addr)
+	}
+
+	first, err := r.Build("local", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	second, err := r.Build("second", cfg, nil)
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	for _, client := range []Client{first, first, second} {
+		if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+			t.Fatalf("Complete() error: %v", err)
+		}
+	}
+	if n := dials.Load(); n != 1 {
+		t.Errorf("expected sequential requests to reuse one connection, dialed %d times", n)
+	}
+}
+
+func TestRegistryProviderProxyURL(t *testing.T) {
+	r := NewRegistry()
+	hc, err := r.sharedClient(config.HTTPConfig{}, "http://proxy.example:3128")
+	if err != nil {
+		t.Fatalf("sharedClient() error: %v", err)
+	}
+
+	transport, ok := hc.Transport.(*http.Transport)
+	if !ok || transport == r.transport {
+		t.Fatalf("expected a separate transport for the proxy, got %T", hc.Transport)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1/models", nil)
+	proxy, err := transport.Proxy(req)
+	if err != nil || proxy == nil || proxy.String() != "http://proxy.example:3128" {
+		t.Errorf("Proxy() = %v, %v; want http://proxy.example:3128", proxy, err)
+	}
+
+	again, _ := r.sharedClient(config.HTTPConfig{}, "http://proxy.example:3128")
+	if again.Transport != transport {
+		t.Error("providers with the same proxy should share a transport")
+	}
+
+	cfg := &config.Config{
+		Provider:  "local",
+		Providers: map[string]config.Provider{"local": {BaseURL: "http://localhost", Model: "m", ProxyURL: "::bad"}},
+	}
+	if _, err := r.Build("local", cfg, nil); err == nil || !strings.Contains(err.Error(), "proxy_url") {
+		t.Errorf("expected an invalid proxy_url error, got %v", err)
+	}
+}
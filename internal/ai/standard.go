@@ -3,14 +3,19 @@ package ai
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
 )
 
 // StandardClientConfig defines the parameters for any OpenAI-compatible endpoint.
@@ -22,6 +27,24 @@ type StandardClientConfig struct {
 	Model      string
 	Provider   string
 	HTTPClient *http.Client
+	Logger     *log.Logger // Optional; logs requests and responses with credentials redacted
+
+	// Query is appended to every request URL (e.g. Azure's api-version)
+	Query url.Values
+
+	// HealthPath is requested by Ping instead of /models, e.g. "/healthz"
+	HealthPath string
+
+	// Headers are sent with every request, e.g. OpenRouter's X-Title
+	Headers map[string]string
+
+	// Quirks adjusts the payload for servers that reject standard fields
+	Quirks config.Quirks
+
+	// Defaults for requests that don't set them; zero uses the provider default
+	Temperature float32
+	MaxTokens   int
+	Stop        []string
 }
 
 // StandardClient implements a generic OpenAI-compatible chat client.
@@ -34,8 +57,18 @@ type StandardClient struct {
 	model      string
 	provider   string
 	httpClient *http.Client
+	query      url.Values
+	healthPath string
+	headers    map[string]string
+	quirks     config.Quirks
+
+	temperature float32
+	maxTokens   int
+	stop        []string
 }
 
+var _ Client = (*StandardClient)(nil)
+
 // NewStandardClient creates a new generic AI client.
 func NewStandardClient(cfg StandardClientConfig) (Client, error) {
 	if cfg.BaseURL == "" {
@@ -50,14 +83,17 @@ func NewStandardClient(cfg StandardClientConfig) (Client, error) {
 	if hc == nil {
 		hc = &http.Client{Timeout: 60 * time.Second}
 	}
+	hc = WithLogging(hc, cfg.Logger, headerNames(cfg.Headers, cfg.AuthHeader)...)
 
+	// A custom header such as Azure's api-key carries the bare key unless a
+	// prefix is given
 	authHeader := cfg.AuthHeader
+	authPrefix := cfg.AuthPrefix
 	if authHeader == "" {
 		authHeader = "Authorization"
-	}
-	authPrefix := cfg.AuthPrefix
-	if authPrefix == "" {
-		authPrefix = "Bearer "
+		if authPrefix == "" {
+			authPrefix = "Bearer "
+		}
 	}
 
 	provider := cfg.Provider
@@ -73,11 +109,20 @@ func NewStandardClient(cfg StandardClientConfig) (Client, error) {
 		model:      cfg.Model,
 		provider:   provider,
 		httpClient: hc,
+		query:      cfg.Query,
+		healthPath: normalizePath(cfg.HealthPath),
+		headers:    cfg.Headers,
+		quirks:     cfg.Quirks,
+
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+		stop:        cfg.Stop,
 	}, nil
 }
 
-func (c *StandardClient) Model() string    { return c.model }
-func (c *StandardClient) Provider() string { return c.provider }
+func (c *StandardClient) Model() string         { return c.model }
+func (c *StandardClient) SetModel(model string) { c.model = model }
+func (c *StandardClient) Provider() string      { return c.provider }
 
 func (c *StandardClient) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
 	payload := c.toPayload(req, false)
@@ -86,7 +131,7 @@ func (c *StandardClient) Complete(ctx context.Context, req ChatRequest) (ChatRes
 		return ChatResponse{}, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint("/chat/completions"), bytes.NewReader(body))
 	if err != nil {
 		return ChatResponse{}, err
 	}
@@ -99,7 +144,7 @@ func (c *StandardClient) Complete(ctx context.Context, req ChatRequest) (ChatRes
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return ChatResponse{}, c.httpError(resp)
+		return ChatResponse{}, c.httpError(ctx, resp, payload.Model)
 	}
 
 	var parsed standardResponse
@@ -110,8 +155,12 @@ func (c *StandardClient) Complete(ctx context.Context, req ChatRequest) (ChatRes
 		return ChatResponse{}, errors.New("no choices returned")
 	}
 
-	content := parsed.Choices[0].Message.Content
-	return ChatResponse{Content: content}, nil
+	choice := parsed.Choices[0]
+	result := ChatResponse{Content: choice.content(), FinishReason: choice.FinishReason}
+	if strings.TrimSpace(result.Content) == "" {
+		return result, ErrEmptyResponse
+	}
+	return result, nil
 }
 
 func (c *StandardClient) Stream(ctx context.Context, req ChatRequest) (<-chan StreamEvent, error) {
@@ -121,7 +170,7 @@ func (c *StandardClient) Stream(ctx context.Context, req ChatRequest) (<-chan St
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint("/chat/completions"), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -138,10 +187,12 @@ func (c *StandardClient) Stream(ctx context.Context, req ChatRequest) (<-chan St
 		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
-			out <- StreamEvent{Type: StreamEventError, Err: c.httpError(resp)}
+			out <- StreamEvent{Type: StreamEventError, Err: c.httpError(ctx, resp, payload.Model)}
 			return
 		}
 
+		var usage *Usage
+		var finishReason string
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -154,47 +205,108 @@ func (c *StandardClient) Stream(ctx context.Context, req ChatRequest) (<-chan St
 
 			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
 			if data == "[DONE]" {
-				out <- StreamEvent{Type: StreamEventDone}
+				out <- StreamEvent{Type: StreamEventDone, Usage: usage, FinishReason: finishReason}
 				return
 			}
 
 			var chunk standardStreamResponse
 			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				if json.Valid([]byte(data)) {
+					// Unexpected but well-formed object (e.g. vendor metadata); skip it
+					continue
+				}
 				out <- StreamEvent{Type: StreamEventError, Err: err}
 				return
 			}
 
+			// A chunk without choices carries metadata such as the final usage block
+			if u := parseUsage(chunk.Usage); u != nil {
+				usage = u
+			}
+
 			for _, choice := range chunk.Choices {
-				if choice.Delta.Content != "" {
-					out <- StreamEvent{Type: StreamEventChunk, Content: choice.Delta.Content}
+				if reasoning := choice.reasoning(); reasoning != "" {
+					out <- StreamEvent{Type: StreamEventReasoning, Content: reasoning}
+				}
+				if content := choice.content(); content != "" {
+					out <- StreamEvent{Type: StreamEventChunk, Content: content}
+				}
+				if choice.FinishReason != "" {
+					finishReason = choice.FinishReason
 				}
 			}
 		}
 
-		if err := scanner.Err(); err != nil && !errors.Is(err, context.Canceled) {
-			out <- StreamEvent{Type: StreamEventError, Err: err}
+		if err := scanner.Err(); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				out <- StreamEvent{Type: StreamEventError, Err: err}
+			}
+			return
 		}
+
+		// Server closed the stream without [DONE]
+		out <- StreamEvent{Type: StreamEventDone, Usage: usage, FinishReason: finishReason}
 	}()
 
 	return out, nil
 }
 
+// normalizePath gives a non-empty path a leading slash
+func normalizePath(path string) string {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
+}
+
+// endpoint joins path to the base URL and appends any configured query
+func (c *StandardClient) endpoint(path string) string {
+	u := c.baseURL + path
+	if len(c.query) > 0 {
+		u += "?" + c.query.Encode()
+	}
+	return u
+}
+
 func (c *StandardClient) applyHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
 	if c.apiKey != "" {
 		req.Header.Set(c.authHeader, c.authPrefix+c.apiKey)
 	}
 }
 
-func (c *StandardClient) httpError(resp *http.Response) error {
+func (c *StandardClient) httpError(ctx context.Context, resp *http.Response, model string) error {
 	b, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("api error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	body := strings.TrimSpace(string(b))
+
+	if isModelNotFound(resp.StatusCode, body) {
+		return c.modelNotFound(ctx, model, body)
+	}
+	return responseError(resp, c.provider, body)
+}
+
+// modelNotFound builds a friendly error, suggesting close matches when the
+// provider's model list is reachable.
+func (c *StandardClient) modelNotFound(ctx context.Context, model, body string) error {
+	e := &ModelNotFoundError{
+		Model:    model,
+		Provider: c.provider,
+		Detail:   body,
+	}
+
+	if available, err := c.ListModels(ctx); err == nil {
+		e.Suggestions = suggestModels(model, available, 3)
+	}
+	return e
 }
 
 func (c *StandardClient) toPayload(req ChatRequest, stream bool) standardRequest {
 	messages := make([]standardMessage, 0, len(req.Messages))
 	for _, m := range req.Messages {
-		messages = append(messages, standardMessage{Role: m.Role, Content: m.Content})
+		messages = append(messages, standardMessage{Role: m.Role, Content: m.Content, Images: m.Images})
 	}
 
 	model := req.Model
@@ -202,41 +314,178 @@ func (c *StandardClient) toPayload(req ChatRequest, stream bool) standardRequest
 		model = c.model
 	}
 
-	return standardRequest{
+	payload := standardRequest{
 		Model:       model,
 		Messages:    messages,
-		Temperature: req.Temperature,
-		MaxTokens:   req.MaxTokens,
+		Temperature: temperature(req.Temperature, c.temperature),
+		MaxTokens:   cmp.Or(req.MaxTokens, c.maxTokens),
+		Stop:        req.Stop,
 		Stream:      stream,
 	}
+	if stream && req.IncludeUsage {
+		payload.StreamOptions = &standardStreamOptions{IncludeUsage: true}
+	}
+	if len(payload.Stop) == 0 {
+		payload.Stop = c.stop
+	}
+	c.applyQuirks(&payload)
+
+	return payload
+}
+
+// applyQuirks strips or renames fields the provider is configured to reject
+func (c *StandardClient) applyQuirks(payload *standardRequest) {
+	if c.quirks.NoStreamOptions {
+		payload.StreamOptions = nil
+	}
+	if c.quirks.NoTemperature {
+		payload.Temperature = nil
+	}
+	if c.quirks.NoStop {
+		payload.Stop = nil
+	}
+	if c.quirks.MaxCompletionTokens {
+		payload.MaxCompletionTokens, payload.MaxTokens = payload.MaxTokens, 0
+	}
 }
 
 type standardRequest struct {
 	Model       string            `json:"model"`
 	Messages    []standardMessage `json:"messages"`
-	Temperature float32           `json:"temperature,omitempty"`
+	Temperature *float32          `json:"temperature,omitempty"`
 	MaxTokens   int               `json:"max_tokens,omitempty"`
+	Stop        []string          `json:"stop,omitempty"`
 	Stream      bool              `json:"stream"`
+
+	StreamOptions       *standardStreamOptions `json:"stream_options,omitempty"`
+	MaxCompletionTokens int                    `json:"max_completion_tokens,omitempty"` // set by the max_completion_tokens quirk
+}
+
+type standardStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type standardMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string
+	Content string
+	Images  []string
 }
 
-type standardResponse struct {
-	Choices []struct {
-		Message struct {
+// MarshalJSON sends content as a plain string, or as an array of text and
+// image_url parts when the message has images.
+func (m standardMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Images) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
 			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
+		}{m.Role, m.Content})
+	}
+
+	parts := make([]standardContentPart, 0, len(m.Images)+1)
+	if m.Content != "" {
+		parts = append(parts, standardContentPart{Type: "text", Text: m.Content})
+	}
+	for _, url := range m.Images {
+		parts = append(parts, standardContentPart{Type: "image_url", ImageURL: &standardImageURL{URL: url}})
+	}
+	return json.Marshal(struct {
+		Role    string                `json:"role"`
+		Content []standardContentPart `json:"content"`
+	}{m.Role, parts})
+}
+
+type standardContentPart struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	ImageURL *standardImageURL `json:"image_url,omitempty"`
+}
+
+type standardImageURL struct {
+	URL string `json:"url"`
+}
+
+type standardResponse struct {
+	Choices []standardChoice `json:"choices"`
+}
+
+// standardChoice covers the fields servers use for completion text. Most
+// use message.content, but legacy-style endpoints use text and some
+// reasoning models only fill reasoning_content.
+type standardChoice struct {
+	Message struct {
+		Content          string `json:"content"`
+		ReasoningContent string `json:"reasoning_content"`
+	} `json:"message"`
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// content returns the first non-empty text field
+func (c standardChoice) content() string {
+	switch {
+	case c.Message.Content != "":
+		return c.Message.Content
+	case c.Text != "":
+		return c.Text
+	default:
+		return c.Message.ReasoningContent
+	}
 }
 
 type standardStreamResponse struct {
-	Choices []struct {
-		Delta struct {
-			Content string `json:"content"`
-		} `json:"delta"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
+	Choices []standardStreamChoice `json:"choices"`
+	Usage   json.RawMessage        `json:"usage"`
+}
+
+type standardStreamChoice struct {
+	Delta struct {
+		Content          string `json:"content"`
+		ReasoningContent string `json:"reasoning_content"`
+		Reasoning        string `json:"reasoning"` // OpenRouter's name for it
+	} `json:"delta"`
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// content returns the delta text, falling back to the legacy text field
+func (c standardStreamChoice) content() string {
+	if c.Delta.Content != "" {
+		return c.Delta.Content
+	}
+	return c.Text
+}
+
+// reasoning returns the delta's thinking text, which reasoning models
+// stream before the answer
+func (c standardStreamChoice) reasoning() string {
+	if c.Delta.ReasoningContent != "" {
+		return c.Delta.ReasoningContent
+	}
+	return c.Delta.Reasoning
+}
+
+type standardUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// parseUsage decodes a usage block, returning nil if it is absent or malformed.
+func parseUsage(raw json.RawMessage) *Usage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var u standardUsage
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil
+	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return &Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
 }
@@ -0,0 +1,650 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+func newTestClient(t *testing.T, srv *httptest.Server, model string) Client {
+	t.Helper()
+
+	client, err := NewStandardClient(StandardClientConfig{
+		BaseURL:  srv.URL,
+		Model:    model,
+		Provider: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewStandardClient() error: %v", err)
+	}
+	return client
+}
+
+func TestCompleteModelNotFoundSuggestsMatches(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat/completions":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"model 'llama3.1:8bb' not found"}}`)
+		case "/models":
+			fmt.Fprint(w, `{"data":[{"id":"llama3.1:8b"},{"id":"llama3.1:70b"},{"id":"qwen2.5-coder"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestClient(t, srv, "llama3.1:8bb")
+	_, err := client.Complete(context.Background(), ChatRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	})
+
+	var notFound *ModelNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ModelNotFoundError, got %v", err)
+	}
+	if notFound.Model != "llama3.1:8bb" {
+		t.Errorf("expected model 'llama3.1:8bb', got %q", notFound.Model)
+	}
+	if len(notFound.Suggestions) == 0 || notFound.Suggestions[0] != "llama3.1:8b" {
+		t.Errorf("expected 'llama3.1:8b' as first suggestion, got %v", notFound.Suggestions)
+	}
+	for _, s := range notFound.Suggestions {
+		if s == "qwen2.5-coder" {
+			t.Errorf("unrelated model should not be suggested: %v", notFound.Suggestions)
+		}
+	}
+}
+
+func TestCompleteModelNotFoundWithoutModelList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `model "nope" does not exist`)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(t, srv, "nope")
+	_, err := client.Complete(context.Background(), ChatRequest{})
+
+	var notFound *ModelNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ModelNotFoundError, got %v", err)
+	}
+	if len(notFound.Suggestions) != 0 {
+		t.Errorf("expected no suggestions, got %v", notFound.Suggestions)
+	}
+}
+
+func TestCompleteOtherErrorsUnchanged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "boom")
+	}))
+	defer srv.Close()
+
+	client := newTestClient(t, srv, "m")
+	_, err := client.Complete(context.Background(), ChatRequest{})
+
+	var notFound *ModelNotFoundError
+	if err == nil || errors.As(err, &notFound) {
+		t.Fatalf("expected generic api error, got %v", err)
+	}
+}
+
+func TestCompleteStructuredAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv, "m").Complete(context.Background(), ChatRequest{})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Message != "Rate limit reached" ||
+		apiErr.Type != "requests" || apiErr.Code != "rate_limit_exceeded" || apiErr.Provider != "test" {
+		t.Errorf("unexpected APIError %+v", apiErr)
+	}
+	if strings.Contains(err.Error(), "{") {
+		t.Errorf("message should not include the raw JSON: %v", err)
+	}
+	if !IsRetryable(err) {
+		t.Error("429 should be retryable")
+	}
+}
+
+func TestCompletePlainTextAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "invalid token")
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv, "m").Complete(context.Background(), ChatRequest{})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.Message != "invalid token" || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the raw body as message, got %+v", apiErr)
+	}
+	if IsRetryable(err) {
+		t.Error("401 should not be retryable")
+	}
+}
+
+func TestSetModel(t *testing.T) {
+	var gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(t, srv, "first")
+	client.SetModel("second")
+
+	if client.Model() != "second" {
+		t.Errorf("expected Model() to return 'second', got %q", client.Model())
+	}
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if gotModel != "second" {
+		t.Errorf("expected request to use 'second', got %q", gotModel)
+	}
+}
+
+// sseServer returns a server that streams the given data lines as SSE.
+func sseServer(t *testing.T, lines ...string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, l := range lines {
+			fmt.Fprintf(w, "data: %s\n\n", l)
+		}
+	}))
+}
+
+// collect drains a stream into content, the final event, and any error.
+func collect(t *testing.T, events <-chan StreamEvent) (string, StreamEvent, error) {
+	t.Helper()
+	var content string
+	var last StreamEvent
+	for e := range events {
+		switch e.Type {
+		case StreamEventChunk:
+			content += e.Content
+		case StreamEventError:
+			return content, e, e.Err
+		}
+		last = e
+	}
+	return content, last, nil
+}
+
+func TestStreamSeparatesReasoning(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"delta":{"role":"assistant","content":"","reasoning_content":"Let me "}}]}`,
+		`{"choices":[{"delta":{"reasoning_content":"think."}}]}`,
+		`{"choices":[{"delta":{"reasoning":"OpenRouter style."}}]}`,
+		`{"choices":[{"delta":{"content":"The answer"}}]}`,
+		`{"choices":[{"delta":{"content":" is 4."},"finish_reason":"stop"}]}`,
+		`[DONE]`,
+	)
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	var got []StreamEvent
+	for e := range events {
+		got = append(got, e)
+	}
+	want := []StreamEvent{
+		{Type: StreamEventReasoning, Content: "Let me "},
+		{Type: StreamEventReasoning, Content: "think."},
+		{Type: StreamEventReasoning, Content: "OpenRouter style."},
+		{Type: StreamEventChunk, Content: "The answer"},
+		{Type: StreamEventChunk, Content: " is 4."},
+		{Type: StreamEventDone, FinishReason: "stop"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("events:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestStreamTrailingUsageChunk(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"delta":{"content":"Hel"}}]}`,
+		`{"choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}`,
+		`[DONE]`,
+	)
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	content, last, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if content != "Hello" {
+		t.Errorf("expected content 'Hello', got %q", content)
+	}
+	if last.Type != StreamEventDone || last.Usage == nil {
+		t.Fatalf("expected done event with usage, got %+v", last)
+	}
+	if last.Usage.PromptTokens != 12 || last.Usage.CompletionTokens != 2 || last.Usage.TotalTokens != 14 {
+		t.Errorf("unexpected usage: %+v", last.Usage)
+	}
+}
+
+func TestStreamIgnoresMalformedUsage(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"delta":{"content":"ok"}}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":"lots"}}`,
+		`{"object":"vendor.metadata","choices":"n/a"}`,
+		`[DONE]`,
+	)
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	content, last, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("malformed trailing chunk should not error the stream: %v", err)
+	}
+	if content != "ok" {
+		t.Errorf("expected content 'ok', got %q", content)
+	}
+	if last.Type != StreamEventDone {
+		t.Errorf("expected done event, got %+v", last)
+	}
+}
+
+func TestStreamRequestsUsage(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":1}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{IncludeUsage: true})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	_, last, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+
+	opts, ok := body["stream_options"].(map[string]any)
+	if !ok || opts["include_usage"] != true {
+		t.Errorf("expected stream_options.include_usage in payload, got %v", body["stream_options"])
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 4 {
+		t.Errorf("expected usage with 4 total tokens, got %+v", last.Usage)
+	}
+}
+
+// Some OpenAI-compatible servers put usage on the last choice chunk and
+// close the stream without [DONE]
+func TestStreamUsageOnFinalChoiceChunk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":5,\"total_tokens\":17}}\n\n")
+	}))
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{IncludeUsage: true})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	_, last, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+
+	if last.Type != StreamEventDone || last.FinishReason != "stop" {
+		t.Errorf("expected a done event with finish reason stop, got %+v", last)
+	}
+	want := Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}
+	if last.Usage == nil || *last.Usage != want {
+		t.Errorf("usage = %+v, want %+v", last.Usage, want)
+	}
+}
+
+func TestStreamOmitsStreamOptionsByDefault(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	collect(t, events)
+
+	if _, ok := body["stream_options"]; ok {
+		t.Errorf("stream_options should be omitted, got %v", body["stream_options"])
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := NewStandardClient(StandardClientConfig{
+		BaseURL: srv.URL,
+		APIKey:  "sk-test",
+		Model:   "m",
+		Headers: map[string]string{"http-referer": "https://example.com", "X-Title": "flux"},
+	})
+	if err != nil {
+		t.Fatalf("NewStandardClient() error: %v", err)
+	}
+	if _, err := client.Complete(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+
+	if got.Get("HTTP-Referer") != "https://example.com" || got.Get("X-Title") != "flux" {
+		t.Errorf("custom headers missing from request: %v", got)
+	}
+	if got.Get("Authorization") != "Bearer sk-test" {
+		t.Errorf("auth header should still be sent, got %q", got.Get("Authorization"))
+	}
+}
+
+func TestPayloadStop(t *testing.T) {
+	c := &StandardClient{model: "m"}
+
+	marshal := func(req ChatRequest) map[string]any {
+		t.Helper()
+		b, err := json.Marshal(c.toPayload(req, false))
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var body map[string]any
+		json.Unmarshal(b, &body)
+		return body
+	}
+
+	if body := marshal(ChatRequest{}); body["stop"] != nil {
+		t.Errorf("stop should be omitted when empty, got %v", body["stop"])
+	}
+
+	body := marshal(ChatRequest{Stop: []string{"```", "END"}})
+	if got := fmt.Sprint(body["stop"]); got != "[``` END]" {
+		t.Errorf("stop = %s, want [``` END]", got)
+	}
+
+	c.stop = []string{"<|end|>"}
+	if got := fmt.Sprint(marshal(ChatRequest{})["stop"]); got != "[<|end|>]" {
+		t.Errorf("configured stop = %s, want [<|end|>]", got)
+	}
+}
+
+func TestPayloadImages(t *testing.T) {
+	c := &StandardClient{model: "m"}
+	b, err := json.Marshal(c.toPayload(ChatRequest{Messages: []ChatMessage{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "what is this?", Images: []string{"data:image/png;base64,iVBOR"}},
+	}}, false))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var body struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	json.Unmarshal(b, &body)
+
+	if got := string(body.Messages[0].Content); got != `"be brief"` {
+		t.Errorf("text-only content should stay a string, got %s", got)
+	}
+	want := `[{"type":"text","text":"what is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBOR"}}]`
+	if got := string(body.Messages[1].Content); got != want {
+		t.Errorf("content = %s\nwant %s", got, want)
+	}
+}
+
+func TestPayloadQuirks(t *testing.T) {
+	temp := float32(0.5)
+	req := ChatRequest{Temperature: &temp, MaxTokens: 100, Stop: []string{"END"}, IncludeUsage: true}
+
+	tests := []struct {
+		name    string
+		quirks  config.Quirks
+		absent  string
+		present string
+	}{
+		{"none", config.Quirks{}, "max_completion_tokens", "stream_options"},
+		{"no_stream_options", config.Quirks{NoStreamOptions: true}, "stream_options", "temperature"},
+		{"no_temperature", config.Quirks{NoTemperature: true}, "temperature", "stop"},
+		{"no_stop", config.Quirks{NoStop: true}, "stop", "max_tokens"},
+		{"max_completion_tokens", config.Quirks{MaxCompletionTokens: true}, "max_tokens", "max_completion_tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StandardClient{model: "m", quirks: tt.quirks}
+			b, err := json.Marshal(c.toPayload(req, true))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var body map[string]any
+			json.Unmarshal(b, &body)
+
+			if _, ok := body[tt.absent]; ok {
+				t.Errorf("%s should be omitted, got %s", tt.absent, b)
+			}
+			if _, ok := body[tt.present]; !ok {
+				t.Errorf("%s should be sent, got %s", tt.present, b)
+			}
+		})
+	}
+}
+
+func TestStreamFinishReason(t *testing.T) {
+	for _, reason := range []string{"stop", "length"} {
+		srv := sseServer(t,
+			`{"choices":[{"delta":{"content":"partial"}}]}`,
+			fmt.Sprintf(`{"choices":[{"delta":{},"finish_reason":%q}]}`, reason),
+			`[DONE]`,
+		)
+
+		events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{})
+		if err != nil {
+			t.Fatalf("Stream() error: %v", err)
+		}
+		_, last, err := collect(t, events)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		if last.Type != StreamEventDone || last.FinishReason != reason {
+			t.Errorf("expected done event with finish reason %q, got %+v", reason, last)
+		}
+	}
+}
+
+func TestCompleteFinishReason(t *testing.T) {
+	for _, reason := range []string{"stop", "length"} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"choices":[{"message":{"content":"ok"},"finish_reason":%q}]}`, reason)
+		}))
+
+		resp, err := newTestClient(t, srv, "m").Complete(context.Background(), ChatRequest{})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Complete() error: %v", err)
+		}
+		if resp.FinishReason != reason {
+			t.Errorf("expected finish reason %q, got %q", reason, resp.FinishReason)
+		}
+	}
+}
+
+func TestCompleteAlternativeContentFields(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"message content", `{"choices":[{"message":{"content":"standard"}}]}`, "standard"},
+		{"text", `{"choices":[{"text":"legacy text"}]}`, "legacy text"},
+		{"reasoning content", `{"choices":[{"message":{"content":"","reasoning_content":"thought"}}]}`, "thought"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			resp, err := newTestClient(t, srv, "m").Complete(context.Background(), ChatRequest{})
+			if err != nil {
+				t.Fatalf("Complete() error: %v", err)
+			}
+			if resp.Content != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, resp.Content)
+			}
+		})
+	}
+}
+
+func TestCompleteEmptyResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"content":" \n"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	resp, err := newTestClient(t, srv, "m").Complete(context.Background(), ChatRequest{})
+	if !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("expected ErrEmptyResponse, got %v", err)
+	}
+	if resp.FinishReason != "stop" {
+		t.Errorf("finish reason should still be reported, got %q", resp.FinishReason)
+	}
+}
+
+func TestStreamTextField(t *testing.T) {
+	srv := sseServer(t,
+		`{"choices":[{"text":"leg"}]}`,
+		`{"choices":[{"text":"acy"}]}`,
+		`[DONE]`,
+	)
+	defer srv.Close()
+
+	events, err := newTestClient(t, srv, "m").Stream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	content, _, err := collect(t, events)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if content != "legacy" {
+		t.Errorf("expected 'legacy', got %q", content)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"data":[{"id":"gpt-4o"},{"id":"gpt-4o-mini"},{"id":""}]}`)
+	}))
+	defer srv.Close()
+
+	models, err := newTestClient(t, srv, "m").ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error: %v", err)
+	}
+	if len(models) != 2 || models[0] != "gpt-4o" || models[1] != "gpt-4o-mini" {
+		t.Errorf("unexpected models %v", models)
+	}
+}
+
+func TestListModelsNotSupported(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv, "m").ListModels(context.Background())
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported for a missing endpoint, got %v", err)
+	}
+}
+
+func TestPayloadTemperature(t *testing.T) {
+	zero := float32(0)
+	tests := []struct {
+		name       string
+		configured float32
+		req        *float32
+		want       string
+	}{
+		{"unset", 0, nil, ""},
+		{"configured", 0.3, nil, `"temperature":0.3`},
+		{"request overrides", 0.3, &zero, `"temperature":0`},
+		{"request zero", 0, &zero, `"temperature":0`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &StandardClient{model: "m", temperature: tt.configured}
+			b, err := json.Marshal(c.toPayload(ChatRequest{Temperature: tt.req}, false))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(string(b), "temperature") {
+					t.Errorf("temperature should be omitted, got %s", b)
+				}
+			} else if !strings.Contains(string(b), tt.want) {
+				t.Errorf("expected %s, got %s", tt.want, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ai
+
+import "strings"
+
+// SystemPrompt is an ordered set of named system contributions, such as the
+// configured prompt, a persona, or project context. Each part becomes its own
+// system message at the start of a request; providers without multiple
+// system turns (e.g. Gemini's systemInstruction) merge them in order.
+type SystemPrompt struct {
+	parts []systemPart
+}
+
+type systemPart struct {
+	name    string
+	content string
+}
+
+// Set replaces the named contribution in place, or appends it if new.
+// Blank content leaves it out of requests but keeps its position.
+func (p *SystemPrompt) Set(name, content string) {
+	content = strings.TrimSpace(content)
+	for i, part := range p.parts {
+		if part.name == name {
+			p.parts[i].content = content
+			return
+		}
+	}
+	p.parts = append(p.parts, systemPart{name: name, content: content})
+}
+
+// Get returns the named contribution, or "" if it is not set.
+func (p SystemPrompt) Get(name string) string {
+	for _, part := range p.parts {
+		if part.name == name {
+			return part.content
+		}
+	}
+	return ""
+}
+
+// Messages returns one system message per contribution, in order.
+func (p SystemPrompt) Messages() []ChatMessage {
+	messages := make([]ChatMessage, 0, len(p.parts))
+	for _, part := range p.parts {
+		if part.content != "" {
+			messages = append(messages, ChatMessage{Role: "system", Content: part.content})
+		}
+	}
+	return messages
+}
+
+// String joins the contributions with blank lines, for providers or callers
+// that need a single prompt.
+func (p SystemPrompt) String() string {
+	contents := make([]string, 0, len(p.parts))
+	for _, part := range p.parts {
+		if part.content != "" {
+			contents = append(contents, part.content)
+		}
+	}
+	return strings.Join(contents, "\n\n")
+}
@@ -0,0 +1,42 @@
+package ai
+
+import "testing"
+
+func TestSystemPromptOrder(t *testing.T) {
+	var p SystemPrompt
+	p.Set("prompt", "You are helpful.")
+	p.Set("project", "Project: flux")
+	p.Set("git", "Branch: main")
+
+	p.Set("project", "Project: flux-code-cli") // replaced in place
+	p.Set("prompt", "")                        // blank keeps the slot
+	p.Set("prompt", "You are terse.")
+
+	want := []string{"You are terse.", "Project: flux-code-cli", "Branch: main"}
+	got := p.Messages()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d messages, got %+v", len(want), got)
+	}
+	for i, content := range want {
+		if got[i].Role != "system" || got[i].Content != content {
+			t.Errorf("message %d: expected system %q, got %s %q", i, content, got[i].Role, got[i].Content)
+		}
+	}
+
+	if s := p.String(); s != "You are terse.\n\nProject: flux-code-cli\n\nBranch: main" {
+		t.Errorf("unexpected joined prompt %q", s)
+	}
+	if p.Get("git") != "Branch: main" || p.Get("missing") != "" {
+		t.Error("Get returned the wrong contribution")
+	}
+}
+
+func TestSystemPromptBlankOmitted(t *testing.T) {
+	var p SystemPrompt
+	p.Set("prompt", "  ")
+	p.Set("git", "Branch: main")
+
+	if got := p.Messages(); len(got) != 1 || got[0].Content != "Branch: main" {
+		t.Errorf("blank contributions should be omitted, got %+v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// requestTimeout bounds each request made by clients built without an
+// explicit http.Client
+const requestTimeout = 60 * time.Second
+
+// NewTransport returns a pooled transport tuned by cfg. Zero values keep
+// the net/http defaults, except that more idle connections are kept per
+// host so repeated requests to one provider reuse them. Requests go through
+// the proxy named by HTTPS_PROXY or HTTP_PROXY, unless NO_PROXY exempts them.
+func NewTransport(cfg config.HTTPConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	t.MaxIdleConns = cmp.Or(cfg.MaxIdleConns, t.MaxIdleConns)
+	t.MaxIdleConnsPerHost = cmp.Or(cfg.MaxIdleConnsPerHost, 10)
+	t.IdleConnTimeout = cmp.Or(cfg.IdleConnTimeout, t.IdleConnTimeout)
+	t.TLSHandshakeTimeout = cmp.Or(cfg.TLSHandshakeTimeout, t.TLSHandshakeTimeout)
+	t.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	return t
+}
+
+// sharedClient returns an http.Client on the registry's transport, so every
+// provider shares one connection pool. A proxyURL routes through that proxy
+// instead of the environment's, on a transport shared by providers using the
+// same one. The transports are replaced when the settings change.
+func (r *Registry) sharedClient(cfg config.HTTPConfig, proxyURL string) (*http.Client, error) {
+	if r.transport == nil || r.transportCfg != cfg {
+		r.closeTransports()
+		r.transport = NewTransport(cfg)
+		r.transportCfg = cfg
+		r.proxied = make(map[string]*http.Transport)
+	}
+
+	transport := r.transport
+	if proxyURL != "" {
+		var ok bool
+		if transport, ok = r.proxied[proxyURL]; !ok {
+			proxy, err := url.Parse(proxyURL)
+			if err != nil || proxy.Host == "" {
+				return nil, fmt.Errorf("invalid proxy_url %q", proxyURL)
+			}
+			transport = r.transport.Clone()
+			transport.Proxy = http.ProxyURL(proxy)
+			r.proxied[proxyURL] = transport
+		}
+	}
+	return &http.Client{Timeout: requestTimeout, Transport: transport}, nil
+}
+
+// closeTransports drops idle connections held by the current transports
+func (r *Registry) closeTransports() {
+	if r.transport != nil {
+		r.transport.CloseIdleConnections()
+	}
+	for _, t := range r.proxied {
+		t.CloseIdleConnections()
+	}
+}
@@ -1,32 +1,64 @@
 package ai
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // ChatMessage represents a single message in a chat request.
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+
+	// Images are sent alongside Content to vision models, as https URLs or
+	// base64 data URLs such as "data:image/png;base64,..."
+	Images []string `json:"images,omitempty"`
 }
 
 // ChatRequest defines a model-agnostic chat completion request.
 type ChatRequest struct {
-	Model       string
-	Messages    []ChatMessage
-	Temperature float32
-	MaxTokens   int
-	Stream      bool
+	Model    string
+	Messages []ChatMessage
+
+	// Temperature overrides the client's configured temperature when set,
+	// so a request can ask for 0
+	Temperature *float32
+
+	// MaxTokens overrides the client's configured default; zero means not
+	// set
+	MaxTokens int
+	Stream    bool
+
+	// Stop ends generation at any of these strings; empty uses the
+	// client's configured stop sequences
+	Stop []string
+
+	// IncludeUsage asks streaming providers to report token usage at the end
+	IncludeUsage bool
 }
 
 // ChatResponse is returned for non-streaming completions.
 type ChatResponse struct {
-	Content string
+	Content      string
+	FinishReason string // e.g. "stop" or "length"; empty if the provider omits it
 }
 
+// ErrEmptyResponse is returned by Complete when the provider finishes
+// without producing any content.
+var ErrEmptyResponse = errors.New("empty response")
+
+// FinishReasonLength means the response was cut off by the token limit.
+const FinishReasonLength = "length"
+
 // Client defines the provider-agnostic AI client interface.
 type Client interface {
 	Complete(ctx context.Context, req ChatRequest) (ChatResponse, error)
 	Stream(ctx context.Context, req ChatRequest) (<-chan StreamEvent, error)
+	// ListModels returns the provider's model IDs, or an error wrapping
+	// ErrNotSupported if it can't list them
+	ListModels(ctx context.Context) ([]string, error)
 	Model() string
+	SetModel(model string)
 	Provider() string
 }
 
@@ -37,11 +69,40 @@ const (
 	StreamEventChunk StreamEventType = "chunk"
 	StreamEventDone  StreamEventType = "done"
 	StreamEventError StreamEventType = "error"
+
+	// StreamEventReasoning carries a reasoning model's thinking, kept apart
+	// from the answer chunks. Consumers that don't show it can skip it.
+	StreamEventReasoning StreamEventType = "reasoning"
 )
 
+// Usage reports token counts for a completion.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
 // StreamEvent is emitted during a streaming completion.
 type StreamEvent struct {
 	Type    StreamEventType
 	Content string
 	Err     error
+	Usage   *Usage // Set on the done event when the provider reports usage
+
+	// FinishReason is set on the done event when the provider reports why
+	// generation stopped
+	FinishReason string
+}
+
+// temperature returns the temperature to send: the request's when it sets
+// one, else the client's configured one. nil, for a configured 0, leaves
+// the provider's default in place.
+func temperature(req *float32, configured float32) *float32 {
+	if req != nil {
+		return req
+	}
+	if configured != 0 {
+		return &configured
+	}
+	return nil
 }
@@ -1,18 +1,81 @@
 package app
 
 import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/kbesada/flux-code-cli/internal/ai"
 	"github.com/kbesada/flux-code-cli/internal/config"
 	"github.com/kbesada/flux-code-cli/internal/ui"
 )
 
-func Run() error {
+// DebugLogName is the file in the config dir that --debug writes to
+const DebugLogName = "flux.log"
+
+// Options are the command-line settings for a run
+type Options struct {
+	// Debug logs AI requests and responses, with credentials redacted
+	Debug bool
+}
+
+func Run(opts Options) error {
 	// Load configuration (errors are non-fatal, uses defaults)
-	_, _ = config.Load()
+	cfg, _ := config.Load()
+
+	registry, closeLog, err := newRegistry(opts)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	// Build the AI client; without one the UI still runs and reports the problem on send
+	var client ai.Client
+	if cfg != nil {
+		client, _ = registry.BuildActive(cfg, nil)
+	}
 
-	model := ui.NewModel()
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	_, err := p.Run()
+	model := ui.NewModel(cfg, client)
+	model.SetRegistry(registry)
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	final, err := p.Run()
+	if m, ok := final.(ui.Model); ok && m.SessionError() != nil {
+		fmt.Fprintf(os.Stderr, "flux: session not saved: %v\n", m.SessionError())
+	}
 	return err
 }
+
+// newRegistry returns the provider registry, logging to the debug log when
+// opts.Debug is set. The returned func closes the log.
+func newRegistry(opts Options) (*ai.Registry, func(), error) {
+	registry := ai.NewRegistry()
+	if !opts.Debug {
+		return registry, func() {}, nil
+	}
+
+	logFile, err := openDebugLog()
+	if err != nil {
+		return nil, nil, err
+	}
+	registry.SetLogger(log.New(logFile, "", log.LstdFlags|log.Lmicroseconds))
+	return registry, func() { logFile.Close() }, nil
+}
+
+// openDebugLog opens the debug log for appending, creating it if needed
+func openDebugLog() (*os.File, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("debug log: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("debug log: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, DebugLogName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("debug log: %w", err)
+	}
+	return f, nil
+}
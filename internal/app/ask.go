@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// Ask sends a single prompt without the TUI and streams the reply to
+// stdout. Input piped to stdin is attached to the prompt as context, so
+// `cat main.go | flux ask "find bugs"` works.
+func Ask(opts Options, prompt string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	registry, closeLog, err := newRegistry(opts)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	client, err := registry.BuildActive(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	var stdin io.Reader
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		stdin = os.Stdin
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var system ai.SystemPrompt
+	system.Set("prompt", cfg.System.Prompt)
+	return ask(ctx, client, system, prompt, stdin, os.Stdout)
+}
+
+// ask sends prompt, with anything read from stdin attached, and writes the
+// streamed reply to out. A nil stdin means nothing was piped.
+func ask(ctx context.Context, client ai.Client, system ai.SystemPrompt, prompt string, stdin io.Reader, out io.Writer) error {
+	var piped []byte
+	if stdin != nil {
+		var err error
+		if piped, err = io.ReadAll(stdin); err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+	}
+
+	message := askMessage(prompt, string(piped))
+	if message == "" {
+		return errors.New("nothing to ask: give a prompt or pipe input to stdin")
+	}
+
+	events, err := client.Stream(ctx, ai.ChatRequest{
+		Messages: append(system.Messages(), ai.ChatMessage{Role: "user", Content: message}),
+		Stream:   true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var last string
+	for event := range events {
+		switch event.Type {
+		case ai.StreamEventChunk:
+			if _, err := io.WriteString(out, event.Content); err != nil {
+				return err
+			}
+			if event.Content != "" {
+				last = event.Content
+			}
+		case ai.StreamEventError:
+			return event.Err
+		}
+	}
+	if !strings.HasSuffix(last, "\n") {
+		fmt.Fprintln(out)
+	}
+	return nil
+}
+
+// askMessage combines the prompt with piped input. Piped input alone is
+// sent as is; with a prompt it is fenced and placed before it. Blank piped
+// input is ignored.
+func askMessage(prompt, piped string) string {
+	prompt = strings.TrimSpace(prompt)
+	if strings.TrimSpace(piped) == "" {
+		return prompt
+	}
+	piped = strings.TrimRight(piped, "\n")
+	if prompt == "" {
+		return piped
+	}
+
+	fence := codeFence(piped)
+	return fence + "\n" + piped + "\n" + fence + "\n\n" + prompt
+}
+
+// codeFence returns a backtick fence longer than any fence inside content,
+// so piped markdown can't close it early
+func codeFence(content string) string {
+	longest := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimLeft(line, " \t")
+		n := len(line) - len(strings.TrimLeft(line, "`"))
+		longest = max(longest, n)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
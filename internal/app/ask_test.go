@@ -0,0 +1,80 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+)
+
+// recordingClient streams a fixed reply and keeps the last request
+type recordingClient struct {
+	req ai.ChatRequest
+}
+
+func (c *recordingClient) Complete(ctx context.Context, req ai.ChatRequest) (ai.ChatResponse, error) {
+	return ai.ChatResponse{}, nil
+}
+
+func (c *recordingClient) Stream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamEvent, error) {
+	c.req = req
+	events := make(chan ai.StreamEvent, 2)
+	events <- ai.StreamEvent{Type: ai.StreamEventChunk, Content: "Looks fine."}
+	events <- ai.StreamEvent{Type: ai.StreamEventDone}
+	close(events)
+	return events, nil
+}
+
+func (c *recordingClient) ListModels(ctx context.Context) ([]string, error) { return nil, nil }
+func (c *recordingClient) Model() string                                    { return "m" }
+func (c *recordingClient) SetModel(model string)                            {}
+func (c *recordingClient) Provider() string                                 { return "fake" }
+
+func TestAskCombinesStdinAndPrompt(t *testing.T) {
+	client := &recordingClient{}
+	stdin := bytes.NewBufferString("func main() {\n\t```\n}\n")
+	var out bytes.Buffer
+
+	var system ai.SystemPrompt
+	system.Set("prompt", "Be brief.")
+	if err := ask(context.Background(), client, system, "find bugs", stdin, &out); err != nil {
+		t.Fatalf("ask() error: %v", err)
+	}
+
+	messages := client.req.Messages
+	if len(messages) != 2 || messages[0].Role != "system" || messages[1].Role != "user" {
+		t.Fatalf("expected a system and a user message, got %+v", messages)
+	}
+	want := "````\nfunc main() {\n\t```\n}\n````\n\nfind bugs"
+	if messages[1].Content != want {
+		t.Errorf("user message =\n%s\nwant\n%s", messages[1].Content, want)
+	}
+	if out.String() != "Looks fine.\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestAskMessage(t *testing.T) {
+	tests := []struct {
+		name, prompt, piped, want string
+	}{
+		{"prompt only", "hello", "", "hello"},
+		{"blank stdin", "hello", " \n\n", "hello"},
+		{"stdin only", "", "what is 2+2?\n", "what is 2+2?"},
+		{"neither", " ", "", ""},
+	}
+	for _, tt := range tests {
+		if got := askMessage(tt.prompt, tt.piped); got != tt.want {
+			t.Errorf("%s: askMessage() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAskRequiresInput(t *testing.T) {
+	err := ask(context.Background(), &recordingClient{}, ai.SystemPrompt{}, "", &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "nothing to ask") {
+		t.Errorf("expected an error for an empty prompt and stdin, got %v", err)
+	}
+}
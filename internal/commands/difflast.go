@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kbesada/flux-code-cli/internal/git"
+)
+
+// codeBlock is a fenced block from an assistant message
+type codeBlock struct {
+	info    string // Text after the opening fence, e.g. "go main.go"
+	hint    string // Nearest non-empty line before the fence
+	content string
+}
+
+// executeDiffLast asks the UI to diff the last suggested code for a file
+func executeDiffLast(cmd *Command) CommandResult {
+	if len(cmd.Args) != 1 {
+		return CommandResult{Error: fmt.Errorf("usage: /difflast <file>")}
+	}
+	return CommandResult{
+		Action: ActionDiffLast,
+		Value:  cmd.Args[0],
+	}
+}
+
+// DiffLast diffs path on disk against the most recent code block suggested
+// for it. Messages are assistant replies, newest first.
+func DiffLast(path string, messages []string) CommandResult {
+	root, err := workDir()
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	return diffLast(root, path, messages)
+}
+
+func diffLast(root, path string, messages []string) CommandResult {
+	current, rel, err := readContextFile(root, path)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	var block *codeBlock
+	for _, msg := range messages {
+		if block = matchBlock(parseCodeBlocks(msg), rel); block != nil {
+			break
+		}
+	}
+	if block == nil {
+		return CommandResult{Error: fmt.Errorf("no suggested code found for %s", rel)}
+	}
+
+	diff, err := git.UnifiedDiff(rel, []byte(current), []byte(block.content))
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	if diff == "" {
+		return CommandResult{Output: fmt.Sprintf("The last suggestion for %s matches the file on disk.", rel)}
+	}
+
+	return CommandResult{
+		Output: fmt.Sprintf("## Suggested changes: %s\n\n```diff\n%s```\n", rel, diff),
+	}
+}
+
+// parseCodeBlocks extracts fenced code blocks from markdown
+func parseCodeBlocks(markdown string) []codeBlock {
+	var blocks []codeBlock
+	var current *codeBlock
+	var body []string
+	var lastLine string
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current == nil {
+			if strings.HasPrefix(trimmed, "```") {
+				current = &codeBlock{info: strings.TrimSpace(strings.TrimPrefix(trimmed, "```")), hint: lastLine}
+				body = nil
+			} else if trimmed != "" {
+				lastLine = trimmed
+			}
+			continue
+		}
+
+		if trimmed == "```" {
+			current.content = strings.Join(body, "\n") + "\n"
+			blocks = append(blocks, *current)
+			current = nil
+			lastLine = ""
+			continue
+		}
+		body = append(body, line)
+	}
+
+	return blocks
+}
+
+// matchBlock picks the block most likely meant for path: an explicit path
+// in the fence info beats a mention just before the block, which beats a
+// matching language. Later blocks win ties.
+func matchBlock(blocks []codeBlock, path string) *codeBlock {
+	base := filepath.Base(path)
+	lang := languageByExt[strings.ToLower(filepath.Ext(path))]
+
+	var best *codeBlock
+	bestScore := 0
+	for i := range blocks {
+		b := &blocks[i]
+
+		score := 0
+		switch {
+		case infoNamesFile(b.info, path):
+			score = 3
+		case strings.Contains(b.hint, base):
+			score = 2
+		case lang != "" && fenceLanguage(b.info) == lang:
+			score = 1
+		}
+
+		if score > 0 && score >= bestScore {
+			best, bestScore = b, score
+		}
+	}
+	return best
+}
+
+// infoNamesFile reports whether fence info such as "go main.go" or
+// "go:cmd/main.go" names path or a file with the same base name
+func infoNamesFile(info, path string) bool {
+	fields := strings.FieldsFunc(info, func(r rune) bool { return r == ' ' || r == ':' })
+	for _, f := range fields {
+		f = strings.TrimPrefix(filepath.ToSlash(f), "./")
+		if f == path || filepath.Base(f) == filepath.Base(path) && strings.Contains(f, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// fenceLanguage returns the language from fence info such as "go" or "go:main.go"
+func fenceLanguage(info string) string {
+	lang, _, _ := strings.Cut(info, " ")
+	lang, _, _ = strings.Cut(lang, ":")
+	return strings.ToLower(lang)
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchBlock(t *testing.T) {
+	msg := "Here's a helper:\n\n```go\nfunc a() {}\n```\n\nUpdated `main.go`:\n\n```go\npackage main\n```\n\n```python\nprint(1)\n```\n"
+	blocks := parseCodeBlocks(msg)
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(blocks))
+	}
+
+	if b := matchBlock(blocks, "main.go"); b == nil || b.content != "package main\n" {
+		t.Errorf("expected block mentioned as main.go, got %+v", b)
+	}
+	if b := matchBlock(blocks, "other.go"); b == nil || b.content != "package main\n" {
+		t.Errorf("expected latest go block by language, got %+v", b)
+	}
+	if b := matchBlock(blocks, "styles.css"); b != nil {
+		t.Errorf("expected no match for css, got %+v", b)
+	}
+
+	explicit := parseCodeBlocks("```go:cmd/app.go\npackage cmd\n```\n```go\npackage other\n```\n")
+	if b := matchBlock(explicit, "cmd/app.go"); b == nil || b.content != "package cmd\n" {
+		t.Errorf("path in fence info should win over language, got %+v", b)
+	}
+}
+
+func TestDiffLast(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {\n\tprintln(\"old\")\n}\n"), 0644)
+
+	replies := []string{
+		"Try this in `main.go`:\n\n```go\npackage main\n\nfunc main() {\n\tprintln(\"new\")\n}\n```\n",
+		"```go\npackage stale\n```",
+	}
+
+	result := diffLast(root, "main.go", replies)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.AddToChat {
+		t.Error("diff is for review and should not be added to chat")
+	}
+	for _, want := range []string{"--- a/main.go", "+++ b/main.go", "-\tprintln(\"old\")", "+\tprintln(\"new\")"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("expected %q in diff, got:\n%s", want, result.Output)
+		}
+	}
+
+	if result := diffLast(root, "main.go", []string{"no code here"}); result.Error == nil {
+		t.Error("expected error when no block matches")
+	}
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/session"
+	"github.com/kbesada/flux-code-cli/internal/textutil"
+)
+
+// exportOptions are the parsed /export arguments
+type exportOptions struct {
+	path        string
+	redact      bool
+	redactPaths bool
+}
+
+func parseExportArgs(args []string) (exportOptions, error) {
+	var opts exportOptions
+	for _, arg := range args {
+		switch {
+		case arg == "--redact":
+			opts.redact = true
+		case arg == "--redact-paths":
+			opts.redactPaths = true
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %s\nusage: /export [--redact] [--redact-paths] [file]", arg)
+		case opts.path != "":
+			return opts, fmt.Errorf("usage: /export [--redact] [--redact-paths] [file]")
+		default:
+			opts.path = arg
+		}
+	}
+	return opts, nil
+}
+
+// executeExport validates the arguments and asks the UI to export the chat
+func executeExport(cmd *Command) CommandResult {
+	if _, err := parseExportArgs(cmd.Args); err != nil {
+		return CommandResult{Error: err}
+	}
+	return CommandResult{
+		Action: ActionExport,
+		Value:  strings.Join(cmd.Args, " "),
+	}
+}
+
+// Export writes turns as markdown. With --redact, secrets (including the
+// known values, e.g. configured API keys) are masked; with --redact-paths,
+// the home and working directories become ~ and ".".
+func Export(args string, turns []session.Turn, known []string) CommandResult {
+	opts, err := parseExportArgs(strings.Fields(args))
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	if len(turns) == 0 {
+		return CommandResult{Error: fmt.Errorf("nothing to export yet")}
+	}
+
+	now := time.Now()
+	if opts.path == "" {
+		opts.path = "flux-transcript-" + now.Format("20060102-150405") + ".md"
+	}
+
+	content := session.Markdown(turns, now)
+	if opts.redact {
+		content = textutil.RedactSecrets(content, known...)
+	}
+	if opts.redactPaths {
+		content = textutil.AnonymizePaths(content, pathReplacements())
+	}
+
+	if err := os.WriteFile(opts.path, []byte(content), 0o644); err != nil {
+		return CommandResult{Error: fmt.Errorf("export: %w", err)}
+	}
+
+	path := opts.path
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	note := ""
+	if opts.redact || opts.redactPaths {
+		note = " (redacted)"
+	}
+	return CommandResult{Output: fmt.Sprintf("Exported %d messages to %s%s", len(turns), path, note)}
+}
+
+// pathReplacements maps the working and home directories to short forms
+func pathReplacements() map[string]string {
+	dirs := make(map[string]string)
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs[home] = "~"
+	}
+	if wd, err := workDir(); err == nil {
+		dirs[wd] = "."
+	}
+	return dirs
+}
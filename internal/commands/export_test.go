@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kbesada/flux-code-cli/internal/session"
+)
+
+var exportTurns = []session.Turn{
+	{Role: "user", Content: "Why does my client fail with key sk-proj-abcdefghijklmnop1234?"},
+	{Role: "assistant", Content: "The key `custom-secret-value` looks revoked."},
+}
+
+func TestExportRedactsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.md")
+
+	result := Export("--redact "+path, exportTurns, []string{"custom-secret-value"})
+	if result.Error != nil {
+		t.Fatalf("Export() error: %v", result.Error)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, secret := range []string{"sk-proj-abcdefghijklmnop1234", "custom-secret-value"} {
+		if strings.Contains(content, secret) {
+			t.Errorf("secret %q should be masked:\n%s", secret, content)
+		}
+	}
+	if !strings.Contains(content, "## User") || !strings.Contains(content, "## Assistant") {
+		t.Errorf("expected markdown headings per turn:\n%s", content)
+	}
+}
+
+func TestExportWithoutRedact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.md")
+	if result := Export(path, exportTurns, nil); result.Error != nil {
+		t.Fatalf("Export() error: %v", result.Error)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "sk-proj-abcdefghijklmnop1234") {
+		t.Error("content should be exported as-is without --redact")
+	}
+}
+
+func TestExportArgs(t *testing.T) {
+	if result := executeExport(&Command{Name: "export", Args: []string{"--bogus"}}); result.Error == nil {
+		t.Error("unknown flags should be rejected")
+	}
+	if result := Export("", nil, nil); result.Error == nil {
+		t.Error("exporting an empty chat should fail")
+	}
+}
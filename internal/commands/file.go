@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+	"github.com/kbesada/flux-code-cli/internal/git"
+	"github.com/kbesada/flux-code-cli/internal/textutil"
+)
+
+// maxFileSize is the largest file /file will load into context
+const maxFileSize = 256 * 1024
+
+// languageByExt maps file extensions to code fence languages
+var languageByExt = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "jsx",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "kotlin",
+	".swift": "swift",
+	".rb":    "ruby",
+	".php":   "php",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".sh":    "bash",
+	".sql":   "sql",
+	".html":  "html",
+	".css":   "css",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".md":    "markdown",
+}
+
+// ExecuteFile loads one or more files into the chat as fenced code blocks.
+// A path may end in :start-end (or :line) to load only those lines.
+func ExecuteFile(cmd *Command) CommandResult {
+	root, err := workDir()
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	lineNumbers := false
+	if cfg := config.Get(); cfg != nil {
+		lineNumbers = cfg.Context.LineNumbers
+	}
+	return loadFiles(root, cmd.Args, lineNumbers)
+}
+
+func loadFiles(root string, paths []string, lineNumbers bool) CommandResult {
+	if len(paths) == 0 {
+		return CommandResult{
+			Error: fmt.Errorf("usage: /file <path>[:start-end] [path...]"),
+		}
+	}
+
+	var builder strings.Builder
+	for i, arg := range paths {
+		path, start, end := splitLineRange(arg)
+		content, rel, err := readContextFile(root, path)
+		if err != nil {
+			return CommandResult{Error: err}
+		}
+
+		label := rel
+		lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+		if start == 0 {
+			start, end = 1, len(lines)
+		} else if start > len(lines) {
+			return CommandResult{Error: fmt.Errorf("%s: line %d is past the end of the file (%d lines)", rel, start, len(lines))}
+		} else {
+			end = min(end, len(lines))
+			label = fmt.Sprintf("%s (lines %d-%d)", rel, start, end)
+		}
+
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(formatFileForContext(rel, label, numberLines(lines[start-1:end], start, end, lineNumbers)))
+	}
+
+	return CommandResult{
+		Output:    builder.String(),
+		AddToChat: true,
+	}
+}
+
+// readContextFile reads path relative to root, refusing paths outside root
+// and files larger than maxFileSize
+func readContextFile(root, path string) (string, string, error) {
+	data, rel, err := readWorkFile(root, path, maxFileSize)
+	if err != nil {
+		return "", "", err
+	}
+	return string(textutil.Normalize(data)), rel, nil
+}
+
+// readWorkFile reads path relative to root, refusing paths outside root and
+// files larger than limit. It returns the contents and the slash-separated
+// path relative to root.
+func readWorkFile(root, path string, limit int64) ([]byte, string, error) {
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(root, path)
+	}
+	full = filepath.Clean(full)
+
+	rel, err := filepath.Rel(root, full)
+	if err != nil || !isWithin(rel) {
+		return nil, "", fmt.Errorf("%s: path is outside the working directory", path)
+	}
+
+	// A symlink inside root may point anywhere, so check where it leads too
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, "", err
+	}
+	target, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", fmt.Errorf("%s: file not found", path)
+		}
+		return nil, "", err
+	}
+	if realRel, err := filepath.Rel(realRoot, target); err != nil || !isWithin(realRel) {
+		return nil, "", fmt.Errorf("%s: links outside the working directory", path)
+	}
+	full = target
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.IsDir() {
+		return nil, "", fmt.Errorf("%s: is a directory", path)
+	}
+	if info.Size() > limit {
+		return nil, "", fmt.Errorf("%s: file is too large (%d KB, limit %d KB)", path, info.Size()/1024, limit/1024)
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, filepath.ToSlash(rel), nil
+}
+
+// isWithin reports whether a path relative to some directory stays inside it
+func isWithin(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// splitLineRange splits "path:start-end" or "path:line" into its parts. A
+// path without a valid range is returned whole with start 0.
+func splitLineRange(arg string) (path string, start, end int) {
+	i := strings.LastIndexByte(arg, ':')
+	if i <= 0 {
+		return arg, 0, 0
+	}
+
+	spec := arg[i+1:]
+	from, to, isRange := strings.Cut(spec, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 1 {
+		return arg, 0, 0
+	}
+	end = start
+	if isRange {
+		if end, err = strconv.Atoi(to); err != nil || end < start {
+			return arg, 0, 0
+		}
+	}
+	return arg[:i], start, end
+}
+
+// numberLines joins lines, prefixing each with its line number in the file
+// when enabled. Numbers are padded to the width of the last one.
+func numberLines(lines []string, first, last int, enabled bool) string {
+	if !enabled {
+		return strings.Join(lines, "\n")
+	}
+
+	width := len(strconv.Itoa(last))
+	numbered := make([]string, len(lines))
+	for i, line := range lines {
+		numbered[i] = fmt.Sprintf("%*d | %s", width, first+i, line)
+	}
+	return strings.Join(numbered, "\n")
+}
+
+func formatFileForContext(path, label, content string) string {
+	lang := languageByExt[strings.ToLower(filepath.Ext(path))]
+	return fmt.Sprintf("## File: %s\n\n```%s\n%s\n```\n", label, lang, strings.TrimRight(content, "\n"))
+}
+
+// workDir returns the repository root, or the current directory outside a repo
+func workDir() (string, error) {
+	if repo, err := git.Open(""); err == nil {
+		return repo.Path(), nil
+	}
+	return os.Getwd()
+}
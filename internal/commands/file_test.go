@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFiles(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("remember this\n"), 0644)
+
+	result := loadFiles(root, []string{"main.go", "notes.txt"}, false)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if !result.AddToChat {
+		t.Error("file contents should be added to chat context")
+	}
+	if !strings.Contains(result.Output, "```go\npackage main\n```") {
+		t.Errorf("expected go fenced block, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "remember this") {
+		t.Errorf("expected second file contents, got:\n%s", result.Output)
+	}
+}
+
+func TestLoadFilesLineNumbers(t *testing.T) {
+	root := t.TempDir()
+	var content strings.Builder
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	os.WriteFile(filepath.Join(root, "lines.txt"), []byte(content.String()), 0644)
+
+	result := loadFiles(root, []string{"lines.txt"}, true)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if !strings.Contains(result.Output, "```\n 1 | line 1\n 2 | line 2\n") ||
+		!strings.Contains(result.Output, "12 | line 12\n```") {
+		t.Errorf("expected numbered lines, got:\n%s", result.Output)
+	}
+
+	result = loadFiles(root, []string{"lines.txt:9-10"}, true)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	want := "## File: lines.txt (lines 9-10)\n\n```\n 9 | line 9\n10 | line 10\n```\n"
+	if result.Output != want {
+		t.Errorf("range output = %q, want %q", result.Output, want)
+	}
+
+	result = loadFiles(root, []string{"lines.txt:20"}, true)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "past the end") {
+		t.Errorf("expected out of range error, got %v", result.Error)
+	}
+}
+
+func TestLoadFilesMissing(t *testing.T) {
+	result := loadFiles(t.TempDir(), []string{"nope.go"}, false)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", result.Error)
+	}
+}
+
+func TestLoadFilesTooLarge(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "big.txt"), make([]byte, maxFileSize+1), 0644)
+
+	result := loadFiles(root, []string{"big.txt"}, false)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "too large") {
+		t.Errorf("expected too large error, got %v", result.Error)
+	}
+}
+
+func TestLoadFilesOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+
+	result := loadFiles(root, []string{"../secret.txt"}, false)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "outside") {
+		t.Errorf("expected outside working directory error, got %v", result.Error)
+	}
+}
+
+func TestLoadFilesSymlinkOutsideRoot(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("token"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	result := loadFiles(root, []string{"link.txt"}, false)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "outside") {
+		t.Errorf("expected a link out of the root to be refused, got %+v", result)
+	}
+}
+
+func TestLoadFilesThroughSymlinkedRoot(t *testing.T) {
+	target := t.TempDir()
+	if err := os.WriteFile(filepath.Join(target, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(t.TempDir(), "repo")
+	if err := os.Symlink(target, root); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("main.go", filepath.Join(target, "alias.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	result := loadFiles(root, []string{"main.go", "alias.go"}, false)
+	if result.Error != nil {
+		t.Fatalf("links that stay inside the root should load: %v", result.Error)
+	}
+	if !strings.Contains(result.Output, "alias.go") {
+		t.Errorf("expected the link's own path in the output, got:\n%s", result.Output)
+	}
+}
+
+func TestLoadFilesNormalizesBOMAndCRLF(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "win.go"), []byte("\ufeffpackage main\r\n\r\nfunc main() {}\r\n"), 0644)
+
+	result := loadFiles(root, []string{"win.go"}, false)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if !strings.Contains(result.Output, "```go\npackage main\n\nfunc main() {}\n```") {
+		t.Errorf("expected clean content, got:\n%q", result.Output)
+	}
+	if strings.ContainsAny(result.Output, "\r\ufeff") {
+		t.Errorf("output should not contain CR or BOM: %q", result.Output)
+	}
+}
+
+func TestLoadImage(t *testing.T) {
+	root := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	os.WriteFile(filepath.Join(root, "shot.png"), png, 0644)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("not an image"), 0644)
+
+	result := loadImage(root, []string{"shot.png"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.Action != ActionAttachImage || !strings.HasPrefix(result.Value, "data:image/png;base64,") {
+		t.Errorf("expected a PNG data URL action, got %v %q", result.Action, result.Value)
+	}
+
+	if result := loadImage(root, []string{"notes.txt"}); result.Error == nil {
+		t.Error("expected an error for a file that isn't an image")
+	}
+}
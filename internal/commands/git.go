@@ -1,52 +1,34 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/kbesada/flux-code-cli/internal/config"
 	"github.com/kbesada/flux-code-cli/internal/git"
 )
 
-// ExecuteGitCommand handles git-related slash commands
-func ExecuteGitCommand(cmd *Command) CommandResult {
-	repo, err := git.Open("")
-	if err != nil {
-		return CommandResult{
-			Error: fmt.Errorf("not in a git repository: %w", err),
-		}
-	}
-
-	switch cmd.Name {
-	case "diff":
-		return executeDiff(repo, cmd.Args)
-	case "staged":
-		return executeStaged(repo)
-	case "log":
-		return executeLog(repo, cmd.Args)
-	case "blame":
-		return executeBlame(repo, cmd.Args)
-	case "branch":
-		return executeBranch(repo)
-	case "status":
-		return executeStatus(repo)
-	case "commit":
-		return executeCommitMsg(repo)
-	default:
-		return CommandResult{
-			Error: fmt.Errorf("unknown command: /%s", cmd.Name),
-		}
-	}
-}
-
+// executeDiff shows unstaged changes, or the diff between two revisions
+// when given /diff <from> <to> [file]
 func executeDiff(repo *git.Repo, args []string) CommandResult {
 	opts := git.DiffOptions{Staged: false}
 
-	if len(args) > 0 {
+	var diff string
+	var err error
+	switch len(args) {
+	case 0:
+		diff, err = repo.GetDiff(opts)
+	case 1:
 		opts.File = args[0]
+		diff, err = repo.GetDiff(opts)
+	default:
+		if len(args) > 2 {
+			opts.File = args[2]
+		}
+		diff, err = repo.DiffRefs(args[0], args[1], opts)
 	}
-
-	diff, err := repo.GetDiff(opts)
 	if err != nil {
 		return CommandResult{Error: err}
 	}
@@ -57,7 +39,53 @@ func executeDiff(repo *git.Repo, args []string) CommandResult {
 	}
 }
 
-func executeStaged(repo *git.Repo) CommandResult {
+// maxChangesSize caps the diff text /changes adds to the chat
+const maxChangesSize = 128 * 1024
+
+// executeChanges bundles the diff of every changed file, staged or not,
+// into one message ready for review
+func executeChanges(repo *git.Repo, args []string) CommandResult {
+	changes, err := repo.Changes()
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	if len(changes) == 0 {
+		return CommandResult{Output: "No changes detected."}
+	}
+
+	return CommandResult{
+		Output:    formatChanges(changes, maxChangesSize),
+		AddToChat: true,
+	}
+}
+
+// formatChanges renders one diff block per file. Binary files and diffs that
+// would push the total past limit are listed without their contents.
+func formatChanges(changes []git.FileChange, limit int) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("## Changes (%d files)\n", len(changes)))
+
+	used := 0
+	var omitted []string
+	for _, c := range changes {
+		switch {
+		case c.Binary:
+			builder.WriteString(fmt.Sprintf("\n### %s (%s, binary; diff omitted)\n", c.Path, c.Status))
+		case used+len(c.Diff) > limit:
+			omitted = append(omitted, c.Path)
+		default:
+			used += len(c.Diff)
+			builder.WriteString(fmt.Sprintf("\n### %s (%s)\n\n```diff\n%s\n```\n", c.Path, c.Status, strings.TrimRight(c.Diff, "\n")))
+		}
+	}
+
+	if len(omitted) > 0 {
+		builder.WriteString(fmt.Sprintf("\n_(size limit of %d KB reached; not shown: %s)_\n", limit/1024, strings.Join(omitted, ", ")))
+	}
+	return builder.String()
+}
+
+func executeStaged(repo *git.Repo, args []string) CommandResult {
 	diff, err := repo.GetDiff(git.DiffOptions{Staged: true})
 	if err != nil {
 		return CommandResult{Error: err}
@@ -69,21 +97,44 @@ func executeStaged(repo *git.Repo) CommandResult {
 	}
 }
 
+// executeLog shows recent commits, or the history of one file when the
+// first argument is not a count: /log [n] or /log <file> [n]
 func executeLog(repo *git.Repo, args []string) CommandResult {
 	n := 10 // default
+	var file string
 	if len(args) > 0 {
 		if parsed, err := strconv.Atoi(args[0]); err == nil {
 			n = parsed
+		} else {
+			file = args[0]
+			if len(args) > 1 {
+				if parsed, err := strconv.Atoi(args[1]); err == nil {
+					n = parsed
+				}
+			}
 		}
 	}
 
-	commits, err := repo.GetLog(n)
+	var commits []git.CommitInfo
+	var err error
+	if file != "" {
+		commits, err = repo.FileLog(file, n)
+	} else {
+		commits, err = repo.GetLog(n)
+	}
 	if err != nil {
 		return CommandResult{Error: err}
 	}
 
 	var builder strings.Builder
-	builder.WriteString("## Recent Commits\n\n")
+	if file != "" {
+		if len(commits) == 0 {
+			return CommandResult{Error: fmt.Errorf("no commits touch %s", file)}
+		}
+		builder.WriteString(fmt.Sprintf("## Recent Commits to %s\n\n", file))
+	} else {
+		builder.WriteString("## Recent Commits\n\n")
+	}
 	for _, c := range commits {
 		builder.WriteString(fmt.Sprintf("- `%s` %s (%s)\n", c.Hash, c.Message, c.Author))
 	}
@@ -106,12 +157,18 @@ func executeBlame(repo *git.Repo, args []string) CommandResult {
 	var result *git.BlameResult
 	var err error
 
+	// An explicit range always bypasses the line cap
+	maxLines := 0
 	if len(args) >= 3 {
 		start, _ := strconv.Atoi(args[1])
 		end, _ := strconv.Atoi(args[2])
 		result, err = repo.BlameRange(file, start, end)
 	} else {
 		result, err = repo.Blame(file)
+		maxLines = defaultBlameMaxLines
+		if cfg := config.Get(); cfg != nil && cfg.Blame.MaxLines > 0 {
+			maxLines = cfg.Blame.MaxLines
+		}
 	}
 
 	if err != nil {
@@ -119,12 +176,37 @@ func executeBlame(repo *git.Repo, args []string) CommandResult {
 	}
 
 	return CommandResult{
-		Output:    result.Format(),
+		Output:    formatBlame(result, maxLines),
 		AddToChat: true,
 	}
 }
 
-func executeBranch(repo *git.Repo) CommandResult {
+const defaultBlameMaxLines = 500
+
+// formatBlame renders blame output, keeping only the first maxLines lines
+// (0 = unlimited) so huge files don't swamp the chat
+func formatBlame(result *git.BlameResult, maxLines int) string {
+	if maxLines <= 0 || len(result.Lines) <= maxLines {
+		return result.Format()
+	}
+
+	capped := &git.BlameResult{Lines: result.Lines[:maxLines]}
+	return capped.Format() + fmt.Sprintf(
+		"\n_(file too large; showing first %d of %d lines; use /blame <file> <start> <end> for a range)_\n",
+		maxLines, len(result.Lines))
+}
+
+// hasCommits reports whether HEAD resolves to a commit
+func hasCommits(repo *git.Repo) bool {
+	_, err := repo.Head()
+	return !errors.Is(err, git.ErrNoCommits)
+}
+
+func executeBranch(repo *git.Repo, args []string) CommandResult {
+	if len(args) > 0 {
+		return checkoutBranch(repo, args)
+	}
+
 	branch, err := repo.CurrentBranch()
 	if err != nil {
 		return CommandResult{Error: err}
@@ -136,7 +218,14 @@ func executeBranch(repo *git.Repo) CommandResult {
 	}
 
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("## Current Branch: %s\n\n", branch))
+	switch {
+	case repo.IsDetached():
+		builder.WriteString(fmt.Sprintf("## Detached HEAD at %s\n\n", branch))
+	case !hasCommits(repo):
+		builder.WriteString(fmt.Sprintf("## Current Branch: %s (no commits yet)\n\n", branch))
+	default:
+		builder.WriteString(fmt.Sprintf("## Current Branch: %s\n\n", branch))
+	}
 
 	if status.Dirty {
 		builder.WriteString("Status: **dirty** (uncommitted changes)\n\n")
@@ -159,12 +248,114 @@ func executeBranch(repo *git.Repo) CommandResult {
 	}
 }
 
-func executeStatus(repo *git.Repo) CommandResult {
+// checkoutBranch handles /branch [-b] [-f] <name>. -b creates the branch
+// and -f discards uncommitted changes instead of refusing to switch.
+func checkoutBranch(repo *git.Repo, args []string) CommandResult {
+	var name string
+	var create, force bool
+	for _, arg := range args {
+		switch arg {
+		case "-b":
+			create = true
+		case "-f", "--force":
+			force = true
+		default:
+			if name != "" || strings.HasPrefix(arg, "-") {
+				return CommandResult{Error: fmt.Errorf("usage: /branch [-b] [-f] <name>")}
+			}
+			name = arg
+		}
+	}
+	if name == "" {
+		return CommandResult{Error: fmt.Errorf("usage: /branch [-b] [-f] <name>")}
+	}
+
+	checkout := repo.Checkout
+	if force {
+		checkout = repo.ForceCheckout
+	}
+	if err := checkout(name, create); err != nil {
+		if errors.Is(err, git.ErrUncommittedChanges) {
+			retry := "/branch -f " + name
+			if create {
+				retry = "/branch -b -f " + name
+			}
+			return CommandResult{Error: fmt.Errorf(
+				"%w switching to %s; commit or /stash them first, or use %s to discard them", err, name, retry)}
+		}
+		return CommandResult{Error: err}
+	}
+
+	summary := "Switched to branch " + name
+	if create {
+		summary = "Switched to a new branch " + name
+	}
+	result := statusAfter(repo, summary)
+	result.Action = ActionRefreshGit
+	return result
+}
+
+func executeStatus(repo *git.Repo, args []string) CommandResult {
 	status, err := repo.GetStatus()
 	if err != nil {
 		return CommandResult{Error: err}
 	}
 
+	return CommandResult{
+		Output:    formatStatus(status),
+		AddToChat: true,
+	}
+}
+
+func executeAdd(repo *git.Repo, args []string) CommandResult {
+	if len(args) == 0 {
+		return CommandResult{Error: fmt.Errorf("usage: /add <file> [file...] (or . for everything)")}
+	}
+	if err := repo.Stage(args...); err != nil {
+		return CommandResult{Error: err}
+	}
+	return statusAfter(repo, "Staged "+strings.Join(args, ", "))
+}
+
+func executeUnstage(repo *git.Repo, args []string) CommandResult {
+	if len(args) == 0 {
+		return CommandResult{Error: fmt.Errorf("usage: /unstage <file> [file...] (or . for everything)")}
+	}
+	if err := repo.Unstage(args...); err != nil {
+		return CommandResult{Error: err}
+	}
+	return statusAfter(repo, "Unstaged "+strings.Join(args, ", "))
+}
+
+func executeStash(repo *git.Repo, args []string) CommandResult {
+	if len(args) == 1 && args[0] == "pop" {
+		if err := repo.StashPop(); err != nil {
+			return CommandResult{Error: err}
+		}
+		return statusAfter(repo, "Restored the last stash")
+	}
+
+	message := strings.Join(args, " ")
+	if err := repo.Stash(message); err != nil {
+		return CommandResult{Error: err}
+	}
+	summary := "Stashed changes"
+	if message != "" {
+		summary += ": " + message
+	}
+	return statusAfter(repo, summary)
+}
+
+// statusAfter reports a change followed by the updated repository status
+func statusAfter(repo *git.Repo, summary string) CommandResult {
+	status, err := repo.GetStatus()
+	if err != nil {
+		return CommandResult{Output: summary}
+	}
+	return CommandResult{Output: summary + "\n\n" + formatStatus(status)}
+}
+
+func formatStatus(status *git.Status) string {
 	var builder strings.Builder
 	builder.WriteString("## Git Status\n\n")
 	builder.WriteString(fmt.Sprintf("Branch: %s\n\n", status.Branch))
@@ -192,13 +383,59 @@ func executeStatus(repo *git.Repo) CommandResult {
 		}
 	}
 
-	return CommandResult{
-		Output:    builder.String(),
-		AddToChat: true,
+	return builder.String()
+}
+
+// executeCommit commits staged changes when given a message, and otherwise
+// asks the assistant to suggest one
+func executeCommit(repo *git.Repo, args []string) CommandResult {
+	if len(args) == 0 {
+		return executeCommitMsg(repo, args)
+	}
+
+	author := commitAuthor(repo)
+	if author.IsZero() {
+		return CommandResult{
+			Error: fmt.Errorf("no commit author: set user.name and user.email in git config, or git.author_name and git.author_email in flux config"),
+		}
 	}
+
+	hash, err := repo.Commit(unquote(strings.Join(args, " ")), author)
+	if errors.Is(err, git.ErrNothingStaged) {
+		return CommandResult{Output: noStagedChanges}
+	}
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	return CommandResult{Output: fmt.Sprintf("Committed `%s`", hash)}
 }
 
-func executeCommitMsg(repo *git.Repo) CommandResult {
+// commitAuthor prefers the git config identity, filling gaps from flux config
+func commitAuthor(repo *git.Repo) git.Signature {
+	author := repo.GitSignature()
+	if cfg := config.Get(); cfg != nil {
+		if author.Name == "" {
+			author.Name = cfg.Git.AuthorName
+		}
+		if author.Email == "" {
+			author.Email = cfg.Git.AuthorEmail
+		}
+	}
+	return author
+}
+
+// unquote strips one pair of matching surrounding quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+const noStagedChanges = "No staged changes. Stage changes with `git add` first."
+
+func executeCommitMsg(repo *git.Repo, args []string) CommandResult {
 	diff, err := repo.GetDiff(git.DiffOptions{Staged: true})
 	if err != nil {
 		return CommandResult{Error: err}
@@ -206,7 +443,7 @@ func executeCommitMsg(repo *git.Repo) CommandResult {
 
 	if diff == "No changes detected." {
 		return CommandResult{
-			Output: "No staged changes. Stage changes with `git add` first.",
+			Output: noStagedChanges,
 		}
 	}
 
@@ -223,6 +460,50 @@ Generate only the commit message, nothing else.`, diff)
 	}
 }
 
+func executeSearch(repo *git.Repo, args []string) CommandResult {
+	opts := git.GrepOptions{MaxPerFile: 20}
+	if cfg := config.Get(); cfg != nil && cfg.Search.MaxMatches > 0 {
+		opts.MaxPerFile = cfg.Search.MaxMatches
+	}
+
+	var pattern []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--context", "-C":
+			if i+1 >= len(args) {
+				return CommandResult{Error: fmt.Errorf("%s requires a number", args[i])}
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return CommandResult{Error: fmt.Errorf("invalid context value: %s", args[i+1])}
+			}
+			opts.Context = n
+			i++
+		case "-i", "--ignore-case":
+			opts.IgnoreCase = true
+		default:
+			pattern = append(pattern, args[i])
+		}
+	}
+
+	if len(pattern) == 0 {
+		return CommandResult{
+			Error: fmt.Errorf("usage: /search [--context N] [-i] <pattern>"),
+		}
+	}
+	opts.Pattern = strings.Join(pattern, " ")
+
+	results, err := repo.Grep(opts)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	return CommandResult{
+		Output:    git.FormatGrep(opts.Pattern, results),
+		AddToChat: true,
+	}
+}
+
 func formatDiffForContext(diff string) string {
 	return fmt.Sprintf("## Git Diff\n\n```diff\n%s\n```", diff)
 }
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kbesada/flux-code-cli/internal/git"
+)
+
+func blameLines(n int) *git.BlameResult {
+	result := &git.BlameResult{}
+	for i := 1; i <= n; i++ {
+		result.Lines = append(result.Lines, git.BlameLine{
+			LineNumber: i,
+			Hash:       "abc1234",
+			Author:     "Test",
+			Date:       "2025-01-01",
+			Content:    fmt.Sprintf("line %d", i),
+		})
+	}
+	return result
+}
+
+func TestFormatBlameCapsLargeFiles(t *testing.T) {
+	out := formatBlame(blameLines(1000), 100)
+
+	if got := strings.Count(out, " │ abc1234 │ "); got != 100 {
+		t.Errorf("expected 100 blame lines, got %d", got)
+	}
+	if strings.Contains(out, "line 101") {
+		t.Error("lines past the cap should be omitted")
+	}
+	if !strings.Contains(out, "file too large; showing first 100 of 1000 lines; use /blame <file> <start> <end> for a range") {
+		t.Errorf("expected truncation note, got tail:\n%s", out[len(out)-200:])
+	}
+}
+
+func TestFormatBlameUnderCap(t *testing.T) {
+	out := formatBlame(blameLines(10), 100)
+	if strings.Contains(out, "file too large") {
+		t.Error("small files should not be capped")
+	}
+
+	// A zero cap (used for explicit ranges) never truncates
+	if out := formatBlame(blameLines(1000), 0); strings.Contains(out, "file too large") {
+		t.Error("uncapped output should not be truncated")
+	}
+}
+
+func TestFormatChangesBundlesFilesWithinCap(t *testing.T) {
+	changes := []git.FileChange{
+		{Path: "a.go", Status: "modified", Diff: "@@ -1 +1 @@\n-old a\n+new a\n"},
+		{Path: "b.go", Status: "added", Diff: "@@ -0,0 +1 @@\n+new b\n"},
+		{Path: "logo.png", Status: "added", Binary: true},
+		{Path: "huge.txt", Status: "modified", Diff: strings.Repeat("+x\n", 2000)},
+	}
+
+	out := formatChanges(changes, 1024)
+
+	for _, want := range []string{"## Changes (4 files)", "### a.go (modified)", "+new a", "### b.go (added)", "+new b", "logo.png (added, binary; diff omitted)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in bundle, got:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "```diff") != 2 {
+		t.Errorf("expected two diff blocks, got:\n%s", out)
+	}
+	if strings.Contains(out, "+x") || !strings.Contains(out, "not shown: huge.txt") {
+		t.Errorf("diff over the cap should be listed, not included:\n%s", out)
+	}
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"strings"
 )
 
@@ -39,9 +40,40 @@ func IsCommand(input string) bool {
 	return strings.HasPrefix(strings.TrimSpace(input), "/")
 }
 
+// Action is a UI-level effect requested by a command
+type Action int
+
+const (
+	ActionNone        Action = iota
+	ActionSetModel           // Switch the active model to Value (empty shows the current model)
+	ActionListModels         // List the provider's models
+	ActionSetProvider        // Switch to configured provider Value (empty lists providers)
+	ActionSetPersona         // Switch the system prompt to persona Value (empty lists personas)
+	ActionDiffLast           // Diff file Value against the last suggested code for it
+	ActionRepeat             // Re-run the previous slash command
+	ActionContext            // Show attached context, or stop sending it when Value is "clear"
+	ActionRetry              // Regenerate the last assistant reply
+	ActionExport             // Export the transcript; Value holds the /export arguments
+	ActionReload             // Reload the config file and rebuild the AI client
+	ActionConfig             // Show or change settings; Value holds the /config arguments
+	ActionAttachImage        // Send the image data URL in Value with the next message
+	ActionTogglePaste        // Switch Enter between sending and inserting a newline
+	ActionRefreshGit         // Show Output after refreshing the status bar's git state
+	ActionQuit               // Save the session and exit
+	ActionSetTemp            // Set the request temperature to Value (empty shows it, "default" resets it)
+	ActionLoad               // Replace the chat with the saved session named Value
+)
+
 // CommandResult represents the result of a command execution
 type CommandResult struct {
 	Output    string
 	AddToChat bool // If true, add to chat as context
 	Error     error
+	Action    Action // Effect for the UI to apply
+	Value     string // Argument for Action
+
+	// Async is slow work, such as running a program or fetching a page, that
+	// the UI runs off its event loop; its result is shown in place of this
+	// one. ctx is cancelled when the user cancels the command.
+	Async func(ctx context.Context) CommandResult
 }
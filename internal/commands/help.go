@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CommandInfo describes a slash command for help output
+type CommandInfo struct {
+	Name        string
+	Args        string
+	Description string
+
+	// FileTarget marks commands whose first argument is a file, so the UI
+	// can fill in the active file when none is given
+	FileTarget bool
+}
+
+// ExecuteHelp lists the built-in slash commands. A Registry's /help also
+// lists the aliases added to it.
+func ExecuteHelp(cmd *Command) CommandResult {
+	return NewRegistry().executeHelp(cmd)
+}
+
+// executeHelp lists all registered slash commands
+func (r *Registry) executeHelp(cmd *Command) CommandResult {
+	var builder strings.Builder
+	builder.WriteString("## Commands\n\n")
+	builder.WriteString("| Command | Arguments | Description |\n")
+	builder.WriteString("|---------|-----------|-------------|\n")
+
+	for _, info := range r.Commands() {
+		builder.WriteString(fmt.Sprintf("| /%s | %s | %s |\n", info.Name, info.Args, info.Description))
+	}
+
+	aliases := r.Aliases()
+	if len(aliases) > 0 {
+		names := make([]string, 0, len(aliases))
+		for alias := range aliases {
+			names = append(names, alias)
+		}
+		sort.Strings(names)
+
+		builder.WriteString("\n### Aliases\n\n")
+		for _, alias := range names {
+			builder.WriteString(fmt.Sprintf("- /%s → /%s\n", alias, aliases[alias]))
+		}
+	}
+
+	return CommandResult{
+		Output: builder.String(),
+	}
+}
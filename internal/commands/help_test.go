@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecuteHelp(t *testing.T) {
+	result := NewRegistry().Dispatch(Parse("/help"))
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.AddToChat {
+		t.Error("help output should not be added to chat context")
+	}
+
+	for _, name := range []string{"/diff", "/log", "/blame"} {
+		if !strings.Contains(result.Output, name) {
+			t.Errorf("help output should mention %s", name)
+		}
+	}
+}
+
+func TestExecuteHelpListsBuiltins(t *testing.T) {
+	result := ExecuteHelp(Parse("/help"))
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if want := NewRegistry().Dispatch(Parse("/help")).Output; result.Output != want {
+		t.Errorf("ExecuteHelp should match a new registry's /help, got:\n%s", result.Output)
+	}
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxImageSize is the largest image /image will attach; providers reject
+// bigger uploads
+const maxImageSize = 20 * 1024 * 1024
+
+// ExecuteImage reads a local image and asks the UI to attach it, base64
+// encoded, to the next message
+func ExecuteImage(cmd *Command) CommandResult {
+	root, err := workDir()
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	return loadImage(root, cmd.Args)
+}
+
+func loadImage(root string, args []string) CommandResult {
+	if len(args) != 1 {
+		return CommandResult{Error: fmt.Errorf("usage: /image <path>")}
+	}
+
+	data, rel, err := readWorkFile(root, args[0], maxImageSize)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	url, err := imageDataURL(data)
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("%s: %w", rel, err)}
+	}
+
+	return CommandResult{
+		Output: fmt.Sprintf("Attached %s (%d KB) to your next message", rel, (len(data)+1023)/1024),
+		Action: ActionAttachImage,
+		Value:  url,
+	}
+}
+
+// imageDataURL encodes an image as a base64 data URL, refusing anything that
+// isn't an image format the providers accept
+func imageDataURL(data []byte) (string, error) {
+	mimeType := http.DetectContentType(data)
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return "", fmt.Errorf("not a PNG, JPEG, GIF or WebP image (%s)", strings.TrimSuffix(mimeType, "; charset=utf-8"))
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
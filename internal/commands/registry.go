@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kbesada/flux-code-cli/internal/git"
+)
+
+// Handler executes a parsed command
+type Handler func(cmd *Command) CommandResult
+
+// Registry maps command names to handlers and help metadata
+type Registry struct {
+	handlers map[string]Handler
+	infos    map[string]CommandInfo
+	order    []string
+	disabled map[string]bool
+	aliases  map[string]string
+}
+
+// NewRegistry creates a registry with the built-in commands registered
+func NewRegistry() *Registry {
+	r := &Registry{
+		handlers: make(map[string]Handler),
+		infos:    make(map[string]CommandInfo),
+		disabled: make(map[string]bool),
+		aliases:  make(map[string]string),
+	}
+
+	r.RegisterWithInfo(CommandInfo{Name: "help", Description: "Show available commands"}, r.executeHelp)
+	r.RegisterWithInfo(CommandInfo{Name: "diff", Args: "[file] \\| <from> <to> [file]", Description: "Add unstaged changes, or changes between two revisions, to the chat"}, gitHandler(executeDiff))
+	r.RegisterWithInfo(CommandInfo{Name: "staged", Description: "Add staged changes to the chat"}, gitHandler(executeStaged))
+	r.RegisterWithInfo(CommandInfo{Name: "changes", Description: "Add the diff of every changed file, staged or not, for review"}, gitHandler(executeChanges))
+	r.RegisterWithInfo(CommandInfo{Name: "log", Args: "[file] [n]", Description: "Add the last n commits, optionally of one file, to the chat (default 10)"}, gitHandler(executeLog))
+	r.RegisterWithInfo(CommandInfo{Name: "blame", Args: "<file> [start] [end]", Description: "Add blame for a file or line range", FileTarget: true}, gitHandler(executeBlame))
+	r.RegisterWithInfo(CommandInfo{Name: "branch", Args: "[-b] [-f] [name]", Description: "Show the current branch, or switch to (-b: create) another"}, gitHandler(executeBranch))
+	r.RegisterWithInfo(CommandInfo{Name: "status", Description: "Show staged, modified, and untracked files"}, gitHandler(executeStatus))
+	r.RegisterWithInfo(CommandInfo{Name: "add", Args: "<file> [file...] \\| .", Description: "Stage files for commit"}, gitHandler(executeAdd))
+	r.RegisterWithInfo(CommandInfo{Name: "unstage", Args: "<file> [file...] \\| .", Description: "Unstage files, keeping working tree changes"}, gitHandler(executeUnstage))
+	r.RegisterWithInfo(CommandInfo{Name: "stash", Args: "[message] \\| pop", Description: "Shelve uncommitted changes, or restore the last stash"}, gitHandler(executeStash))
+	r.RegisterWithInfo(CommandInfo{Name: "commit", Args: "[message]", Description: "Commit staged changes, or ask the assistant for a message"}, gitHandler(executeCommit))
+	r.RegisterWithInfo(CommandInfo{Name: "search", Args: "[--context N] [-i] <pattern>", Description: "Search tracked files for a pattern"}, gitHandler(executeSearch))
+	r.RegisterWithInfo(CommandInfo{Name: "file", Args: "<path>[:start-end] [path...]", Description: "Add file contents, or a line range, to the chat", FileTarget: true}, ExecuteFile)
+	r.RegisterWithInfo(CommandInfo{Name: "image", Args: "<path>", Description: "Attach an image to your next message"}, ExecuteImage)
+	r.RegisterWithInfo(CommandInfo{Name: "difflast", Args: "<file>", Description: "Diff a file against the assistant's last code for it", FileTarget: true}, executeDiffLast)
+	r.RegisterWithInfo(CommandInfo{Name: "context", Args: "[clear]", Description: "Show attached context, or stop sending it while keeping the chat"}, executeContext)
+	r.RegisterWithInfo(CommandInfo{Name: "export", Args: "[--redact] [--redact-paths] [file]", Description: "Save the chat as markdown, optionally masking secrets and paths"}, executeExport)
+	r.RegisterWithInfo(CommandInfo{Name: "retry", Description: "Discard the last response and ask again"}, executeRetry)
+	r.RegisterWithInfo(CommandInfo{Name: "again", Description: "Re-run the previous slash command"}, executeAgain)
+	r.RegisterWithInfo(CommandInfo{Name: "model", Args: "[name]", Description: "Show or switch the active model (partial names match)"}, executeModel)
+	r.RegisterWithInfo(CommandInfo{Name: "models", Description: "List the models the provider offers"}, executeModels)
+	r.RegisterWithInfo(CommandInfo{Name: "provider", Args: "[name]", Description: "List configured providers or switch to one (partial names match)"}, executeProvider)
+	r.RegisterWithInfo(CommandInfo{Name: "reload", Description: "Reload the config file and reconnect, keeping the chat"}, executeReload)
+	r.RegisterWithInfo(CommandInfo{Name: "config", Args: "[set <key> <value> [--save]]", Description: "Show the config or change a setting"}, executeConfig)
+	r.RegisterWithInfo(CommandInfo{Name: "paste", Description: "Toggle paste mode: Enter adds a line, Ctrl+S sends"}, executePaste)
+	r.RegisterWithInfo(CommandInfo{Name: "temp", Args: "[0-2\\|default]", Description: "Show or set the temperature for the next requests"}, executeTemp)
+	r.RegisterWithInfo(CommandInfo{Name: "persona", Args: "[name]", Description: "List personas or switch the system prompt"}, executePersona)
+	r.RegisterWithInfo(CommandInfo{Name: "web", Args: "<url>", Description: "Fetch a web page and add its text to the chat"}, executeWeb)
+	r.RegisterWithInfo(CommandInfo{Name: "run", Args: "<command> [args...]", Description: "Run an allow-listed command and add its output to the chat"}, ExecuteRun)
+	r.RegisterWithInfo(CommandInfo{Name: "load", Args: "[name]", Description: "Replace the chat with a saved session (default last-session)"}, executeLoad)
+	r.RegisterWithInfo(CommandInfo{Name: "quit", Description: "Save the chat as last-session and exit"}, executeQuit)
+	r.RegisterWithInfo(CommandInfo{Name: "exit", Description: "Same as /quit"}, executeQuit)
+
+	return r
+}
+
+// Register adds/overrides the handler for a command name
+func (r *Registry) Register(name string, handler Handler) {
+	info, ok := r.infos[name]
+	if !ok {
+		info = CommandInfo{Name: name}
+	}
+	r.RegisterWithInfo(info, handler)
+}
+
+// RegisterWithInfo adds/overrides a handler along with its help metadata
+func (r *Registry) RegisterWithInfo(info CommandInfo, handler Handler) {
+	if _, exists := r.handlers[info.Name]; !exists {
+		r.order = append(r.order, info.Name)
+	}
+	r.handlers[info.Name] = handler
+	r.infos[info.Name] = info
+}
+
+// AddAlias makes /alias run /target. The target may itself be an alias, but
+// the chain must end at a registered command; cycles are rejected.
+func (r *Registry) AddAlias(alias, target string) error {
+	alias = normalizeName(alias)
+	target = normalizeName(target)
+	if alias == "" || target == "" {
+		return fmt.Errorf("invalid alias %q -> %q", alias, target)
+	}
+	if _, ok := r.handlers[alias]; ok {
+		return fmt.Errorf("alias /%s would shadow a built-in command", alias)
+	}
+
+	seen := map[string]bool{alias: true}
+	for name := target; ; {
+		if seen[name] {
+			return fmt.Errorf("alias /%s -> /%s forms a cycle", alias, target)
+		}
+		seen[name] = true
+
+		next, ok := r.aliases[name]
+		if !ok {
+			if _, ok := r.handlers[name]; !ok {
+				return fmt.Errorf("alias /%s points to unknown command /%s", alias, target)
+			}
+			break
+		}
+		name = next
+	}
+
+	r.aliases[alias] = target
+	return nil
+}
+
+// Aliases returns a copy of the alias -> target map
+func (r *Registry) Aliases() map[string]string {
+	aliases := make(map[string]string, len(r.aliases))
+	for alias, target := range r.aliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+// resolve follows aliases to a command name. AddAlias rejects cycles, so the
+// hop limit is only a safeguard.
+func (r *Registry) resolve(name string) string {
+	for range len(r.aliases) {
+		target, ok := r.aliases[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return name
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "/"))
+}
+
+// Disable hides commands from help and makes dispatching them fail. Names
+// may be given with or without the leading slash.
+func (r *Registry) Disable(names ...string) {
+	for _, name := range names {
+		name = normalizeName(name)
+		if name != "" {
+			r.disabled[name] = true
+		}
+	}
+}
+
+// Info returns help metadata for a command or alias
+func (r *Registry) Info(name string) (CommandInfo, bool) {
+	info, ok := r.infos[r.resolve(normalizeName(name))]
+	return info, ok
+}
+
+// Commands returns help metadata for every enabled command in registration order
+func (r *Registry) Commands() []CommandInfo {
+	infos := make([]CommandInfo, 0, len(r.order))
+	for _, name := range r.order {
+		if r.disabled[name] {
+			continue
+		}
+		infos = append(infos, r.infos[name])
+	}
+	return infos
+}
+
+// Complete returns the enabled commands and aliases whose names start with
+// prefix, commands first in registration order, then aliases sorted
+func (r *Registry) Complete(prefix string) []string {
+	prefix = normalizeName(prefix)
+
+	var matches []string
+	for _, info := range r.Commands() {
+		if strings.HasPrefix(info.Name, prefix) {
+			matches = append(matches, info.Name)
+		}
+	}
+
+	var aliases []string
+	for alias, target := range r.aliases {
+		if strings.HasPrefix(alias, prefix) && !r.disabled[r.resolve(target)] {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+
+	return append(matches, aliases...)
+}
+
+// Dispatch routes a parsed command to its handler
+func (r *Registry) Dispatch(cmd *Command) CommandResult {
+	if cmd == nil {
+		return CommandResult{Error: fmt.Errorf("invalid command")}
+	}
+
+	if name := r.resolve(cmd.Name); name != cmd.Name {
+		resolved := *cmd
+		resolved.Name = name
+		cmd = &resolved
+	}
+
+	if r.disabled[cmd.Name] {
+		return CommandResult{
+			Error: fmt.Errorf("command disabled: /%s", cmd.Name),
+		}
+	}
+
+	handler, ok := r.handlers[cmd.Name]
+	if !ok {
+		return CommandResult{
+			Error: fmt.Errorf("unknown command: /%s (try /help)", cmd.Name),
+		}
+	}
+
+	return handler(cmd)
+}
+
+// gitHandler adapts a repository command to a Handler, opening the repo first
+func gitHandler(fn func(repo *git.Repo, args []string) CommandResult) Handler {
+	return func(cmd *Command) CommandResult {
+		repo, err := git.Open("")
+		if err != nil {
+			return CommandResult{
+				Error: fmt.Errorf("not in a git repository: %w", err),
+			}
+		}
+		return fn(repo, cmd.Args)
+	}
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryDispatchCustomCommand(t *testing.T) {
+	r := NewRegistry()
+
+	var got *Command
+	r.Register("echo", func(cmd *Command) CommandResult {
+		got = cmd
+		return CommandResult{Output: "echoed"}
+	})
+
+	result := r.Dispatch(Parse("/echo one two"))
+	if result.Output != "echoed" {
+		t.Errorf("expected custom handler output, got %q", result.Output)
+	}
+	if got == nil || len(got.Args) != 2 || got.Args[0] != "one" {
+		t.Errorf("handler should receive parsed command, got %+v", got)
+	}
+}
+
+func TestRegistryDispatchQuit(t *testing.T) {
+	for _, name := range []string{"/quit", "/exit"} {
+		if result := NewRegistry().Dispatch(Parse(name)); result.Action != ActionQuit {
+			t.Errorf("%s: action = %v, want ActionQuit", name, result.Action)
+		}
+	}
+}
+
+func TestRegistryDispatchUnknownCommand(t *testing.T) {
+	result := NewRegistry().Dispatch(Parse("/nope"))
+	if result.Error == nil {
+		t.Error("expected error for unknown command")
+	}
+}
+
+func TestRegistryRegisterOverrides(t *testing.T) {
+	r := NewRegistry()
+	before := len(r.Commands())
+
+	r.Register("status", func(cmd *Command) CommandResult {
+		return CommandResult{Output: "overridden"}
+	})
+
+	if len(r.Commands()) != before {
+		t.Error("overriding a command should not duplicate it")
+	}
+	if r.Dispatch(Parse("/status")).Output != "overridden" {
+		t.Error("expected overridden handler to run")
+	}
+}
+
+func TestRegistryDisabledCommand(t *testing.T) {
+	r := NewRegistry()
+
+	ran := false
+	r.Register("echo", func(cmd *Command) CommandResult {
+		ran = true
+		return CommandResult{Output: "echoed"}
+	})
+	r.Disable("/echo", "Status")
+
+	result := r.Dispatch(Parse("/echo hi"))
+	if ran {
+		t.Error("disabled command should not run")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "command disabled") {
+		t.Errorf("expected 'command disabled' error, got %v", result.Error)
+	}
+
+	help := r.Dispatch(Parse("/help")).Output
+	if strings.Contains(help, "/echo") || strings.Contains(help, "/status") {
+		t.Errorf("disabled commands should be absent from help:\n%s", help)
+	}
+	if !strings.Contains(help, "/diff") {
+		t.Error("enabled commands should still be listed")
+	}
+}
+
+func TestRegistryAliasResolves(t *testing.T) {
+	r := NewRegistry()
+
+	var got *Command
+	r.Register("echo", func(cmd *Command) CommandResult {
+		got = cmd
+		return CommandResult{Output: "echoed"}
+	})
+	if err := r.AddAlias("e", "echo"); err != nil {
+		t.Fatalf("AddAlias() error: %v", err)
+	}
+	if err := r.AddAlias("/ee", "/e"); err != nil {
+		t.Fatalf("AddAlias() to another alias error: %v", err)
+	}
+
+	result := r.Dispatch(Parse("/ee one"))
+	if result.Output != "echoed" {
+		t.Errorf("expected alias to run target, got %+v", result)
+	}
+	if got == nil || got.Name != "echo" || len(got.Args) != 1 {
+		t.Errorf("handler should receive resolved command, got %+v", got)
+	}
+
+	help := r.Dispatch(Parse("/help")).Output
+	if !strings.Contains(help, "/e → /echo") {
+		t.Errorf("help should list aliases:\n%s", help)
+	}
+}
+
+func TestRegistryAliasRejectsCycles(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.AddAlias("loop", "loop"); err == nil {
+		t.Error("self-referential alias should be rejected")
+	}
+	if err := r.AddAlias("s", "nope"); err == nil {
+		t.Error("alias to unknown command should be rejected")
+	}
+	if err := r.AddAlias("status", "diff"); err == nil {
+		t.Error("alias shadowing a command should be rejected")
+	}
+	if result := r.Dispatch(Parse("/loop")); result.Error == nil {
+		t.Error("rejected alias should not be dispatchable")
+	}
+}
+
+func TestRegistryComplete(t *testing.T) {
+	r := NewRegistry()
+
+	got := r.Complete("/di")
+	if len(got) != 2 || got[0] != "diff" || got[1] != "difflast" {
+		t.Errorf("expected [diff difflast], got %v", got)
+	}
+
+	if got := r.Complete("/sta"); len(got) != 3 || got[0] != "staged" || got[1] != "status" || got[2] != "stash" {
+		t.Errorf("expected [staged status stash], got %v", got)
+	}
+	if got := r.Complete("/zzz"); len(got) != 0 {
+		t.Errorf("expected no matches, got %v", got)
+	}
+}
+
+func TestRegistryCompleteSkipsDisabledAndIncludesAliases(t *testing.T) {
+	r := NewRegistry()
+	r.Disable("difflast")
+	if err := r.AddAlias("dd", "diff"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.Complete("d"); len(got) != 2 || got[0] != "diff" || got[1] != "dd" {
+		t.Errorf("expected [diff dd], got %v", got)
+	}
+}
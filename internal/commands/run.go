@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kbesada/flux-code-cli/internal/exec"
+)
+
+// ExecuteRun runs an allow-listed command from the repository root and adds
+// its output to the chat. The command runs in the background, so the UI
+// stays responsive and Esc can stop it.
+func ExecuteRun(cmd *Command) CommandResult {
+	if len(cmd.Args) == 0 {
+		return CommandResult{
+			Error: fmt.Errorf("usage: /run <command> [args...]"),
+		}
+	}
+
+	dir, err := workDir()
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	runner := exec.NewRunner(dir)
+	if !runner.Allowed(cmd.Args[0]) {
+		return CommandResult{Error: fmt.Errorf("command %q is not allowed", cmd.Args[0])}
+	}
+	return CommandResult{
+		Async: func(ctx context.Context) CommandResult {
+			return runWith(ctx, runner, cmd.Args)
+		},
+	}
+}
+
+func runWith(ctx context.Context, runner *exec.Runner, args []string) CommandResult {
+	if len(args) == 0 {
+		return CommandResult{
+			Error: fmt.Errorf("usage: /run <command> [args...]"),
+		}
+	}
+
+	result, err := runner.Run(ctx, args[0], args[1:]...)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("## Output of `%s`\n\n", strings.Join(args, " ")))
+
+	switch {
+	case ctx.Err() != nil && !result.TimedOut:
+		return CommandResult{Error: fmt.Errorf("%s: %w", args[0], ctx.Err())}
+	case result.TimedOut:
+		builder.WriteString("Status: **timed out**\n\n")
+	case result.ExitCode != 0:
+		builder.WriteString(fmt.Sprintf("Status: **exit %d**\n\n", result.ExitCode))
+	}
+
+	output := strings.TrimRight(result.Output, "\n")
+	if output == "" {
+		output = "(no output)"
+	}
+	builder.WriteString(fmt.Sprintf("```\n%s\n```\n", output))
+
+	if result.Truncated {
+		builder.WriteString("_(output truncated)_\n")
+	}
+
+	return CommandResult{
+		Output:    builder.String(),
+		AddToChat: true,
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/exec"
+)
+
+func TestRunWithAddsOutputToChat(t *testing.T) {
+	runner := &exec.Runner{
+		AllowList: []string{"echo"},
+		Timeout:   5 * time.Second,
+		MaxOutput: 1024,
+		Dir:       t.TempDir(),
+	}
+
+	result := runWith(context.Background(), runner, []string{"echo", "hello", "from", "run"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if !result.AddToChat {
+		t.Error("run output should be added to chat context")
+	}
+	if !strings.Contains(result.Output, "hello from run") {
+		t.Errorf("output should contain command output, got:\n%s", result.Output)
+	}
+}
+
+func TestRunWithRejectsDisallowedCommand(t *testing.T) {
+	runner := &exec.Runner{AllowList: []string{"echo"}}
+
+	result := runWith(context.Background(), runner, []string{"rm", "-rf", "/"})
+	if result.Error == nil {
+		t.Fatal("expected error for disallowed command")
+	}
+}
+
+func TestRunWithTruncatesOutput(t *testing.T) {
+	runner := &exec.Runner{
+		AllowList: []string{"echo"},
+		Timeout:   5 * time.Second,
+		MaxOutput: 4,
+	}
+
+	result := runWith(context.Background(), runner, []string{"echo", "abcdefgh"})
+	if !strings.Contains(result.Output, "```\nabcd\n```") {
+		t.Errorf("output should be capped to 4 bytes, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "output truncated") {
+		t.Error("output should note truncation")
+	}
+}
+
+func TestExecuteRunChecksBeforeRunning(t *testing.T) {
+	if result := ExecuteRun(&Command{Name: "run"}); result.Error == nil || result.Async != nil {
+		t.Errorf("expected a usage error, got %+v", result)
+	}
+
+	result := ExecuteRun(&Command{Name: "run", Args: []string{"rm", "-rf", "/"}})
+	if result.Error == nil || result.Async != nil {
+		t.Fatalf("a disallowed command should be refused before anything runs, got %+v", result)
+	}
+
+	result = ExecuteRun(&Command{Name: "run", Args: []string{"go", "version"}})
+	if result.Error != nil || result.Async == nil {
+		t.Errorf("an allowed command should run in the background, got %+v", result)
+	}
+}
+
+func TestRunWithCancelled(t *testing.T) {
+	runner := &exec.Runner{AllowList: []string{"sleep"}, Timeout: 5 * time.Second}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := runWith(ctx, runner, []string{"sleep", "5"})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "canceled") {
+		t.Errorf("expected a cancellation error, got %+v", result)
+	}
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kbesada/flux-code-cli/internal/session"
+)
+
+// executeModel asks the UI to show or switch the active model
+func executeModel(cmd *Command) CommandResult {
+	return CommandResult{
+		Action: ActionSetModel,
+		Value:  strings.Join(cmd.Args, " "),
+	}
+}
+
+// executeReload asks the UI to reload the config and rebuild the client
+func executeReload(cmd *Command) CommandResult {
+	return CommandResult{Action: ActionReload}
+}
+
+// executeConfig asks the UI to show the config or change a setting
+func executeConfig(cmd *Command) CommandResult {
+	return CommandResult{
+		Action: ActionConfig,
+		Value:  strings.Join(cmd.Args, " "),
+	}
+}
+
+const (
+	// MaxTemperature is the highest temperature /temp accepts
+	MaxTemperature = 2.0
+	// DefaultTemperature is the /temp argument that drops a temperature
+	// set earlier in favour of the configured one
+	DefaultTemperature = "default"
+)
+
+// executeTemp validates a temperature and asks the UI to use it for the
+// next requests. Without an argument the UI shows the current one.
+func executeTemp(cmd *Command) CommandResult {
+	if len(cmd.Args) == 0 {
+		return CommandResult{Action: ActionSetTemp}
+	}
+	if len(cmd.Args) > 1 {
+		return CommandResult{Error: fmt.Errorf("usage: /temp [0-2|default]")}
+	}
+	if strings.EqualFold(cmd.Args[0], DefaultTemperature) {
+		return CommandResult{Action: ActionSetTemp, Value: DefaultTemperature}
+	}
+
+	temp, err := strconv.ParseFloat(cmd.Args[0], 32)
+	if err != nil || temp < 0 || temp > MaxTemperature {
+		return CommandResult{Error: fmt.Errorf("temperature must be a number from 0 to %g, got %q", MaxTemperature, cmd.Args[0])}
+	}
+	return CommandResult{
+		Action: ActionSetTemp,
+		Value:  strconv.FormatFloat(temp, 'g', -1, 32),
+	}
+}
+
+// executeLoad asks the UI to replace the chat with a saved session,
+// last-session by default
+func executeLoad(cmd *Command) CommandResult {
+	if len(cmd.Args) > 1 {
+		return CommandResult{Error: fmt.Errorf("usage: /load [name]")}
+	}
+	name := session.LastSession
+	if len(cmd.Args) == 1 {
+		name = cmd.Args[0]
+	}
+	return CommandResult{Action: ActionLoad, Value: name}
+}
+
+// executeQuit asks the UI to exit
+func executeQuit(cmd *Command) CommandResult {
+	return CommandResult{Action: ActionQuit}
+}
+
+// executePaste asks the UI to toggle paste mode
+func executePaste(cmd *Command) CommandResult {
+	return CommandResult{Action: ActionTogglePaste}
+}
+
+// executeModels asks the UI to list the provider's models
+func executeModels(cmd *Command) CommandResult {
+	return CommandResult{Action: ActionListModels}
+}
+
+// executeProvider asks the UI to list or switch providers
+func executeProvider(cmd *Command) CommandResult {
+	return CommandResult{
+		Action: ActionSetProvider,
+		Value:  strings.Join(cmd.Args, " "),
+	}
+}
+
+// executePersona asks the UI to list or switch personas
+func executePersona(cmd *Command) CommandResult {
+	return CommandResult{
+		Action: ActionSetPersona,
+		Value:  strings.ToLower(strings.Join(cmd.Args, " ")),
+	}
+}
+
+// executeContext asks the UI to summarize or clear attached context
+func executeContext(cmd *Command) CommandResult {
+	if len(cmd.Args) > 1 || (len(cmd.Args) == 1 && strings.ToLower(cmd.Args[0]) != "clear") {
+		return CommandResult{Error: fmt.Errorf("usage: /context [clear]")}
+	}
+	return CommandResult{
+		Action: ActionContext,
+		Value:  strings.ToLower(strings.Join(cmd.Args, " ")),
+	}
+}
+
+// executeRetry asks the UI to regenerate the last response
+func executeRetry(cmd *Command) CommandResult {
+	return CommandResult{Action: ActionRetry}
+}
+
+// executeAgain asks the UI to re-run the previous slash command
+func executeAgain(cmd *Command) CommandResult {
+	return CommandResult{Action: ActionRepeat}
+}
@@ -0,0 +1,305 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+const (
+	// webTimeout bounds a /web fetch, including reading the body
+	webTimeout = 15 * time.Second
+	// maxWebBody is the most of a response /web reads
+	maxWebBody = 2 * 1024 * 1024
+	// maxWebText caps the page text /web adds to the chat
+	maxWebText = 64 * 1024
+)
+
+// executeWeb fetches a URL in the background and adds its readable text to
+// the chat
+func executeWeb(cmd *Command) CommandResult {
+	if len(cmd.Args) != 1 {
+		return CommandResult{Error: fmt.Errorf("usage: /web <url>")}
+	}
+
+	var httpCfg config.HTTPConfig
+	allowPrivate := false
+	if cfg := config.Get(); cfg != nil {
+		httpCfg, allowPrivate = cfg.HTTP, cfg.Web.AllowPrivate
+	}
+	client := webClient(httpCfg, allowPrivate)
+	return CommandResult{
+		Async: func(ctx context.Context) CommandResult {
+			ctx, cancel := context.WithTimeout(ctx, webTimeout)
+			defer cancel()
+			return fetchWeb(ctx, client, cmd.Args[0], maxWebText)
+		},
+	}
+}
+
+// webClient returns the client /web fetches with. It uses the transport
+// settings and proxy the providers do and, unless allowPrivate is set,
+// refuses hosts on the local network, including after redirects.
+func webClient(cfg config.HTTPConfig, allowPrivate bool) *http.Client {
+	var transport http.RoundTripper = ai.NewTransport(cfg)
+	if !allowPrivate {
+		transport = publicOnly{next: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// publicOnly is a RoundTripper that refuses requests to hosts that resolve to
+// loopback, link-local, private or unspecified addresses
+type publicOnly struct {
+	next http.RoundTripper
+}
+
+func (p publicOnly) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := checkPublicHost(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return p.next.RoundTrip(req)
+}
+
+// checkPublicHost returns an error if host is, or resolves to, an address
+// that isn't on the public internet
+func checkPublicHost(ctx context.Context, host string) error {
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{addr}
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+		if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+			addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+			return fmt.Errorf("%s is a local or private address (%s); set web.allow_private to fetch it", host, addr.WithZone(""))
+		}
+	}
+	return nil
+}
+
+// fetchWeb gets an http or https URL and formats its text for the chat.
+// HTML is reduced to its readable text; other text types are kept as is.
+func fetchWeb(ctx context.Context, client *http.Client, rawURL string, limit int) CommandResult {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return CommandResult{Error: fmt.Errorf("not an http or https URL: %s", rawURL)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return CommandResult{Error: err}
+	}
+	req.Header.Set("User-Agent", "flux")
+	req.Header.Set("Accept", "text/html, text/plain;q=0.9, */*;q=0.1")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return CommandResult{Error: fmt.Errorf("fetching %s: timed out after %s", u, webTimeout)}
+		}
+		return CommandResult{Error: fmt.Errorf("fetching %s: %w", u, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return CommandResult{Error: fmt.Errorf("fetching %s: %s", u, resp.Status)}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isHTML := mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml"
+	if !isHTML && !strings.HasPrefix(mediaType, "text/") && mediaType != "application/json" {
+		return CommandResult{Error: fmt.Errorf("fetching %s: unsupported content type %s", u, mediaType)}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWebBody))
+	if err != nil {
+		return CommandResult{Error: fmt.Errorf("fetching %s: %w", u, err)}
+	}
+
+	title, text := "", strings.TrimSpace(string(body))
+	if isHTML {
+		title, text = htmlText(string(body))
+	}
+	if text == "" {
+		return CommandResult{Output: fmt.Sprintf("No readable text at %s.", u)}
+	}
+
+	truncated := false
+	if len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text, truncated = strings.TrimRight(text[:cut], " \n"), true
+	}
+
+	var builder strings.Builder
+	if title != "" {
+		builder.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", title, u))
+	} else {
+		builder.WriteString(fmt.Sprintf("## %s\n\n", u))
+	}
+	builder.WriteString(text + "\n")
+	if truncated {
+		builder.WriteString("\n_(page truncated)_\n")
+	}
+
+	return CommandResult{
+		Output:    builder.String(),
+		AddToChat: true,
+	}
+}
+
+// skippedElements hold no readable text
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Form:     true,
+}
+
+// blockElements start a new line of text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Tr: true, atom.Pre: true, atom.Blockquote: true,
+	atom.Section: true, atom.Article: true, atom.Main: true, atom.Header: true, atom.Aside: true,
+	atom.Figure: true, atom.Figcaption: true,
+}
+
+// paragraphElements are set apart from the text around them by a blank line
+var paragraphElements = map[atom.Atom]bool{
+	atom.P: true, atom.Pre: true, atom.Blockquote: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+var (
+	spaceRun = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankRun = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlText returns a page's title and its readable text: markup, scripts,
+// styles and navigation are dropped, block elements become lines, list
+// items get a "- " bullet and <pre> keeps its layout
+func htmlText(page string) (title, text string) {
+	z := html.NewTokenizer(strings.NewReader(page))
+
+	var b strings.Builder
+	skip, pre := 0, 0
+	inTitle := false
+	newline := func(n int) {
+		s := b.String()
+		trailing := len(s) - len(strings.TrimRight(s, "\n"))
+		if len(s) > 0 && trailing < n {
+			b.WriteString(strings.Repeat("\n", n-trailing))
+		}
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		name, _ := z.TagName()
+		a := atom.Lookup(name)
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if a == atom.Title {
+				inTitle = tt == html.StartTagToken
+				continue
+			}
+			if skippedElements[a] && tt == html.StartTagToken {
+				skip++
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			if a == atom.Pre {
+				pre++
+			}
+			switch {
+			case a == atom.Li:
+				newline(1)
+				b.WriteString("- ")
+			case paragraphElements[a]:
+				newline(2)
+			case blockElements[a]:
+				newline(1)
+			case a == atom.Td || a == atom.Th:
+				b.WriteString(" ")
+			}
+		case html.EndTagToken:
+			if a == atom.Title {
+				inTitle = false
+				continue
+			}
+			if skippedElements[a] {
+				skip = max(skip-1, 0)
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			if a == atom.Pre {
+				pre = max(pre-1, 0)
+			}
+			if blockElements[a] {
+				newline(1)
+			}
+		case html.TextToken:
+			raw := string(z.Text())
+			if inTitle {
+				title += raw
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			if pre > 0 {
+				b.WriteString(raw)
+				continue
+			}
+			s := spaceRun.ReplaceAllString(raw, " ")
+			if strings.HasSuffix(b.String(), "\n") || b.Len() == 0 {
+				s = strings.TrimLeft(s, " ")
+			}
+			b.WriteString(s)
+		}
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	text = blankRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(spaceRun.ReplaceAllString(title, " ")), strings.TrimSpace(text)
+}
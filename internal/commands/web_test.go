@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Flux &amp; Friends</title>
+  <style>body { color: red; }</style>
+  <script>var tracking = "secret";</script>
+</head>
+<body>
+  <nav><a href="/">Home</a></nav>
+  <h1>Getting   started</h1>
+  <p>Install with <code>go install</code>, then run
+     <b>flux</b>.</p>
+  <ul><li>Fast</li><li>Local &lt;first&gt;</li></ul>
+</body>
+</html>`
+
+func TestFetchWebStripsHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(testPage))
+	}))
+	defer server.Close()
+
+	result := fetchWeb(context.Background(), server.Client(), server.URL, maxWebText)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if !result.AddToChat {
+		t.Error("page text should be added to chat context")
+	}
+
+	for _, want := range []string{
+		"## Flux & Friends",
+		"Getting started",
+		"Install with go install, then run flux.",
+		"- Fast\n- Local <first>",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, result.Output)
+		}
+	}
+	for _, unwanted := range []string{"<p>", "color: red", "tracking", "Home"} {
+		if strings.Contains(result.Output, unwanted) {
+			t.Errorf("output should not contain %q, got:\n%s", unwanted, result.Output)
+		}
+	}
+}
+
+func TestFetchWebTruncates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("word ", 100)))
+	}))
+	defer server.Close()
+
+	result := fetchWeb(context.Background(), server.Client(), server.URL, 20)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if strings.Count(result.Output, "word") != 4 || !strings.Contains(result.Output, "page truncated") {
+		t.Errorf("text should be cut to 20 bytes and noted, got:\n%s", result.Output)
+	}
+}
+
+func TestFetchWebRejectsOtherSchemes(t *testing.T) {
+	for _, url := range []string{"file:///etc/passwd", "ftp://example.com/x", "example.com"} {
+		if result := fetchWeb(context.Background(), http.DefaultClient, url, maxWebText); result.Error == nil {
+			t.Errorf("%s: expected an error", url)
+		}
+	}
+}
+
+func TestFetchWebReportsHTTPErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	result := fetchWeb(context.Background(), server.Client(), server.URL, maxWebText)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", result.Error)
+	}
+}
+
+func TestFetchWebRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal only"))
+	}))
+	defer server.Close()
+
+	result := fetchWeb(context.Background(), webClient(config.HTTPConfig{}, false), server.URL, maxWebText)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "web.allow_private") {
+		t.Errorf("expected a loopback server to be refused, got %+v", result)
+	}
+
+	result = fetchWeb(context.Background(), webClient(config.HTTPConfig{}, true), server.URL, maxWebText)
+	if result.Error != nil || !strings.Contains(result.Output, "internal only") {
+		t.Errorf("allow_private should let the fetch through, got %+v", result)
+	}
+}
+
+func TestFetchWebRefusesRedirectToPrivateAddress(t *testing.T) {
+	// The first hop is allowed so the redirect itself is what gets refused
+	client := webClient(config.HTTPConfig{}, false)
+	client.Transport = publicOnly{next: redirector{to: "http://169.254.169.254/latest/meta-data/"}}
+
+	result := fetchWeb(context.Background(), client, "http://93.184.216.34/", maxWebText)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "169.254.169.254") {
+		t.Errorf("expected the redirect to be refused, got %+v", result)
+	}
+}
+
+// redirector answers every request with a redirect, without any network
+type redirector struct {
+	to string
+}
+
+func (r redirector) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusFound,
+		Header:     http.Header{"Location": {r.to}},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+func TestCheckPublicHost(t *testing.T) {
+	tests := []struct {
+		host   string
+		public bool
+	}{
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1%eth0", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+	}
+	for _, tt := range tests {
+		err := checkPublicHost(context.Background(), tt.host)
+		if (err == nil) != tt.public {
+			t.Errorf("checkPublicHost(%q) = %v, want public %v", tt.host, err, tt.public)
+		}
+	}
+}
+
+func TestExecuteWebFetchesInBackground(t *testing.T) {
+	if result := executeWeb(&Command{Name: "web"}); result.Error == nil {
+		t.Error("expected a usage error without a URL")
+	}
+	if result := executeWeb(&Command{Name: "web", Args: []string{"https://example.com"}}); result.Error != nil || result.Async == nil {
+		t.Errorf("the fetch should run in the background, got %+v", result)
+	}
+}
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/viper"
+
+	"github.com/kbesada/flux-code-cli/internal/textutil"
 )
 
 var cfg *Config
@@ -17,7 +24,32 @@ func Load() (*Config, error) {
 	v.SetDefault("ui.word_wrap", 80)
 	v.SetDefault("ui.show_tokens", true)
 	v.SetDefault("ui.syntax_highlighting", true)
+	v.SetDefault("ui.group_context", false)
+	v.SetDefault("ui.show_timestamps", false)
+	v.SetDefault("ui.message_spacing", 1)
+	v.SetDefault("ui.thinking_text", "Thinking…")
+	v.SetDefault("ui.spinner", "dot")
+	v.SetDefault("ui.empty_response", EmptyResponseNote)
+	v.SetDefault("ui.min_width", 60)
+	v.SetDefault("ui.min_height", 10)
 	v.SetDefault("system.system_prompt", "You are a helpful AI coding assistant.")
+	v.SetDefault("search.max_matches", 20)
+	v.SetDefault("context.max_tokens", 0)
+	v.SetDefault("context.line_numbers", false)
+	v.SetDefault("blame.max_lines", 500)
+	keys := DefaultKeybindings()
+	v.SetDefault("keybindings.send", keys.Send)
+	v.SetDefault("keybindings.quit", keys.Quit)
+	v.SetDefault("keybindings.clear", keys.Clear)
+	v.SetDefault("keybindings.scroll_up", keys.ScrollUp)
+	v.SetDefault("keybindings.scroll_down", keys.ScrollDown)
+	v.SetDefault("usage.log", false)
+	v.SetDefault("http.max_idle_conns", 100)
+	v.SetDefault("http.max_idle_conns_per_host", 10)
+	v.SetDefault("http.idle_conn_timeout", "90s")
+	v.SetDefault("http.tls_handshake_timeout", "10s")
+	v.SetDefault("web.allow_private", false)
+	v.SetDefault("session.ai_titles", false)
 
 	// Config paths
 	v.SetConfigName("config")
@@ -30,7 +62,14 @@ func Load() (*Config, error) {
 	v.AutomaticEnv()
 
 	// Read config
-	if err := v.ReadInConfig(); err != nil {
+	err := v.ReadInConfig()
+	if path := v.ConfigFileUsed(); path != "" {
+		// Re-read without a BOM or CRLF line endings, which trip up the parser
+		if data, readErr := os.ReadFile(path); readErr == nil {
+			err = v.ReadConfig(bytes.NewReader(textutil.Normalize(data)))
+		}
+	}
+	if err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, err
 		}
@@ -46,15 +85,119 @@ func Load() (*Config, error) {
 		cfg.Providers = make(map[string]Provider)
 	}
 
-	// Expand environment variables in API keys
+	if files := existingConfigFiles(); len(files) > 1 {
+		cfg.Warnings = append(cfg.Warnings, fmt.Sprintf(
+			"multiple config files found (%s); using %s",
+			strings.Join(files, ", "), v.ConfigFileUsed()))
+	}
+	cfg.Validate()
+	loaded = v
+
+	cfg.Usage.Path = os.ExpandEnv(cfg.Usage.Path)
+
+	// Expand environment variables in API keys and endpoint settings
 	for name, provider := range cfg.Providers {
 		provider.APIKey = os.ExpandEnv(provider.APIKey)
+		provider.BaseURL = os.ExpandEnv(provider.BaseURL)
+		provider.Model = os.ExpandEnv(provider.Model)
+		provider.AuthHeader = os.ExpandEnv(provider.AuthHeader)
+		provider.AuthPrefix = os.ExpandEnv(provider.AuthPrefix)
+		provider.ProxyURL = os.ExpandEnv(provider.ProxyURL)
+		for header, value := range provider.Headers {
+			provider.Headers[header] = os.ExpandEnv(value)
+		}
+		if provider.APIKey == "" && provider.APIKeyFile != "" {
+			key, err := readAPIKeyFile(os.ExpandEnv(provider.APIKeyFile))
+			if err != nil {
+				cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("providers.%s: %v", name, err))
+			}
+			provider.APIKey = key
+		}
 		cfg.Providers[name] = provider
 	}
 
 	return cfg, nil
 }
 
+// Validate records warnings for ambiguous settings. It never fails; the
+// warnings explain which value takes precedence.
+func (c *Config) Validate() {
+	if _, ok := c.Providers[c.Provider]; !ok && c.Provider != "" {
+		c.Warnings = append(c.Warnings, fmt.Sprintf(
+			"provider %q is selected but has no entry under providers", c.Provider))
+	}
+
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := c.Providers[name]
+		if p.APIKey != "" && p.APIKeyFile != "" {
+			c.Warnings = append(c.Warnings, fmt.Sprintf(
+				"providers.%s: both api_key and api_key_file are set; using api_key", name))
+		}
+	}
+}
+
+// existingConfigFiles returns the config files present in the search paths
+func existingConfigFiles() []string {
+	var dirs []string
+	if dir, err := Dir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	dirs = append(dirs, ".")
+
+	var files []string
+	for _, dir := range dirs {
+		for _, ext := range []string{"yaml", "yml"} {
+			path := filepath.Join(dir, "config."+ext)
+			if _, err := os.Stat(path); err == nil {
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+func readAPIKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading api_key_file: %w", err)
+	}
+	return strings.TrimSpace(string(textutil.Normalize(data))), nil
+}
+
+// Dir returns the user config directory, ~/.config/flux
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "flux"), nil
+}
+
+// DataDir returns where flux keeps generated data such as logs:
+// $XDG_DATA_HOME/flux, falling back to ~/.local/share/flux
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "flux"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "flux"), nil
+}
+
 func Get() *Config {
 	return cfg
 }
+
+// Replace makes c the config Get returns, such as a copy with a setting
+// changed at runtime
+func Replace(c *Config) {
+	cfg = c
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// template is the commented config written by Init. It documents the same
+// options as config.example.yaml; a test keeps the two in step.
+//
+//go:embed template.yaml
+var template []byte
+
+// ErrConfigExists is returned by Init when a config file is already present
+var ErrConfigExists = errors.New("config file already exists")
+
+// Init writes a commented starter config.yaml to the config dir and returns
+// its path. An existing file is only replaced when force is set.
+func Init(force bool) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "config.yaml")
+
+	if _, err := os.Stat(path); err == nil && !force {
+		return path, fmt.Errorf("%w: %s (use --force to overwrite)", ErrConfigExists, path)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	// The file may come to hold API keys
+	if err := os.WriteFile(path, template, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,360 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/kbesada/flux-code-cli/internal/textutil"
+)
+
+// loaded is the viper instance behind the last Load, kept so settings
+// changed at runtime can be written back to the same file
+var loaded *viper.Viper
+
+// maxDescribeValue caps how much of a long value Describe shows
+const maxDescribeValue = 60
+
+// Describe lists the effective settings as sorted "key: value" lines using
+// dotted keys, followed by any warnings from loading as "# warning:" lines.
+// API keys and custom header values, which often carry tokens, are redacted,
+// proxy credentials dropped and long values shortened.
+func (c *Config) Describe() string {
+	var lines []string
+	walkSettings(reflect.ValueOf(c).Elem(), "", true, func(key string, field reflect.Value) {
+		lines = append(lines, key+": "+describeValue(key, field))
+	})
+	sort.Strings(lines)
+	for _, warning := range c.Warnings {
+		lines = append(lines, "# warning: "+warning)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describeValue(key string, field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.String:
+		s := field.String()
+		if s != "" && (strings.HasSuffix(key, ".api_key") || strings.HasSuffix(key, ".api_key_file") ||
+			strings.Contains(key, ".headers.")) {
+			return textutil.Redacted
+		}
+		if strings.HasSuffix(key, ".proxy_url") {
+			s = stripUserinfo(s)
+		}
+		return strconv.Quote(textutil.TruncateRunes(s, maxDescribeValue, textutil.Ellipsis))
+	case reflect.Slice:
+		items := make([]string, field.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(field.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(field.Interface())
+	}
+}
+
+// stripUserinfo drops the user and password from a URL such as a proxy's
+func stripUserinfo(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// A URL that doesn't parse may still hold credentials
+		if strings.Contains(raw, "@") {
+			return textutil.Redacted
+		}
+		return raw
+	}
+	if u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// Set parses value for the setting named by a dotted key such as
+// "ui.word_wrap" and stores it. Lists take comma-separated values. Settings
+// inside maps, such as providers, can't be set.
+func (c *Config) Set(key, value string) error {
+	var target reflect.Value
+	walkSettings(reflect.ValueOf(c).Elem(), "", false, func(k string, field reflect.Value) {
+		if k == key {
+			target = field
+		}
+	})
+	if !target.IsValid() {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		target.SetBool(b)
+	case reflect.Int64:
+		if target.Type() != reflect.TypeOf(time.Duration(0)) {
+			return fmt.Errorf("%s can't be set at runtime", key)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration such as 30s", key, value)
+		}
+		target.SetInt(int64(d))
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a whole number", key, value)
+		}
+		target.SetInt(int64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", key, value)
+		}
+		target.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		target.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s can't be set at runtime", key)
+	}
+	return nil
+}
+
+// Persist writes the current value of key to the config file that was
+// loaded. Usually only that setting's line changes; when the setting spans
+// several lines, or goes in a section the file already has, the file is
+// rewritten with its comments but not its blank lines or alignment, and
+// rewritten is true.
+func (c *Config) Persist(key string) (rewritten bool, err error) {
+	if loaded == nil || loaded.ConfigFileUsed() == "" {
+		return false, fmt.Errorf("no config file loaded to save to")
+	}
+	path := loaded.ConfigFileUsed()
+
+	var value any
+	walkSettings(reflect.ValueOf(c).Elem(), "", false, func(k string, field reflect.Value) {
+		if k == key {
+			value = field.Interface()
+		}
+	})
+	if value == nil {
+		return false, fmt.Errorf("unknown setting %q", key)
+	}
+	if d, ok := value.(time.Duration); ok {
+		value = d.String()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("save %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("save %s: %w", path, err)
+	}
+	data, rewritten, err = setYAMLValue(data, key, value)
+	if err != nil {
+		return false, fmt.Errorf("save %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("save %s: %w", path, err)
+	}
+	loaded.Set(key, value)
+	return rewritten, nil
+}
+
+// setYAMLValue sets the setting at a dotted key in a YAML document. A value
+// on one line is replaced in place and a missing section is appended, so
+// the rest of the text is kept as is. Otherwise the value is set on the
+// parsed document, which is written back out with its comments, and
+// rewritten is true.
+func setYAMLValue(data []byte, key string, value any) (out []byte, rewritten bool, err error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, false, fmt.Errorf("the config file is not a mapping")
+	}
+
+	var replacement yaml.Node
+	if err := replacement.Encode(value); err != nil {
+		return nil, false, err
+	}
+	if replacement.Kind == yaml.SequenceNode {
+		replacement.Style = yaml.FlowStyle
+	}
+
+	parts := strings.Split(key, ".")
+	node := root
+	for i, part := range parts {
+		var keyNode, valueNode *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part {
+				keyNode, valueNode = node.Content[j], node.Content[j+1]
+				break
+			}
+		}
+
+		if valueNode == nil {
+			// Add the rest of the key as nested mappings
+			next := &replacement
+			for k := len(parts) - 1; k > i; k-- {
+				next = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+					{Kind: yaml.ScalarNode, Value: parts[k]}, next,
+				}}
+			}
+			entry := []*yaml.Node{{Kind: yaml.ScalarNode, Value: part}, next}
+			if node == root {
+				text, err := encodeYAML(&yaml.Node{Kind: yaml.MappingNode, Content: entry})
+				if err != nil {
+					return nil, false, err
+				}
+				if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+					data = append(data, '\n')
+				}
+				return append(data, text...), false, nil
+			}
+			node.Content = append(node.Content, entry...)
+			out, err := encodeYAML(&doc)
+			return out, true, err
+		}
+
+		if i < len(parts)-1 {
+			if valueNode.Kind != yaml.MappingNode {
+				return nil, false, fmt.Errorf("%s is not a section", strings.Join(parts[:i+1], "."))
+			}
+			node = valueNode
+			continue
+		}
+
+		if out, ok := replaceLine(data, keyNode, valueNode, &replacement); ok {
+			return out, false, nil
+		}
+		replacement.HeadComment = valueNode.HeadComment
+		replacement.LineComment = valueNode.LineComment
+		*valueNode = replacement
+		out, err := encodeYAML(&doc)
+		return out, true, err
+	}
+	return nil, false, fmt.Errorf("empty key")
+}
+
+// replaceLine swaps the text of a value written on its key's line for the
+// replacement's, keeping the rest of the line. It reports false when the
+// value spans lines, such as a block list.
+func replaceLine(data []byte, keyNode, valueNode, replacement *yaml.Node) ([]byte, bool) {
+	if valueNode.Line != keyNode.Line || valueNode.Tag == "!!null" || valueNode.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 ||
+		(valueNode.Kind != yaml.ScalarNode && valueNode.Style&yaml.FlowStyle == 0) {
+		return nil, false
+	}
+	text, err := yaml.Marshal(replacement)
+	if err != nil || bytes.Count(text, []byte("\n")) != 1 {
+		return nil, false
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if valueNode.Line > len(lines) {
+		return nil, false
+	}
+	line := lines[valueNode.Line-1]
+	start := valueNode.Column - 1
+	end := len(strings.TrimRight(line, "\r\n"))
+	for _, comment := range []string{valueNode.LineComment, keyNode.LineComment} {
+		if comment != "" {
+			if i := strings.LastIndex(line, comment); i >= start {
+				end = i
+			}
+			break
+		}
+	}
+	if start > end {
+		return nil, false
+	}
+	value := strings.TrimSuffix(string(text), "\n")
+	padding := ""
+	if rest := strings.TrimRight(line[end:], "\r\n"); rest != "" {
+		// Keep a trailing comment in its column when the value fits
+		padding = strings.Repeat(" ", max(end-start-len(value), 1))
+	}
+
+	lines[valueNode.Line-1] = line[:start] + value + padding + line[end:]
+	return []byte(strings.Join(lines, "")), true
+}
+
+// encodeYAML writes a node with the two-space indent the config files use
+func encodeYAML(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// walkSettings calls fn for each leaf setting under v with its dotted key.
+// Map entries, such as each provider, are visited only when withMaps is set.
+func walkSettings(v reflect.Value, prefix string, withMaps bool, fn func(key string, field reflect.Value)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.Struct:
+			walkSettings(field, key+".", withMaps, fn)
+		case reflect.Map:
+			if !withMaps {
+				continue
+			}
+			names := make([]string, 0, field.Len())
+			for _, k := range field.MapKeys() {
+				names = append(names, k.String())
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				entry := field.MapIndex(reflect.ValueOf(name))
+				if entry.Kind() == reflect.Struct {
+					// Map values aren't addressable; walk a copy for reading
+					copied := reflect.New(entry.Type()).Elem()
+					copied.Set(entry)
+					walkSettings(copied, key+"."+name+".", withMaps, fn)
+				} else {
+					fn(key+"."+name, entry)
+				}
+			}
+		default:
+			fn(key, field)
+		}
+	}
+}
@@ -1,18 +1,65 @@
 package config
 
+import "time"
+
 type Config struct {
-	Provider  string              `mapstructure:"provider"`
-	Providers map[string]Provider `mapstructure:"providers"`
-	UI        UIConfig            `mapstructure:"ui"`
-	System    SystemConfig        `mapstructure:"system"`
+	Provider    string              `mapstructure:"provider"`
+	Providers   map[string]Provider `mapstructure:"providers"`
+	Fallback    []string            `mapstructure:"fallback"`
+	UI          UIConfig            `mapstructure:"ui"`
+	System      SystemConfig        `mapstructure:"system"`
+	Search      SearchConfig        `mapstructure:"search"`
+	Context     ContextConfig       `mapstructure:"context"`
+	Blame       BlameConfig         `mapstructure:"blame"`
+	Commands    CommandsConfig      `mapstructure:"commands"`
+	Keybindings KeybindingsConfig   `mapstructure:"keybindings"`
+	Git         GitConfig           `mapstructure:"git"`
+	Usage       UsageConfig         `mapstructure:"usage"`
+	HTTP        HTTPConfig          `mapstructure:"http"`
+	Web         WebConfig           `mapstructure:"web"`
+	Session     SessionConfig       `mapstructure:"session"`
+	Personas    map[string]string   `mapstructure:"personas"`
+
+	// Warnings collects non-fatal configuration problems found while loading
+	Warnings []string `mapstructure:"-"`
 }
 
 type Provider struct {
 	APIKey     string `mapstructure:"api_key"`
+	APIKeyFile string `mapstructure:"api_key_file"`
 	BaseURL    string `mapstructure:"base_url"`
 	Model      string `mapstructure:"model"`
 	AuthHeader string `mapstructure:"auth_header"`
 	AuthPrefix string `mapstructure:"auth_prefix"`
+	HealthPath string `mapstructure:"health_path"` // e.g. /healthz; defaults to /models
+	ProxyURL   string `mapstructure:"proxy_url"`   // overrides HTTPS_PROXY/HTTP_PROXY for this provider
+
+	// Extra headers sent with every request, e.g. OpenRouter's X-Title
+	Headers map[string]string `mapstructure:"headers"`
+
+	// Generation defaults sent with every request; zero leaves the
+	// provider's own default in place
+	Temperature float32  `mapstructure:"temperature"`
+	MaxTokens   int      `mapstructure:"max_tokens"`
+	Stop        []string `mapstructure:"stop"`
+
+	// Azure OpenAI
+	Deployment string `mapstructure:"deployment"`
+	APIVersion string `mapstructure:"api_version"`
+
+	// AWS Bedrock; defaults to AWS_REGION
+	Region string `mapstructure:"region"`
+
+	Quirks Quirks `mapstructure:"quirks"`
+}
+
+// Quirks toggles payload workarounds for OpenAI-compatible servers that
+// reject parts of the standard request
+type Quirks struct {
+	NoStreamOptions     bool `mapstructure:"no_stream_options"`     // never send stream_options
+	NoTemperature       bool `mapstructure:"no_temperature"`        // never send temperature
+	NoStop              bool `mapstructure:"no_stop"`               // never send stop sequences
+	MaxCompletionTokens bool `mapstructure:"max_completion_tokens"` // send max_tokens as max_completion_tokens
 }
 
 type UIConfig struct {
@@ -20,8 +67,101 @@ type UIConfig struct {
 	WordWrap           int    `mapstructure:"word_wrap"`
 	ShowTokens         bool   `mapstructure:"show_tokens"`
 	SyntaxHighlighting bool   `mapstructure:"syntax_highlighting"`
+	GroupContext       bool   `mapstructure:"group_context"`
+	ShowTimestamps     bool   `mapstructure:"show_timestamps"`
+	MessageSpacing     int    `mapstructure:"message_spacing"`
+	ThinkingText       string `mapstructure:"thinking_text"`
+	Spinner            string `mapstructure:"spinner"`
+	EmptyResponse      string `mapstructure:"empty_response"`
+	MinWidth           int    `mapstructure:"min_width"`
+	MinHeight          int    `mapstructure:"min_height"`
 }
 
+// Values for ui.empty_response: how to handle a completed reply with no content
+const (
+	EmptyResponseNote  = "note"
+	EmptyResponseRetry = "retry"
+)
+
 type SystemConfig struct {
-	Prompt string `mapstructure:"system_prompt"`
+	Prompt      string   `mapstructure:"system_prompt"`
+	PostProcess []string `mapstructure:"postprocess"`
+}
+
+// ContextConfig limits how much conversation is sent with each request
+type ContextConfig struct {
+	// MaxContextTokens is the estimated token budget for the request
+	// history; older messages are dropped past it. Zero disables trimming.
+	MaxContextTokens int `mapstructure:"max_tokens"`
+
+	// LineNumbers prefixes each line of /file output with its line number
+	LineNumbers bool `mapstructure:"line_numbers"`
+}
+
+type SearchConfig struct {
+	MaxMatches int `mapstructure:"max_matches"`
+}
+
+type BlameConfig struct {
+	MaxLines int `mapstructure:"max_lines"`
+}
+
+type CommandsConfig struct {
+	Disabled []string          `mapstructure:"disabled"`
+	Aliases  map[string]string `mapstructure:"aliases"`
+}
+
+// KeybindingsConfig lists the keys bound to each UI action, named the way
+// Bubble Tea prints them: "enter", "ctrl+s", "alt+enter", "pgup", "k"
+type KeybindingsConfig struct {
+	Send       []string `mapstructure:"send"`
+	Quit       []string `mapstructure:"quit"` // press twice; first cancels a streaming reply
+	Clear      []string `mapstructure:"clear"`
+	ScrollUp   []string `mapstructure:"scroll_up"`
+	ScrollDown []string `mapstructure:"scroll_down"`
+}
+
+// DefaultKeybindings returns the built-in keys. Clear is unbound, so the chat
+// can't be wiped by a stray keypress.
+func DefaultKeybindings() KeybindingsConfig {
+	return KeybindingsConfig{
+		Send:       []string{"enter", "ctrl+s", "alt+enter"},
+		Quit:       []string{"ctrl+c"},
+		ScrollUp:   []string{"pgup"},
+		ScrollDown: []string{"pgdown"},
+	}
+}
+
+// GitConfig supplies a commit identity when git config has none
+type GitConfig struct {
+	AuthorName  string `mapstructure:"author_name"`
+	AuthorEmail string `mapstructure:"author_email"`
+}
+
+// HTTPConfig tunes the connection pool shared by all provider clients
+type HTTPConfig struct {
+	MaxIdleConns        int           `mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
+	TLSHandshakeTimeout time.Duration `mapstructure:"tls_handshake_timeout"`
+}
+
+// WebConfig controls what /web may fetch
+type WebConfig struct {
+	// AllowPrivate lets /web fetch loopback, link-local and private network
+	// addresses, which are refused by default
+	AllowPrivate bool `mapstructure:"allow_private"`
+}
+
+// SessionConfig controls how the chat is saved on quit
+type SessionConfig struct {
+	// AITitles asks the model for the saved session's title instead of
+	// deriving it from the first message
+	AITitles bool `mapstructure:"ai_titles"`
+}
+
+// UsageConfig controls the per-turn token usage log
+type UsageConfig struct {
+	Log  bool   `mapstructure:"log"`
+	Path string `mapstructure:"path"` // defaults to usage.jsonl in the data dir
 }
@@ -0,0 +1,119 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	osexec "os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultAllowList is the set of programs a Runner may execute by default
+var DefaultAllowList = []string{"go", "git", "make", "npm", "yarn", "pnpm", "cargo", "pytest", "ls"}
+
+const (
+	defaultTimeout   = 60 * time.Second
+	defaultMaxOutput = 64 * 1024
+)
+
+// Runner executes allow-listed commands with a timeout and an output cap
+type Runner struct {
+	AllowList []string
+	Timeout   time.Duration
+	MaxOutput int
+	Dir       string
+}
+
+// Result holds the captured output of a command
+type Result struct {
+	Output    string
+	ExitCode  int
+	Truncated bool
+	TimedOut  bool
+}
+
+// NewRunner creates a runner with default limits working in dir
+func NewRunner(dir string) *Runner {
+	return &Runner{
+		AllowList: DefaultAllowList,
+		Timeout:   defaultTimeout,
+		MaxOutput: defaultMaxOutput,
+		Dir:       dir,
+	}
+}
+
+// Allowed reports whether the program may be executed
+func (r *Runner) Allowed(name string) bool {
+	return filepath.Base(name) == name && slices.Contains(r.AllowList, name)
+}
+
+// Run executes name with args, capturing stdout and stderr together.
+// A non-zero exit status is reported on the Result rather than as an error.
+func (r *Runner) Run(ctx context.Context, name string, args ...string) (*Result, error) {
+	if !r.Allowed(name) {
+		return nil, fmt.Errorf("command %q is not allowed", name)
+	}
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	limit := r.MaxOutput
+	if limit <= 0 {
+		limit = defaultMaxOutput
+	}
+	out := &cappedBuffer{limit: limit}
+
+	c := osexec.CommandContext(ctx, name, args...)
+	c.Dir = r.Dir
+	c.Stdout = out
+	c.Stderr = out
+
+	err := c.Run()
+
+	result := &Result{
+		Output:    out.buf.String(),
+		Truncated: out.truncated,
+		TimedOut:  errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+
+	var exitErr *osexec.ExitError
+	switch {
+	case err == nil:
+	case result.TimedOut:
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// cappedBuffer keeps the first limit bytes written and discards the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if remaining <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
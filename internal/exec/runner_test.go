@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerAllowed(t *testing.T) {
+	r := &Runner{AllowList: []string{"go", "echo"}}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"go", true},
+		{"echo", true},
+		{"rm", false},
+		{"/usr/bin/go", false},
+		{"./go", false},
+		{"../echo", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := r.Allowed(tt.name); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunRejectsDisallowed(t *testing.T) {
+	r := &Runner{AllowList: []string{"echo"}}
+
+	if _, err := r.Run(context.Background(), "sh", "-c", "echo hi"); err == nil {
+		t.Error("expected an error for a program not on the allow list")
+	}
+	if _, err := r.Run(context.Background(), "/bin/echo", "hi"); err == nil {
+		t.Error("expected an error for a path, even to an allowed program")
+	}
+}
+
+func TestRunReportsExitCode(t *testing.T) {
+	r := &Runner{AllowList: []string{"sh"}, Timeout: 5 * time.Second}
+
+	result, err := r.Run(context.Background(), "sh", "-c", "echo out; echo err >&2; exit 3")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", result.ExitCode)
+	}
+	if !strings.Contains(result.Output, "out") || !strings.Contains(result.Output, "err") {
+		t.Errorf("output should capture stdout and stderr, got %q", result.Output)
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	r := &Runner{AllowList: []string{"sleep"}, Timeout: 100 * time.Millisecond}
+
+	start := time.Now()
+	result, err := r.Run(context.Background(), "sleep", "5")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("expected a timed out result, got %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("the command should be killed at the timeout, took %s", elapsed)
+	}
+}
+
+func TestRunCapsOutput(t *testing.T) {
+	r := &Runner{AllowList: []string{"sh"}, Timeout: 5 * time.Second, MaxOutput: 10}
+
+	result, err := r.Run(context.Background(), "sh", "-c", "echo 0123456789abcdef; echo more")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Output != "0123456789" {
+		t.Errorf("Output = %q, want the first 10 bytes", result.Output)
+	}
+	if !result.Truncated {
+		t.Error("capped output should be marked truncated")
+	}
+
+	result, err = r.Run(context.Background(), "sh", "-c", "printf 0123456789")
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Truncated {
+		t.Error("output exactly at the cap should not be marked truncated")
+	}
+}
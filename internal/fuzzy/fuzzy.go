@@ -0,0 +1,81 @@
+// Package fuzzy resolves loosely typed names, such as providers and models,
+// against a list of candidates.
+package fuzzy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxListed caps how many candidates an error message names
+const maxListed = 10
+
+// ErrNoMatch is returned, wrapped, when no candidate matches the query
+var ErrNoMatch = errors.New("no match")
+
+// Resolve returns the candidate query refers to, ignoring case. An exact
+// match wins; otherwise a unique substring match, then a unique subsequence
+// match (e.g. "g4o" for "gpt-4o"). Ambiguous or unmatched queries return an
+// error listing the candidates to choose from; an unmatched query's error
+// wraps ErrNoMatch.
+func Resolve(query string, candidates []string) (string, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return "", fmt.Errorf("empty name")
+	}
+
+	for _, c := range candidates {
+		if strings.ToLower(c) == q {
+			return c, nil
+		}
+	}
+
+	for _, match := range []func(string) bool{
+		func(c string) bool { return strings.Contains(c, q) },
+		func(c string) bool { return isSubsequence(q, c) },
+	} {
+		var matches []string
+		for _, c := range candidates {
+			if match(strings.ToLower(c)) {
+				matches = append(matches, c)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			return "", fmt.Errorf("%q is ambiguous: %s", query, list(matches))
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w for %q", ErrNoMatch, query)
+	}
+	return "", fmt.Errorf("%w for %q; available: %s", ErrNoMatch, query, list(candidates))
+}
+
+// isSubsequence reports whether the runes of q appear in s in order
+func isSubsequence(q, s string) bool {
+	rs := []rune(s)
+	i := 0
+	for _, r := range q {
+		for i < len(rs) && rs[i] != r {
+			i++
+		}
+		if i == len(rs) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func list(names []string) string {
+	if len(names) > maxListed {
+		return strings.Join(names[:maxListed], ", ") + fmt.Sprintf(", … (%d more)", len(names)-maxListed)
+	}
+	return strings.Join(names, ", ")
+}
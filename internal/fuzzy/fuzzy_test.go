@@ -0,0 +1,62 @@
+package fuzzy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var models = []string{"gpt-4o", "gpt-4o-mini", "o3-mini", "claude-sonnet"}
+
+func TestResolveUnique(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"GPT-4O", "gpt-4o"}, // exact match beats the longer substring match
+		{"sonnet", "claude-sonnet"},
+		{"o3", "o3-mini"},
+		{"cls", "claude-sonnet"}, // subsequence
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.query, models)
+		if err != nil {
+			t.Errorf("Resolve(%q) error: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	_, err := Resolve("mini", models)
+	if err == nil {
+		t.Fatal("expected an ambiguity error")
+	}
+	for _, name := range []string{"gpt-4o-mini", "o3-mini"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error should list %q: %v", name, err)
+		}
+	}
+	if strings.Contains(err.Error(), "claude") {
+		t.Errorf("error should only list matching candidates: %v", err)
+	}
+}
+
+func TestResolveNoMatch(t *testing.T) {
+	_, err := Resolve("llama", models)
+	if err == nil {
+		t.Fatal("expected a no-match error")
+	}
+	if !strings.Contains(err.Error(), "no match") || !strings.Contains(err.Error(), "gpt-4o") {
+		t.Errorf("error should say nothing matched and list the candidates: %v", err)
+	}
+	if !errors.Is(err, ErrNoMatch) {
+		t.Errorf("error should wrap ErrNoMatch: %v", err)
+	}
+	if _, err := Resolve("gpt", models); errors.Is(err, ErrNoMatch) {
+		t.Errorf("an ambiguous name should not be reported as unmatched: %v", err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/kbesada/flux-code-cli/internal/textutil"
 )
 
 // BlameResult contains blame information for a file
@@ -18,12 +21,16 @@ type BlameLine struct {
 	Hash       string
 	Author     string
 	Date       string
+	Summary    string // first line of the commit message
 	Content    string
 }
 
+// summaryWidth is the width of the commit summary column in Format
+const summaryWidth = 24
+
 // Blame returns blame information for a file
 func (r *Repo) Blame(file string) (*BlameResult, error) {
-	head, err := r.repo.Head()
+	head, err := r.Head()
 	if err != nil {
 		return nil, err
 	}
@@ -42,12 +49,23 @@ func (r *Repo) Blame(file string) (*BlameResult, error) {
 		Lines: make([]BlameLine, len(blame.Lines)),
 	}
 
+	// Most files come from a handful of commits; look each one up once
+	summaries := make(map[plumbing.Hash]string)
 	for i, line := range blame.Lines {
+		summary, ok := summaries[line.Hash]
+		if !ok {
+			if c, err := r.repo.CommitObject(line.Hash); err == nil {
+				summary, _, _ = strings.Cut(strings.TrimSpace(c.Message), "\n")
+			}
+			summaries[line.Hash] = summary
+		}
+
 		result.Lines[i] = BlameLine{
 			LineNumber: i + 1,
 			Hash:       line.Hash.String()[:7],
 			Author:     line.Author,
 			Date:       line.Date.Format("2006-01-02"),
+			Summary:    summary,
 			Content:    line.Text,
 		}
 	}
@@ -68,6 +86,9 @@ func (r *Repo) BlameRange(file string, startLine, endLine int) (*BlameResult, er
 	if endLine > len(full.Lines) {
 		endLine = len(full.Lines)
 	}
+	if startLine > endLine {
+		return nil, fmt.Errorf("invalid line range %d-%d for %s (%d lines)", startLine, endLine, file, len(full.Lines))
+	}
 
 	return &BlameResult{
 		Lines: full.Lines[startLine-1 : endLine],
@@ -80,21 +101,15 @@ func (b *BlameResult) Format() string {
 
 	for _, line := range b.Lines {
 		builder.WriteString(fmt.Sprintf(
-			"%4d │ %s │ %-12s │ %s │ %s\n",
+			"%4d │ %s │ %s │ %s │ %s │ %s\n",
 			line.LineNumber,
 			line.Hash,
-			truncate(line.Author, 12),
+			textutil.PadRunes(line.Author, 12, textutil.Ellipsis),
 			line.Date,
+			textutil.PadRunes(line.Summary, summaryWidth, textutil.Ellipsis),
 			line.Content,
 		))
 	}
 
 	return builder.String()
 }
-
-func truncate(s string, max int) string {
-	if len(s) <= max {
-		return s + strings.Repeat(" ", max-len(s))
-	}
-	return s[:max-1] + "…"
-}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRepo_BlameSummary(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte("hello\nworld\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := repo.worktree.Add("test.txt"); err != nil {
+		t.Fatalf("failed to add file: %v", err)
+	}
+	_, err = repo.worktree.Commit("Add a second line\n\nThe body should not be shown.", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	result, err := repo.Blame("test.txt")
+	if err != nil {
+		t.Fatalf("Blame() error: %v", err)
+	}
+	if len(result.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(result.Lines))
+	}
+	if got := result.Lines[1].Summary; got != "Add a second line" {
+		t.Errorf("line 2 summary = %q, want the commit's first line", got)
+	}
+
+	lines := strings.Split(result.Format(), "\n")
+	if !strings.Contains(lines[1], "Add a second line") || !strings.Contains(lines[1], "world") {
+		t.Errorf("expected the summary beside line 2, got %q", lines[1])
+	}
+	if strings.Contains(result.Format(), "body should not") {
+		t.Error("only the first line of the message should be shown")
+	}
+}
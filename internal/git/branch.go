@@ -0,0 +1,61 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ErrUncommittedChanges is returned by Checkout when the worktree has
+// changes that switching branches would lose
+var ErrUncommittedChanges = errors.New("uncommitted changes would be lost")
+
+// Checkout switches to branch name, first creating it at HEAD when create
+// is set. It refuses while the worktree is dirty, untracked files included,
+// since go-git's checkout removes files the target branch doesn't track.
+func (r *Repo) Checkout(name string, create bool) error {
+	return r.checkout(name, create, false)
+}
+
+// ForceCheckout is like Checkout but discards uncommitted changes.
+func (r *Repo) ForceCheckout(name string, create bool) error {
+	return r.checkout(name, create, true)
+}
+
+func (r *Repo) checkout(name string, create, force bool) error {
+	branch := plumbing.NewBranchReferenceName(name)
+	if err := branch.Validate(); err != nil || name == "" {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+
+	_, err := r.repo.Reference(branch, false)
+	switch {
+	case create && err == nil:
+		return fmt.Errorf("a branch named %q already exists", name)
+	case !create && errors.Is(err, plumbing.ErrReferenceNotFound):
+		return fmt.Errorf("no branch named %q", name)
+	case err != nil && !errors.Is(err, plumbing.ErrReferenceNotFound):
+		return err
+	}
+	if _, err := r.Head(); err != nil {
+		return err
+	}
+
+	if !force {
+		dirty, err := r.IsDirty()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrUncommittedChanges
+		}
+	}
+
+	return r.worktree.Checkout(&gogit.CheckoutOptions{
+		Branch: branch,
+		Create: create,
+		Force:  force,
+	})
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepo_Checkout(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	start, _ := repo.CurrentBranch()
+
+	if err := repo.Checkout("feature", true); err != nil {
+		t.Fatalf("Checkout(create) error: %v", err)
+	}
+	if branch, _ := repo.CurrentBranch(); branch != "feature" {
+		t.Errorf("expected to be on feature, got %s", branch)
+	}
+
+	if err := repo.Checkout(start, false); err != nil {
+		t.Fatalf("Checkout(%s) error: %v", start, err)
+	}
+	if branch, _ := repo.CurrentBranch(); branch != start {
+		t.Errorf("expected to be back on %s, got %s", start, branch)
+	}
+
+	if err := repo.Checkout("feature", true); err == nil {
+		t.Error("creating an existing branch should fail")
+	}
+	if err := repo.Checkout("missing", false); err == nil {
+		t.Error("switching to a missing branch should fail")
+	}
+}
+
+func TestRepo_CheckoutDirty(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	start, _ := repo.CurrentBranch()
+
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644)
+	if err := repo.Checkout("feature", true); !errors.Is(err, ErrUncommittedChanges) {
+		t.Fatalf("untracked files should block a checkout, got %v", err)
+	}
+	os.Remove(filepath.Join(dir, "notes.txt"))
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("changed"), 0644)
+	if err := repo.Checkout("feature", true); !errors.Is(err, ErrUncommittedChanges) {
+		t.Fatalf("expected ErrUncommittedChanges, got %v", err)
+	}
+	if branch, _ := repo.CurrentBranch(); branch != start {
+		t.Errorf("a refused checkout should stay on %s, got %s", start, branch)
+	}
+
+	if err := repo.ForceCheckout("feature", true); err != nil {
+		t.Fatalf("ForceCheckout() error: %v", err)
+	}
+	if branch, _ := repo.CurrentBranch(); branch != "feature" {
+		t.Errorf("expected to be on feature, got %s", branch)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "test.txt"))
+	if string(data) != "hello" {
+		t.Errorf("forced checkout should discard changes, got %q", data)
+	}
+}
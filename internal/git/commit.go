@@ -0,0 +1,65 @@
+package git
+
+import (
+	"errors"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrNothingStaged is returned when committing with an empty index diff
+var ErrNothingStaged = errors.New("nothing staged to commit")
+
+// Signature identifies the author of a commit
+type Signature struct {
+	Name  string
+	Email string
+}
+
+// IsZero reports whether the signature has no name or email
+func (s Signature) IsZero() bool {
+	return s.Name == "" || s.Email == ""
+}
+
+// GitSignature returns user.name and user.email from the repository's
+// local and global git config. Missing values are left empty.
+func (r *Repo) GitSignature() Signature {
+	cfg, err := r.repo.ConfigScoped(gitconfig.GlobalScope)
+	if err != nil {
+		return Signature{}
+	}
+	return Signature{Name: cfg.User.Name, Email: cfg.User.Email}
+}
+
+// Commit records the staged changes and returns the new commit's short hash
+func (r *Repo) Commit(message string, author Signature) (string, error) {
+	if message == "" {
+		return "", errors.New("commit message is required")
+	}
+	if author.IsZero() {
+		return "", errors.New("commit author name and email are required")
+	}
+
+	status, err := r.GetStatus()
+	if err != nil {
+		return "", err
+	}
+	if len(status.Staged) == 0 {
+		return "", ErrNothingStaged
+	}
+
+	hash, err := r.worktree.Commit(message, &gogit.CommitOptions{
+		Author: &object.Signature{
+			Name:  author.Name,
+			Email: author.Email,
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hash.String()[:7], nil
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepo_Commit(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := repo.worktree.Add("test.txt"); err != nil {
+		t.Fatalf("failed to stage file: %v", err)
+	}
+
+	hash, err := repo.Commit("Update test file", Signature{Name: "Test", Email: "test@test.com"})
+	if err != nil {
+		t.Fatalf("Commit() error: %v", err)
+	}
+
+	commits, err := repo.GetLog(5)
+	if err != nil {
+		t.Fatalf("GetLog() error: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].Hash != hash || commits[0].Message != "Update test file" {
+		t.Errorf("expected new commit %s at HEAD, got %+v", hash, commits[0])
+	}
+}
+
+func TestRepo_CommitNothingStaged(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	// Unstaged edits alone are not committed
+	if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	_, err = repo.Commit("Nothing here", Signature{Name: "Test", Email: "test@test.com"})
+	if !errors.Is(err, ErrNothingStaged) {
+		t.Errorf("expected ErrNothingStaged, got %v", err)
+	}
+}
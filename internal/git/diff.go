@@ -1,10 +1,21 @@
 package git
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // DiffOptions configures diff generation
@@ -14,12 +25,9 @@ type DiffOptions struct {
 	Context int    // Lines of context (default 3)
 }
 
-// GetDiff returns the diff as a string
+// GetDiff returns a unified diff of unstaged changes, or of staged changes
+// against HEAD when opts.Staged is set. Untracked files appear as additions.
 func (r *Repo) GetDiff(opts DiffOptions) (string, error) {
-	// For now, we'll use a simplified approach since generating full unified diffs
-	// manually with go-git is complex. We'll list changed files and their status.
-	// In a full implementation, we would iterate through patches.
-
 	status, err := r.worktree.Status()
 	if err != nil {
 		return "", err
@@ -29,63 +37,342 @@ func (r *Repo) GetDiff(opts DiffOptions) (string, error) {
 		return "No changes detected.", nil
 	}
 
-	var builder strings.Builder
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return "", err
+	}
 
-	// Build diff output
-	for file, fileStatus := range status {
+	headTree, err := r.headTree()
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]string, 0, len(status))
+	for file := range status {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	p := &patch{}
+	for _, file := range files {
 		if opts.File != "" && file != opts.File {
 			continue
 		}
 
-		// Filter based on staged/unstaged
-		// Status codes: ' ' (Unmodified), 'M' (Modified), 'A' (Added), 'D' (Deleted), etc.
-		// Staging is the first char, Worktree is the second.
+		fileStatus := status[file]
+		var from, to *fileVersion
 
 		if opts.Staged {
-			if fileStatus.Staging == gogit.Unmodified && fileStatus.Staging != gogit.Untracked {
+			if fileStatus.Staging == gogit.Unmodified || fileStatus.Staging == gogit.Untracked {
 				continue
 			}
+			if from, err = r.treeVersion(headTree, file); err != nil {
+				return "", err
+			}
+			if to, err = r.indexVersion(idx, file); err != nil {
+				return "", err
+			}
 		} else {
 			if fileStatus.Worktree == gogit.Unmodified {
 				continue
 			}
+			if from, err = r.indexVersion(idx, file); err != nil {
+				return "", err
+			}
+			if to, err = r.worktreeVersion(file); err != nil {
+				return "", err
+			}
 		}
 
-		statusChar := getStatusChar(fileStatus, opts.Staged)
-		builder.WriteString(fmt.Sprintf("%s %s\n", statusChar, file))
+		if from == nil && to == nil {
+			continue
+		}
+		p.files = append(p.files, newFilePatch(from, to))
+	}
+
+	if len(p.files) == 0 {
+		return "No changes detected.", nil
+	}
+
+	var builder strings.Builder
+	if err := encodePatch(&builder, p, opts.Context); err != nil {
+		return "", err
 	}
 
 	return builder.String(), nil
 }
 
-// GetDiffStats returns summary statistics
+// FileChange is the diff of one file between HEAD and the working tree
+type FileChange struct {
+	Path   string
+	Status string // "added", "modified" or "deleted"
+	Binary bool   // Diff is empty for binary files
+	Diff   string
+}
+
+// Changes returns a diff per file of everything that differs from HEAD in
+// the working tree, staged or not, sorted by path
+func (r *Repo) Changes() ([]FileChange, error) {
+	status, err := r.worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	headTree, err := r.headTree()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(status))
+	for file, s := range status {
+		if s.Staging == gogit.Unmodified && s.Worktree == gogit.Unmodified {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var changes []FileChange
+	for _, file := range files {
+		from, err := r.treeVersion(headTree, file)
+		if err != nil {
+			return nil, err
+		}
+		to, err := r.worktreeVersion(file)
+		if err != nil {
+			return nil, err
+		}
+
+		change := FileChange{Path: file, Status: "modified"}
+		switch {
+		case from == nil && to == nil:
+			continue
+		case from == nil:
+			change.Status = "added"
+		case to == nil:
+			change.Status = "deleted"
+		case bytes.Equal(from.content, to.content):
+			continue
+		}
+
+		fp := newFilePatch(from, to)
+		if fp.IsBinary() {
+			change.Binary = true
+		} else {
+			var builder strings.Builder
+			if err := encodePatch(&builder, &patch{files: []fdiff.FilePatch{fp}}, 0); err != nil {
+				return nil, err
+			}
+			change.Diff = builder.String()
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// headTree returns the tree at HEAD, or nil if there are no commits yet
+func (r *Repo) headTree() (*object.Tree, error) {
+	head, err := r.Head()
+	if err != nil {
+		if errors.Is(err, ErrNoCommits) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	return commit.Tree()
+}
+
+// treeVersion loads a file from a tree, returning nil if it is absent
+func (r *Repo) treeVersion(tree *object.Tree, path string) (*fileVersion, error) {
+	if tree == nil {
+		return nil, nil
+	}
+
+	f, err := tree.File(path)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	content, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileVersion{path: path, hash: f.Hash, mode: f.Mode, content: []byte(content)}, nil
+}
+
+// indexVersion loads a file from the index, returning nil if it is absent
+func (r *Repo) indexVersion(idx *index.Index, path string) (*fileVersion, error) {
+	entry, err := idx.Entry(path)
+	if err != nil {
+		if errors.Is(err, index.ErrEntryNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	blob, err := r.repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileVersion{path: path, hash: entry.Hash, mode: entry.Mode, content: content}, nil
+}
+
+// worktreeVersion loads a file from disk, returning nil if it is absent
+func (r *Repo) worktreeVersion(path string) (*fileVersion, error) {
+	fullPath := filepath.Join(r.path, filepath.FromSlash(path))
+
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		mode = filemode.Regular
+	}
+
+	return &fileVersion{
+		path:    path,
+		hash:    plumbing.ComputeHash(plumbing.BlobObject, content),
+		mode:    mode,
+		content: content,
+	}, nil
+}
+
+// GetDiffStats returns summary statistics. go-git's status never reports
+// renames or copies, so, like git diff -M -C, an added file whose content
+// exactly matches a deleted or modified file's previous content is counted
+// as renamed from a deleted file (once) or otherwise copied.
 func (r *Repo) GetDiffStats(staged bool) (*DiffStats, error) {
 	status, err := r.worktree.Status()
 	if err != nil {
 		return nil, err
 	}
 
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	headTree, err := r.headTree()
+	if err != nil {
+		return nil, err
+	}
+
 	stats := &DiffStats{}
+	var added, deleted, modified []string
 
-	for _, s := range status {
+	for file, s := range status {
+		code := s.Worktree
 		if staged {
-			switch s.Staging {
-			case gogit.Added:
-				stats.Added++
-			case gogit.Modified:
-				stats.Modified++
-			case gogit.Deleted:
-				stats.Deleted++
-			}
-		} else {
-			switch s.Worktree {
-			case gogit.Added, gogit.Untracked:
-				stats.Added++
-			case gogit.Modified:
-				stats.Modified++
-			case gogit.Deleted:
-				stats.Deleted++
-			}
+			code = s.Staging
+		} else if code == gogit.Untracked {
+			code = gogit.Added
+		}
+
+		switch code {
+		case gogit.Added:
+			added = append(added, file)
+		case gogit.Modified:
+			modified = append(modified, file)
+		case gogit.Deleted:
+			deleted = append(deleted, file)
+		case gogit.Renamed:
+			stats.Renamed++
+		case gogit.Copied:
+			stats.Copied++
+		}
+	}
+	stats.Added = len(added)
+	stats.Modified = len(modified)
+	stats.Deleted = len(deleted)
+
+	if len(added) == 0 || len(deleted)+len(modified) == 0 {
+		return stats, nil
+	}
+
+	// Before and after versions depend on which side of the index is shown
+	before := func(file string) (*fileVersion, error) {
+		if staged {
+			return r.treeVersion(headTree, file)
+		}
+		return r.indexVersion(idx, file)
+	}
+	after := func(file string) (*fileVersion, error) {
+		if staged {
+			return r.indexVersion(idx, file)
+		}
+		return r.worktreeVersion(file)
+	}
+
+	renameSources := make(map[plumbing.Hash]int)
+	copySources := make(map[plumbing.Hash]bool)
+	for _, file := range deleted {
+		v, err := before(file)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			renameSources[v.hash]++
+			copySources[v.hash] = true
+		}
+	}
+	for _, file := range modified {
+		v, err := before(file)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			copySources[v.hash] = true
+		}
+	}
+
+	for _, file := range added {
+		v, err := after(file)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case v == nil:
+		case renameSources[v.hash] > 0:
+			renameSources[v.hash]--
+			stats.Renamed++
+			stats.Added--
+			stats.Deleted--
+		case copySources[v.hash]:
+			stats.Copied++
+			stats.Added--
 		}
 	}
 
@@ -96,34 +383,19 @@ type DiffStats struct {
 	Added    int
 	Modified int
 	Deleted  int
+	Renamed  int
+	Copied   int
 }
 
+// String formats the counts as "+added ~modified -deleted", followed by
+// "R<n>" and "C<n>" for renames and copies when there are any.
 func (d DiffStats) String() string {
-	return fmt.Sprintf("+%d ~%d -%d", d.Added, d.Modified, d.Deleted)
-}
-
-func getStatusChar(s *gogit.FileStatus, staged bool) string {
-	var code gogit.StatusCode
-	if staged {
-		code = s.Staging
-	} else {
-		code = s.Worktree
-	}
-
-	switch code {
-	case gogit.Added:
-		return "A"
-	case gogit.Modified:
-		return "M"
-	case gogit.Deleted:
-		return "D"
-	case gogit.Renamed:
-		return "R"
-	case gogit.Copied:
-		return "C"
-	case gogit.Untracked:
-		return "?"
-	default:
-		return " "
+	s := fmt.Sprintf("+%d ~%d -%d", d.Added, d.Modified, d.Deleted)
+	if d.Renamed > 0 {
+		s += fmt.Sprintf(" R%d", d.Renamed)
+	}
+	if d.Copied > 0 {
+		s += fmt.Sprintf(" C%d", d.Copied)
 	}
+	return s
 }
@@ -0,0 +1,268 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepo_GetDiffClean(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	diff, err := repo.GetDiff(DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetDiff() error: %v", err)
+	}
+	if diff != "No changes detected." {
+		t.Errorf("expected no-changes sentinel, got %q", diff)
+	}
+}
+
+func TestRepo_GetDiffUnstaged(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("hello world\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("brand new\n"), 0644)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	diff, err := repo.GetDiff(DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetDiff() error: %v", err)
+	}
+
+	for _, want := range []string{
+		"diff --git a/test.txt b/test.txt",
+		"@@ ",
+		"-hello",
+		"+hello world",
+		"+brand new",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+
+	scoped, err := repo.GetDiff(DiffOptions{File: "new.txt"})
+	if err != nil {
+		t.Fatalf("GetDiff() error: %v", err)
+	}
+	if strings.Contains(scoped, "test.txt") {
+		t.Errorf("file-scoped diff should not include test.txt:\n%s", scoped)
+	}
+}
+
+func TestRepo_GetDiffStaged(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("staged change\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "other.txt"), []byte("unstaged\n"), 0644)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	if _, err := repo.worktree.Add("test.txt"); err != nil {
+		t.Fatalf("failed to stage file: %v", err)
+	}
+
+	diff, err := repo.GetDiff(DiffOptions{Staged: true})
+	if err != nil {
+		t.Fatalf("GetDiff() error: %v", err)
+	}
+	if !strings.Contains(diff, "-hello") || !strings.Contains(diff, "+staged change") {
+		t.Errorf("staged diff missing expected lines:\n%s", diff)
+	}
+	if strings.Contains(diff, "other.txt") {
+		t.Errorf("staged diff should not include unstaged files:\n%s", diff)
+	}
+}
+
+func TestRepo_GetDiffContextLines(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	original := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	os.WriteFile(filepath.Join(dir, "lines.txt"), []byte(original), 0644)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	repo.worktree.Add("lines.txt")
+
+	os.WriteFile(filepath.Join(dir, "lines.txt"), []byte(strings.Replace(original, "5\n", "five\n", 1)), 0644)
+
+	diff, err := repo.GetDiff(DiffOptions{File: "lines.txt", Context: 1})
+	if err != nil {
+		t.Fatalf("GetDiff() error: %v", err)
+	}
+	if !strings.Contains(diff, " 4\n-5\n+five\n 6\n") {
+		t.Errorf("expected one line of context around the change:\n%s", diff)
+	}
+	if strings.Contains(diff, "\n 3\n") {
+		t.Errorf("diff should not include more than one context line:\n%s", diff)
+	}
+}
+
+func TestRepo_DiffRefs(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	author := Signature{Name: "Test", Email: "test@test.com"}
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("hello\nsecond line\n"), 0644)
+	repo.Stage("test.txt")
+	first, err := repo.Commit("First change", author)
+	if err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "added.txt"), []byte("brand new\n"), 0644)
+	repo.Stage("added.txt")
+	second, err := repo.Commit("Second change", author)
+	if err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	diff, err := repo.DiffRefs(first, second, DiffOptions{})
+	if err != nil {
+		t.Fatalf("DiffRefs() error: %v", err)
+	}
+	if !strings.Contains(diff, "+++ b/added.txt") || !strings.Contains(diff, "+brand new") {
+		t.Errorf("expected added file in diff, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "test.txt") {
+		t.Errorf("unchanged file should not appear, got:\n%s", diff)
+	}
+
+	// Branch names and relative revisions resolve too
+	diff, err = repo.DiffRefs("HEAD~2", "master", DiffOptions{File: "test.txt"})
+	if err != nil {
+		t.Fatalf("DiffRefs() error: %v", err)
+	}
+	if !strings.Contains(diff, "+second line") || strings.Contains(diff, "added.txt") {
+		t.Errorf("expected only test.txt changes, got:\n%s", diff)
+	}
+}
+
+func TestRepo_DiffRefsUnknownRef(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	_, err = repo.DiffRefs("HEAD", "no-such-branch", DiffOptions{})
+	if err == nil || !strings.Contains(err.Error(), `unknown revision "no-such-branch"`) {
+		t.Errorf("expected unknown revision error, got %v", err)
+	}
+}
+
+func TestRepo_Changes(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	// One staged edit, one unstaged new file, one binary file
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("hello\nworld\n"), 0644)
+	if err := repo.Stage("test.txt"); err != nil {
+		t.Fatalf("failed to stage: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte{0x89, 'P', 'N', 'G', 0, 1}, 0644)
+
+	changes, err := repo.Changes()
+	if err != nil {
+		t.Fatalf("Changes() error: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changed files, got %+v", changes)
+	}
+
+	byPath := map[string]FileChange{}
+	for _, c := range changes {
+		byPath[c.Path] = c
+	}
+	if c := byPath["test.txt"]; c.Status != "modified" || !strings.Contains(c.Diff, "+world") {
+		t.Errorf("staged edit missing from changes: %+v", c)
+	}
+	if c := byPath["new.go"]; c.Status != "added" || !strings.Contains(c.Diff, "+package main") {
+		t.Errorf("untracked file missing from changes: %+v", c)
+	}
+	if c := byPath["logo.png"]; !c.Binary || c.Diff != "" {
+		t.Errorf("binary file should be flagged without a diff: %+v", c)
+	}
+}
+
+func TestRepo_GetDiffStatsRename(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	if err := os.Rename(filepath.Join(dir, "test.txt"), filepath.Join(dir, "renamed.txt")); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+
+	stats, err := repo.GetDiffStats(false)
+	if err != nil {
+		t.Fatalf("GetDiffStats() error: %v", err)
+	}
+	if *stats != (DiffStats{Renamed: 1}) {
+		t.Errorf("expected one unstaged rename, got %+v", *stats)
+	}
+
+	if err := repo.Stage("."); err != nil {
+		t.Fatalf("Stage() error: %v", err)
+	}
+	stats, err = repo.GetDiffStats(true)
+	if err != nil {
+		t.Fatalf("GetDiffStats() error: %v", err)
+	}
+	if *stats != (DiffStats{Renamed: 1}) {
+		t.Errorf("expected one staged rename, got %+v", *stats)
+	}
+	if got := stats.String(); got != "+0 ~0 -0 R1" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestRepo_GetDiffStatsCopy(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	// A copy of the original content alongside an edit of the source
+	os.WriteFile(filepath.Join(dir, "copy.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("hello, world"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("unrelated"), 0644)
+
+	stats, err := repo.GetDiffStats(false)
+	if err != nil {
+		t.Fatalf("GetDiffStats() error: %v", err)
+	}
+	if *stats != (DiffStats{Added: 1, Modified: 1, Copied: 1}) {
+		t.Errorf("expected a copy, an addition and a modification, got %+v", *stats)
+	}
+}
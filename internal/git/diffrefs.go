@@ -0,0 +1,69 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DiffRefs returns a unified diff between the trees of two revisions. Each
+// ref may be a branch, tag, hash, or any revision go-git can resolve (such
+// as HEAD~1). opts.Staged is ignored.
+func (r *Repo) DiffRefs(from, to string, opts DiffOptions) (string, error) {
+	fromTree, err := r.refTree(from)
+	if err != nil {
+		return "", err
+	}
+	toTree, err := r.refTree(to)
+	if err != nil {
+		return "", err
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.File != "" {
+		var filtered object.Changes
+		for _, c := range changes {
+			if c.From.Name == opts.File || c.To.Name == opts.File {
+				filtered = append(filtered, c)
+			}
+		}
+		changes = filtered
+	}
+
+	if len(changes) == 0 {
+		return "No changes detected.", nil
+	}
+
+	p, err := changes.Patch()
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	if err := encodePatch(&builder, p, opts.Context); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// refTree resolves a revision to its commit tree
+func (r *Repo) refTree(ref string) (*object.Tree, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q: %w", ref, err)
+	}
+
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("revision %q is not a commit: %w", ref, err)
+	}
+
+	return commit.Tree()
+}
@@ -0,0 +1,164 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GrepOptions configures a search across tracked files
+type GrepOptions struct {
+	Pattern    string // Regular expression to search for
+	IgnoreCase bool   // Case-insensitive matching
+	Context    int    // Lines of context around each match
+	MaxPerFile int    // Max matches reported per file (0 = unlimited)
+}
+
+// GrepMatch is a single matching line with its surrounding context
+type GrepMatch struct {
+	LineNumber int
+	Line       string
+	Before     []string // Context lines preceding the match
+	After      []string // Context lines following the match
+}
+
+// GrepFileResult groups the matches found in one file
+type GrepFileResult struct {
+	File      string
+	Matches   []GrepMatch
+	Truncated bool // More matches existed beyond MaxPerFile
+}
+
+// Grep searches the working copy of every tracked file for the pattern
+func (r *Repo) Grep(opts GrepOptions) ([]GrepFileResult, error) {
+	if opts.Pattern == "" {
+		return nil, fmt.Errorf("search pattern is required")
+	}
+
+	expr := opts.Pattern
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(idx.Entries))
+	for _, e := range idx.Entries {
+		files = append(files, e.Name)
+	}
+	sort.Strings(files)
+
+	var results []GrepFileResult
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(r.path, filepath.FromSlash(file)))
+		if err != nil || isBinary(data) {
+			continue
+		}
+
+		if result, ok := grepLines(file, data, re, opts); ok {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+func grepLines(file string, data []byte, re *regexp.Regexp, opts GrepOptions) (GrepFileResult, bool) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	result := GrepFileResult{File: file}
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		if opts.MaxPerFile > 0 && len(result.Matches) >= opts.MaxPerFile {
+			result.Truncated = true
+			break
+		}
+
+		start := max(i-opts.Context, 0)
+		end := min(i+opts.Context+1, len(lines))
+		result.Matches = append(result.Matches, GrepMatch{
+			LineNumber: i + 1,
+			Line:       line,
+			Before:     lines[start:i],
+			After:      lines[i+1 : end],
+		})
+	}
+
+	return result, len(result.Matches) > 0
+}
+
+// isBinary reports whether data looks like binary content
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
+
+// FormatGrep formats search results for display
+func FormatGrep(pattern string, results []GrepFileResult) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("## Search: `%s`\n\n", pattern))
+
+	if len(results) == 0 {
+		builder.WriteString("No matches found.\n")
+		return builder.String()
+	}
+
+	for _, res := range results {
+		builder.WriteString(fmt.Sprintf("### %s\n\n```\n", res.File))
+		// Context shared by nearby matches is written once, and a match's
+		// trailing context stops at the next match so it isn't shown as
+		// context too. last is the number of the last line written.
+		last := 0
+		line := func(n int, sep, text string) {
+			if n <= last {
+				return
+			}
+			if last > 0 && n > last+1 {
+				builder.WriteString("--\n")
+			}
+			builder.WriteString(fmt.Sprintf("%4d%s %s\n", n, sep, text))
+			last = n
+		}
+		for i, m := range res.Matches {
+			for j, l := range m.Before {
+				line(m.LineNumber-len(m.Before)+j, "-", l)
+			}
+			line(m.LineNumber, ":", m.Line)
+			for j, l := range m.After {
+				n := m.LineNumber + 1 + j
+				if i+1 < len(res.Matches) && n >= res.Matches[i+1].LineNumber {
+					break
+				}
+				line(n, "-", l)
+			}
+		}
+		builder.WriteString("```\n")
+		if res.Truncated {
+			builder.WriteString(fmt.Sprintf("_(showing first %d matches)_\n", len(res.Matches)))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
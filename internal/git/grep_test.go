@@ -0,0 +1,86 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepo_GrepContextAndCap(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	content := "alpha\nbeta\nTODO one\ngamma\nTODO two\nTODO three\ndelta\n"
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(content), 0644)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	if _, err := repo.worktree.Add("notes.txt"); err != nil {
+		t.Fatalf("failed to add file: %v", err)
+	}
+
+	results, err := repo.Grep(GrepOptions{Pattern: "TODO", Context: 1, MaxPerFile: 2})
+	if err != nil {
+		t.Fatalf("Grep() error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 file result, got %d", len(results))
+	}
+
+	res := results[0]
+	if len(res.Matches) != 2 {
+		t.Fatalf("expected matches capped at 2, got %d", len(res.Matches))
+	}
+	if !res.Truncated {
+		t.Error("expected result to be marked truncated")
+	}
+
+	first := res.Matches[0]
+	if first.LineNumber != 3 {
+		t.Errorf("expected first match on line 3, got %d", first.LineNumber)
+	}
+	if len(first.Before) != 1 || first.Before[0] != "beta" {
+		t.Errorf("expected 'beta' before first match, got %v", first.Before)
+	}
+	if len(first.After) != 1 || first.After[0] != "gamma" {
+		t.Errorf("expected 'gamma' after first match, got %v", first.After)
+	}
+
+	out := FormatGrep("TODO", results)
+	if !strings.Contains(out, "   2- beta") || !strings.Contains(out, "   3: TODO one") {
+		t.Errorf("formatted output missing context lines:\n%s", out)
+	}
+	if strings.Contains(out, "   6: TODO three") {
+		t.Error("formatted output should not include matches beyond the cap")
+	}
+}
+
+func TestFormatGrepMergesNearbyMatches(t *testing.T) {
+	results := []GrepFileResult{{
+		File: "notes.txt",
+		Matches: []GrepMatch{
+			{LineNumber: 3, Line: "TODO one", Before: []string{"beta"}, After: []string{"gamma"}},
+			{LineNumber: 5, Line: "TODO two", Before: []string{"gamma"}, After: []string{"TODO three"}},
+			{LineNumber: 6, Line: "TODO three", Before: []string{"TODO two"}, After: []string{"delta"}},
+			{LineNumber: 10, Line: "TODO four", Before: []string{"eta"}, After: nil},
+		},
+	}}
+
+	out := FormatGrep("TODO", results)
+	want := "```\n" +
+		"   2- beta\n" +
+		"   3: TODO one\n" +
+		"   4- gamma\n" +
+		"   5: TODO two\n" +
+		"   6: TODO three\n" +
+		"   7- delta\n" +
+		"--\n" +
+		"   9- eta\n" +
+		"  10: TODO four\n" +
+		"```\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected overlapping context to be merged, got:\n%s", out)
+	}
+}
@@ -0,0 +1,123 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// fileVersion is one side of a file comparison
+type fileVersion struct {
+	path    string
+	hash    plumbing.Hash
+	mode    filemode.FileMode
+	content []byte
+}
+
+func (f *fileVersion) Hash() plumbing.Hash     { return f.hash }
+func (f *fileVersion) Mode() filemode.FileMode { return f.mode }
+func (f *fileVersion) Path() string            { return f.path }
+
+// filePatch implements fdiff.FilePatch for two in-memory file versions
+type filePatch struct {
+	from, to *fileVersion
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func newFilePatch(from, to *fileVersion) *filePatch {
+	fp := &filePatch{from: from, to: to}
+
+	var src, dst []byte
+	if from != nil {
+		src = from.content
+	}
+	if to != nil {
+		dst = to.content
+	}
+
+	if isBinary(src) || isBinary(dst) {
+		fp.binary = true
+		return fp
+	}
+
+	for _, d := range diff.Do(string(src), string(dst)) {
+		var op fdiff.Operation
+		switch d.Type {
+		case dmp.DiffEqual:
+			op = fdiff.Equal
+		case dmp.DiffDelete:
+			op = fdiff.Delete
+		case dmp.DiffInsert:
+			op = fdiff.Add
+		}
+		fp.chunks = append(fp.chunks, chunk{content: d.Text, op: op})
+	}
+
+	return fp
+}
+
+func (p *filePatch) IsBinary() bool { return p.binary }
+
+func (p *filePatch) Files() (fdiff.File, fdiff.File) {
+	// Return untyped nils so the encoder can detect added/deleted files
+	var from, to fdiff.File
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+func (p *filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c chunk) Content() string       { return c.content }
+func (c chunk) Type() fdiff.Operation { return c.op }
+
+// patch implements fdiff.Patch over a set of file patches
+type patch struct {
+	files []fdiff.FilePatch
+}
+
+func (p *patch) FilePatches() []fdiff.FilePatch { return p.files }
+func (p *patch) Message() string                { return "" }
+
+// encodePatch writes p as a unified diff with the given context lines
+func encodePatch(w io.Writer, p fdiff.Patch, contextLines int) error {
+	if contextLines <= 0 {
+		contextLines = fdiff.DefaultContextLines
+	}
+	return fdiff.NewUnifiedEncoder(w, contextLines).Encode(p)
+}
+
+// UnifiedDiff returns a unified diff turning from into to for a single
+// file, or "" if the contents are identical
+func UnifiedDiff(path string, from, to []byte) (string, error) {
+	if bytes.Equal(from, to) {
+		return "", nil
+	}
+
+	fp := newFilePatch(
+		&fileVersion{path: path, hash: plumbing.ComputeHash(plumbing.BlobObject, from), mode: filemode.Regular, content: from},
+		&fileVersion{path: path, hash: plumbing.ComputeHash(plumbing.BlobObject, to), mode: filemode.Regular, content: to},
+	)
+
+	var builder strings.Builder
+	if err := encodePatch(&builder, &patch{files: []fdiff.FilePatch{fp}}, 0); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
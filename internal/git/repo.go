@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,10 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
+// ErrNoCommits is returned by operations that need HEAD in a freshly
+// initialized repository
+var ErrNoCommits = errors.New("repository has no commits yet")
+
 // Repo wraps go-git repository operations
 type Repo struct {
 	repo     *gogit.Repository
@@ -76,14 +81,27 @@ func (r *Repo) Path() string {
 	return r.path
 }
 
-// Head returns the current HEAD reference
+// Head returns the current HEAD reference, or ErrNoCommits if the
+// repository has no commits yet
 func (r *Repo) Head() (*plumbing.Reference, error) {
-	return r.repo.Head()
+	head, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, ErrNoCommits
+	}
+	return head, err
 }
 
-// CurrentBranch returns the current branch name
+// CurrentBranch returns the current branch name. In a repository with no
+// commits it returns the branch the first commit will create.
 func (r *Repo) CurrentBranch() (string, error) {
-	head, err := r.repo.Head()
+	head, err := r.Head()
+	if errors.Is(err, ErrNoCommits) {
+		sym, symErr := r.repo.Reference(plumbing.HEAD, false)
+		if symErr != nil || sym.Type() != plumbing.SymbolicReference {
+			return "", err
+		}
+		return sym.Target().Short(), nil
+	}
 	if err != nil {
 		return "", err
 	}
@@ -96,6 +114,12 @@ func (r *Repo) CurrentBranch() (string, error) {
 	return head.Hash().String()[:7], nil
 }
 
+// IsDetached reports whether HEAD points at a commit rather than a branch
+func (r *Repo) IsDetached() bool {
+	head, err := r.repo.Reference(plumbing.HEAD, false)
+	return err == nil && head.Type() == plumbing.HashReference
+}
+
 // IsDirty returns true if there are uncommitted changes
 func (r *Repo) IsDirty() (bool, error) {
 	status, err := r.worktree.Status()
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -96,3 +97,56 @@ func TestRepo_IsDirty(t *testing.T) {
 		t.Error("expected dirty repo")
 	}
 }
+
+func TestRepo_NoCommits(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := gogit.PlainInit(dir, false); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("hello"), 0644)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	if _, err := repo.GetLog(5); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("GetLog() error = %v, want ErrNoCommits", err)
+	}
+	_, err = repo.Blame("test.txt")
+	if !errors.Is(err, ErrNoCommits) || err.Error() != "repository has no commits yet" {
+		t.Errorf("Blame() error = %v, want ErrNoCommits", err)
+	}
+
+	branch, err := repo.CurrentBranch()
+	if err != nil {
+		t.Fatalf("CurrentBranch() error: %v", err)
+	}
+	if branch != "master" && branch != "main" {
+		t.Errorf("expected unborn branch master or main, got %s", branch)
+	}
+	if repo.IsDetached() {
+		t.Error("unborn branch should not be reported as detached")
+	}
+}
+
+func TestRepo_Detached(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	head, _ := repo.Head()
+	if err := repo.worktree.Checkout(&gogit.CheckoutOptions{Hash: head.Hash()}); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	if !repo.IsDetached() {
+		t.Error("expected detached HEAD")
+	}
+	branch, err := repo.CurrentBranch()
+	if err != nil || branch != head.Hash().String()[:7] {
+		t.Errorf("CurrentBranch() = %q, %v; want short hash", branch, err)
+	}
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"errors"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+// Stage adds paths to the index. A path of "." stages every change,
+// including deletions and untracked files.
+func (r *Repo) Stage(paths ...string) error {
+	if len(paths) == 0 {
+		return errors.New("no paths given")
+	}
+
+	for _, p := range paths {
+		if p == "." {
+			return r.worktree.AddWithOptions(&gogit.AddOptions{All: true})
+		}
+	}
+
+	for _, p := range paths {
+		if _, err := r.worktree.Add(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unstage resets paths in the index to HEAD, leaving the working tree
+// untouched. A path of "." unstages everything.
+func (r *Repo) Unstage(paths ...string) error {
+	if len(paths) == 0 {
+		return errors.New("no paths given")
+	}
+
+	opts := &gogit.ResetOptions{Mode: gogit.MixedReset}
+	for _, p := range paths {
+		if p == "." {
+			opts.Files = nil
+			break
+		}
+		opts.Files = append(opts.Files, p)
+	}
+
+	return r.worktree.Reset(opts)
+}
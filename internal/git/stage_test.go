@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRepo_StageAndUnstage(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "test.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	status, _ := repo.GetStatus()
+	if !slices.Contains(status.Modified, "test.txt") || slices.Contains(status.Staged, "test.txt") {
+		t.Fatalf("expected test.txt modified but not staged, got %+v", status)
+	}
+
+	if err := repo.Stage("test.txt"); err != nil {
+		t.Fatalf("Stage() error: %v", err)
+	}
+	status, _ = repo.GetStatus()
+	if !slices.Contains(status.Staged, "test.txt") || slices.Contains(status.Modified, "test.txt") {
+		t.Errorf("expected test.txt staged, got %+v", status)
+	}
+
+	if err := repo.Unstage("test.txt"); err != nil {
+		t.Fatalf("Unstage() error: %v", err)
+	}
+	status, _ = repo.GetStatus()
+	if slices.Contains(status.Staged, "test.txt") || !slices.Contains(status.Modified, "test.txt") {
+		t.Errorf("expected test.txt back to modified, got %+v", status)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "test.txt"))
+	if string(data) != "changed" {
+		t.Errorf("unstage should not touch the working tree, got %q", data)
+	}
+}
+
+func TestRepo_StageAll(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("changed"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0644)
+
+	if err := repo.Stage("."); err != nil {
+		t.Fatalf("Stage(.) error: %v", err)
+	}
+	status, _ := repo.GetStatus()
+	for _, f := range []string{"test.txt", "new.txt"} {
+		if !slices.Contains(status.Staged, f) {
+			t.Errorf("expected %s staged, got %+v", f, status)
+		}
+	}
+
+	if err := repo.Unstage("."); err != nil {
+		t.Fatalf("Unstage(.) error: %v", err)
+	}
+	status, _ = repo.GetStatus()
+	if len(status.Staged) != 0 {
+		t.Errorf("expected nothing staged, got %v", status.Staged)
+	}
+	if !slices.Contains(status.Untracked, "new.txt") {
+		t.Errorf("expected new.txt untracked again, got %+v", status)
+	}
+}
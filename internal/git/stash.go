@@ -0,0 +1,59 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNothingToStash is returned by Stash when the worktree is clean
+var ErrNothingToStash = errors.New("no local changes to stash")
+
+// Stash shelves staged, unstaged and untracked changes, leaving a clean
+// worktree. go-git has no stash support, so this runs the git binary; the
+// entries are ordinary git stashes.
+func (r *Repo) Stash(message string) error {
+	dirty, err := r.IsDirty()
+	if err != nil {
+		return err
+	}
+	if !dirty {
+		return ErrNothingToStash
+	}
+
+	args := []string{"stash", "push", "--include-untracked"}
+	if message != "" {
+		args = append(args, "--message", message)
+	}
+	_, err = r.runGit(args...)
+	return err
+}
+
+// StashPop restores the most recent stash and drops it. On a conflict the
+// stash is kept and git's message is returned.
+func (r *Repo) StashPop() error {
+	_, err := r.runGit("stash", "pop")
+	return err
+}
+
+// runGit runs the git binary in the repository root and returns its output.
+// Failures carry git's stderr.
+func (r *Repo) runGit(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.path}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("git %s needs the git command, which was not found", args[0])
+		}
+		if detail := strings.TrimSpace(stderr.String() + "\n" + stdout.String()); detail != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], detail)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepo_StashAndPop(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@test.com")
+	}
+
+	dir := setupTestRepo(t)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	if err := repo.Stash(""); !errors.Is(err, ErrNothingToStash) {
+		t.Errorf("Stash() on a clean tree = %v, want ErrNothingToStash", err)
+	}
+
+	file := filepath.Join(dir, "test.txt")
+	os.WriteFile(file, []byte("changed"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("untracked"), 0644)
+
+	if err := repo.Stash("work in progress"); err != nil {
+		t.Fatalf("Stash() error: %v", err)
+	}
+	if dirty, _ := repo.IsDirty(); dirty {
+		t.Error("worktree should be clean after stashing")
+	}
+	if data, _ := os.ReadFile(file); string(data) != "hello" {
+		t.Errorf("expected committed content after stashing, got %q", data)
+	}
+
+	if err := repo.StashPop(); err != nil {
+		t.Fatalf("StashPop() error: %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "changed" {
+		t.Errorf("expected the change back after popping, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); err != nil {
+		t.Errorf("untracked file should be restored: %v", err)
+	}
+
+	if err := repo.StashPop(); err == nil {
+		t.Error("expected an error popping with no stash left")
+	}
+}
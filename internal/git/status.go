@@ -2,10 +2,12 @@ package git
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
 // Status represents the repository status
@@ -68,24 +70,37 @@ func (s *Status) FormatForStatusBar() string {
 
 // GetLog returns recent commits
 func (r *Repo) GetLog(n int) ([]CommitInfo, error) {
-	head, err := r.repo.Head()
+	return r.log(&gogit.LogOptions{}, n)
+}
+
+// FileLog returns the n most recent commits that changed path, which is
+// relative to the repository root
+func (r *Repo) FileLog(path string, n int) ([]CommitInfo, error) {
+	path = filepath.ToSlash(filepath.Clean(path))
+	return r.log(&gogit.LogOptions{FileName: &path}, n)
+}
+
+// log walks history from HEAD with opts, keeping at most n commits
+func (r *Repo) log(opts *gogit.LogOptions, n int) ([]CommitInfo, error) {
+	head, err := r.Head()
 	if err != nil {
 		return nil, err
 	}
 
-	iter, err := r.repo.Log(&gogit.LogOptions{
-		From: head.Hash(),
-	})
+	opts.From = head.Hash()
+	iter, err := r.repo.Log(opts)
 	if err != nil {
 		return nil, err
 	}
 
+	defer iter.Close()
+
 	var commits []CommitInfo
 	count := 0
 
 	err = iter.ForEach(func(c *object.Commit) error {
 		if count >= n {
-			return fmt.Errorf("done") // Stop iteration
+			return storer.ErrStop // ForEach treats this as a clean stop
 		}
 
 		commits = append(commits, CommitInfo{
@@ -98,7 +113,7 @@ func (r *Repo) GetLog(n int) ([]CommitInfo, error) {
 		return nil
 	})
 
-	if err != nil && err.Error() != "done" {
+	if err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepo_GetLogStopsAtN(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		content := []byte(fmt.Sprintf("change %d", i))
+		if err := os.WriteFile(filepath.Join(dir, "test.txt"), content, 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		if err := repo.Stage("test.txt"); err != nil {
+			t.Fatalf("failed to stage: %v", err)
+		}
+		if _, err := repo.Commit(fmt.Sprintf("Change %d", i), Signature{Name: "Test", Email: "test@test.com"}); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+
+	commits, err := repo.GetLog(3)
+	if err != nil {
+		t.Fatalf("GetLog() error: %v", err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("expected 3 commits, got %d", len(commits))
+	}
+	if commits[0].Message != "Change 4" || commits[2].Message != "Change 2" {
+		t.Errorf("expected newest commits first, got %+v", commits)
+	}
+
+	// Asking for more than exist returns them all
+	commits, err = repo.GetLog(10)
+	if err != nil {
+		t.Fatalf("GetLog() error: %v", err)
+	}
+	if len(commits) != 5 {
+		t.Errorf("expected all 5 commits, got %d", len(commits))
+	}
+}
+
+func TestRepo_FileLog(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+
+	commit := func(file, content, message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		if err := repo.Stage(file); err != nil {
+			t.Fatalf("failed to stage: %v", err)
+		}
+		if _, err := repo.Commit(message, Signature{Name: "Test", Email: "test@test.com"}); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	commit("main.go", "package main\n", "Add main")
+	commit("other.txt", "unrelated\n", "Add other")
+	commit("main.go", "package main\n\nfunc main() {}\n", "Add func main")
+
+	commits, err := repo.FileLog("main.go", 10)
+	if err != nil {
+		t.Fatalf("FileLog() error: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits touching main.go, got %+v", commits)
+	}
+	if commits[0].Message != "Add func main" || commits[1].Message != "Add main" {
+		t.Errorf("unexpected file history: %+v", commits)
+	}
+
+	if commits, _ := repo.FileLog("main.go", 1); len(commits) != 1 {
+		t.Errorf("expected FileLog to stop at n, got %d commits", len(commits))
+	}
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Turn is one message of a transcript
+type Turn struct {
+	Role    string `json:"role"` // "user", "assistant", "system", or "error"
+	Content string `json:"content"`
+}
+
+// roleHeadings label each role in exported markdown
+var roleHeadings = map[string]string{
+	"user":      "User",
+	"assistant": "Assistant",
+	"system":    "Context",
+	"error":     "Error",
+	"note":      "Note",
+}
+
+// FirstMessage returns the content of the first user turn, or "" if there
+// is none
+func FirstMessage(turns []Turn) string {
+	for _, t := range turns {
+		if t.Role == "user" {
+			return t.Content
+		}
+	}
+	return ""
+}
+
+// Markdown renders turns as a markdown document titled after the first user
+// message.
+func Markdown(turns []Turn, exported time.Time) string {
+	title := DeriveTitle(FirstMessage(turns))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n_Exported from flux on %s_\n", title, exported.Format("2006-01-02 15:04"))
+	for _, t := range turns {
+		heading, ok := roleHeadings[t.Role]
+		if !ok {
+			heading = t.Role
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", heading, strings.TrimSpace(t.Content))
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// LastSession is the name the conversation is saved under when flux quits
+const LastSession = "last-session"
+
+// saved is a session as stored on disk
+type saved struct {
+	Title string    `json:"title,omitempty"`
+	Saved time.Time `json:"saved"`
+	Turns []Turn    `json:"turns"`
+}
+
+// Dir returns where sessions are saved: a sessions folder in the data dir
+func Dir() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sessions"), nil
+}
+
+// Save writes turns and their title as the named session in dir, replacing
+// an earlier save of the same name, and returns the file's path. The file is
+// replaced in one step, so a failed save leaves the previous one intact.
+func Save(dir, name, title string, turns []Turn, now time.Time) (string, error) {
+	data, err := json.MarshalIndent(saved{Title: title, Saved: now, Turns: turns}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("saving session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("saving session: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("saving session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("saving session: %w", err)
+	}
+
+	path := filepath.Join(dir, name+".json")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("saving session: %w", err)
+	}
+	return path, nil
+}
+
+// Load reads the named session saved in dir, returning its title and turns.
+// The name may include the .json extension but not a directory.
+func Load(dir, name string) (title string, turns []Turn, err error) {
+	name = strings.TrimSuffix(name, ".json")
+	if name == "" || name != filepath.Base(name) || name == ".." {
+		return "", nil, fmt.Errorf("invalid session name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, fmt.Errorf("no saved session named %s", name)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("loading session: %w", err)
+	}
+
+	var s saved
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", nil, fmt.Errorf("loading session %s: %w", name, err)
+	}
+	if s.Title == "" {
+		s.Title = DeriveTitle(FirstMessage(s.Turns))
+	}
+	return s.Title, s.Turns, nil
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	now := time.Date(2025, 3, 1, 14, 5, 0, 0, time.UTC)
+
+	if _, err := Save(dir, LastSession, "old", []Turn{{Role: "user", Content: "old"}}, now); err != nil {
+		t.Fatal(err)
+	}
+	turns := []Turn{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "hi"}}
+	path, err := Save(dir, LastSession, "Greeting", turns, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "last-session.json") {
+		t.Errorf("path = %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got saved
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("saved file is not JSON: %v\n%s", err, data)
+	}
+	if got.Title != "Greeting" || !got.Saved.Equal(now) || !slices.Equal(got.Turns, turns) {
+		t.Errorf("saved %+v, want the second save", got)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestLoadReadsSave(t *testing.T) {
+	dir := t.TempDir()
+	turns := []Turn{{Role: "user", Content: "Why does my goroutine leak?"}, {Role: "assistant", Content: "Check the channel"}}
+	if _, err := Save(dir, LastSession, "Goroutine leak", turns, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	title, got, err := Load(dir, LastSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Goroutine leak" || !slices.Equal(got, turns) {
+		t.Errorf("Load() = %q, %+v; want the saved session", title, got)
+	}
+	if _, _, err := Load(dir, LastSession+".json"); err != nil {
+		t.Errorf("Load() with the extension: %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600)
+	untitled := `{"saved":"2025-03-01T14:05:00Z","turns":[{"role":"user","content":"Fix the build"}]}`
+	os.WriteFile(filepath.Join(dir, "untitled.json"), []byte(untitled), 0o600)
+
+	for _, name := range []string{"missing", "broken", "../escape", ""} {
+		if _, _, err := Load(dir, name); err == nil {
+			t.Errorf("Load(%q) should fail", name)
+		}
+	}
+	if title, _, err := Load(dir, "untitled"); err != nil || title != "Fix the build" {
+		t.Errorf("Load(untitled) = %q, %v; want a title derived from the first message", title, err)
+	}
+}
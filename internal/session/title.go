@@ -0,0 +1,77 @@
+// Package session holds helpers for naming and persisting chat sessions.
+package session
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+	"github.com/kbesada/flux-code-cli/internal/textutil"
+)
+
+// MaxTitleLength caps generated titles, in terminal columns
+const MaxTitleLength = 50
+
+const titlePrompt = "Write a title of at most six words for a chat that starts with the message below. Reply with the title only, no quotes or punctuation at the end."
+
+// DeriveTitle builds a short title from the first user message without
+// calling a model: the first non-empty line, with markdown and slash
+// commands stripped, cut at a word boundary.
+func DeriveTitle(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "#>*-` ")
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		return truncateTitle(strings.Join(strings.Fields(line), " "))
+	}
+	return "Untitled session"
+}
+
+// GenerateTitle asks the client for a title, falling back to DeriveTitle when
+// the client is nil, the message is empty, the call fails, or the reply is
+// empty.
+func GenerateTitle(ctx context.Context, client ai.Client, message string) string {
+	if client == nil || strings.TrimSpace(message) == "" {
+		return DeriveTitle(message)
+	}
+
+	resp, err := client.Complete(ctx, ai.ChatRequest{
+		Messages: []ai.ChatMessage{
+			{Role: "system", Content: titlePrompt},
+			{Role: "user", Content: message},
+		},
+		MaxTokens: 20,
+	})
+	if err != nil {
+		return DeriveTitle(message)
+	}
+
+	title := strings.Trim(strings.TrimSpace(resp.Content), `"'.`)
+	if title == "" {
+		return DeriveTitle(message)
+	}
+	return truncateTitle(strings.Join(strings.Fields(title), " "))
+}
+
+// truncateTitle cuts s to MaxTitleLength columns, at a word boundary when
+// one falls in the second half, and marks the cut with an ellipsis
+func truncateTitle(s string) string {
+	if ansi.StringWidth(s) <= MaxTitleLength {
+		return s
+	}
+
+	// One column more than fits, so a word ending exactly at the limit is kept
+	head := textutil.TruncateRunes(s, MaxTitleLength+1, "")
+	cut := textutil.TruncateRunes(s, MaxTitleLength, "")
+	if i := strings.LastIndexFunc(head, unicode.IsSpace); i >= 0 && ansi.StringWidth(head[:i]) > MaxTitleLength/2 {
+		cut = head[:i]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + textutil.Ellipsis
+}
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+)
+
+func TestDeriveTitle(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"How do I reverse a slice in Go?", "How do I reverse a slice in Go?"},
+		{"\n\n## Fix the   flaky test\nmore details", "Fix the flaky test"},
+		{"", "Untitled session"},
+	}
+
+	for _, tt := range tests {
+		if got := DeriveTitle(tt.message); got != tt.want {
+			t.Errorf("DeriveTitle(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDeriveTitleTruncatesAtWord(t *testing.T) {
+	msg := strings.Repeat("refactor ", 20)
+	title := DeriveTitle(msg)
+
+	if utf8.RuneCountInString(title) > MaxTitleLength+1 {
+		t.Errorf("title too long: %q", title)
+	}
+	if !strings.HasSuffix(title, "refactor…") {
+		t.Errorf("title should be cut at a word boundary, got %q", title)
+	}
+}
+
+type titleClient struct {
+	reply string
+	err   error
+}
+
+func (c *titleClient) Complete(ctx context.Context, req ai.ChatRequest) (ai.ChatResponse, error) {
+	return ai.ChatResponse{Content: c.reply}, c.err
+}
+
+func (c *titleClient) Stream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamEvent, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *titleClient) ListModels(ctx context.Context) ([]string, error) {
+	return nil, ai.ErrNotSupported
+}
+
+func (c *titleClient) Model() string    { return "test" }
+func (c *titleClient) SetModel(string)  {}
+func (c *titleClient) Provider() string { return "test" }
+
+func TestGenerateTitle(t *testing.T) {
+	ctx := context.Background()
+	msg := "Why does my goroutine leak?"
+
+	if got := GenerateTitle(ctx, &titleClient{reply: "\"Debugging a goroutine leak.\""}, msg); got != "Debugging a goroutine leak" {
+		t.Errorf("expected cleaned AI title, got %q", got)
+	}
+	if got := GenerateTitle(ctx, &titleClient{err: errors.New("offline")}, msg); got != msg {
+		t.Errorf("expected heuristic fallback on error, got %q", got)
+	}
+	if got := GenerateTitle(ctx, nil, msg); got != msg {
+		t.Errorf("expected heuristic title without a client, got %q", got)
+	}
+}
+
+func TestDeriveTitleTruncatesWideText(t *testing.T) {
+	title := DeriveTitle(strings.Repeat("修正", 40))
+
+	if !strings.HasSuffix(title, "…") {
+		t.Errorf("expected long title to end with an ellipsis, got %q", title)
+	}
+	if got := ansi.StringWidth(strings.TrimSuffix(title, "…")); got > MaxTitleLength {
+		t.Errorf("title is %d columns wide, want at most %d: %q", got, MaxTitleLength, title)
+	}
+	if !utf8.ValidString(title) {
+		t.Errorf("title is not valid UTF-8: %q", title)
+	}
+}
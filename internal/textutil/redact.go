@@ -0,0 +1,62 @@
+package textutil
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces masked secrets.
+const Redacted = "[REDACTED]"
+
+// secretPatterns match well-known credential formats. Patterns with a group
+// keep the group (e.g. "api_key=") and mask only what follows it.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),        // OpenAI, Anthropic
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),        // Google
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`),   // GitHub
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`), // GitHub fine-grained
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),           // AWS access key
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), // Slack
+	regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/=-]{16,}`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|secret|token|password|passwd)["']?\s*[:=]\s*["']?)[^\s"',;]{8,}`),
+}
+
+// RedactSecrets masks API keys, tokens, and passwords in s. Any known
+// values (such as configured API keys) are masked wherever they appear.
+func RedactSecrets(s string, known ...string) string {
+	// Longest first so a key that contains another is masked whole
+	known = append([]string(nil), known...)
+	sort.Slice(known, func(i, j int) bool { return len(known[i]) > len(known[j]) })
+	for _, k := range known {
+		if len(k) >= 8 {
+			s = strings.ReplaceAll(s, k, Redacted)
+		}
+	}
+
+	for _, re := range secretPatterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+Redacted)
+		} else {
+			s = re.ReplaceAllString(s, Redacted)
+		}
+	}
+	return s
+}
+
+// AnonymizePaths rewrites the given directories in s, e.g. a home directory
+// to "~". Replacements are applied longest directory first.
+func AnonymizePaths(s string, dirs map[string]string) string {
+	keys := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		if dir != "" && dir != "/" {
+			keys = append(keys, dir)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+	for _, dir := range keys {
+		s = strings.ReplaceAll(s, dir, dirs[dir])
+	}
+	return s
+}
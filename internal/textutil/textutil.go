@@ -0,0 +1,17 @@
+// Package textutil holds small text helpers shared across packages.
+package textutil
+
+import "bytes"
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Normalize strips a leading UTF-8 byte order mark and converts CRLF and
+// lone CR line endings to LF. Data without either is returned unchanged.
+func Normalize(data []byte) []byte {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if bytes.IndexByte(data, '\r') == -1 {
+		return data
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+}
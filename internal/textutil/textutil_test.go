@@ -0,0 +1,64 @@
+package textutil
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain\ntext\n", "plain\ntext\n"},
+		{"\ufeffbom first", "bom first"},
+		{"a\r\nb\r\n", "a\nb\n"},
+		{"old\rmac", "old\nmac"},
+		{"\ufeffboth\r\n", "both\n"},
+		{"mid\ufeffdle", "mid\ufeffdle"},
+	}
+
+	for _, tt := range tests {
+		if got := string(Normalize([]byte(tt.in))); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s      string
+		width  int
+		marker string
+		want   string
+	}{
+		{"short", 10, Ellipsis, "short"},
+		{"exactly10!", 10, Ellipsis, "exactly10!"},
+		{"a longer string", 8, Ellipsis, "a longe…"},
+		{"José García Márquez", 12, Ellipsis, "José García…"},
+		{"日本語のテキスト", 7, Ellipsis, "日本語…"},
+		{"a longer string", 8, "...", "a lon..."},
+		{"a longer string", 8, " [more]", "a [more]"},
+		{"abcdef", 2, "...", ".."},
+		{"abc", 0, Ellipsis, ""},
+	}
+
+	for _, tt := range tests {
+		got := TruncateRunes(tt.s, tt.width, tt.marker)
+		if got != tt.want {
+			t.Errorf("TruncateRunes(%q, %d, %q) = %q, want %q", tt.s, tt.width, tt.marker, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("TruncateRunes(%q, %d, %q) produced invalid UTF-8", tt.s, tt.width, tt.marker)
+		}
+	}
+}
+
+func TestPadRunes(t *testing.T) {
+	if got := PadRunes("Zoë", 6, Ellipsis); got != "Zoë   " {
+		t.Errorf("PadRunes() = %q, want %q", got, "Zoë   ")
+	}
+	if got := PadRunes("日本語テキスト", 6, Ellipsis); got != "日本… " {
+		t.Errorf("PadRunes() = %q, want wide runes padded to width", got)
+	}
+}
@@ -0,0 +1,34 @@
+package textutil
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Ellipsis is the default marker for truncated text.
+const Ellipsis = "…"
+
+// TruncateRunes shortens s to at most width terminal columns, ending it with
+// marker when anything was cut. It never splits a multi-byte rune and
+// counts wide characters such as CJK and emoji as two columns. A marker
+// wider than width is itself cut to fit.
+func TruncateRunes(s string, width int, marker string) string {
+	if width <= 0 {
+		return ""
+	}
+	if ansi.StringWidth(s) <= width {
+		return s
+	}
+	if ansi.StringWidth(marker) > width {
+		return ansi.Truncate(marker, width, "")
+	}
+	return ansi.Truncate(s, width, marker)
+}
+
+// PadRunes truncates s like TruncateRunes and pads it with spaces to
+// exactly width columns, for aligned columns of text.
+func PadRunes(s string, width int, marker string) string {
+	s = TruncateRunes(s, width, marker)
+	return s + strings.Repeat(" ", max(width-ansi.StringWidth(s), 0))
+}
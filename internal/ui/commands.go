@@ -0,0 +1,624 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+	"github.com/kbesada/flux-code-cli/internal/commands"
+	"github.com/kbesada/flux-code-cli/internal/config"
+	"github.com/kbesada/flux-code-cli/internal/fuzzy"
+	"github.com/kbesada/flux-code-cli/internal/session"
+	"github.com/kbesada/flux-code-cli/internal/ui/components"
+)
+
+// runCommand dispatches a slash command and applies its result
+func (m Model) runCommand(value string) (Model, tea.Cmd) {
+	cmd := commands.Parse(value)
+	if cmd != nil && len(cmd.Args) == 0 && m.currentFile != "" {
+		if info, ok := m.commands.Info(cmd.Name); ok && info.FileTarget {
+			cmd.Args = []string{m.currentFile}
+		}
+	}
+
+	result := m.commands.Dispatch(cmd)
+	if result.Error == nil && result.Action == commands.ActionRepeat {
+		if m.lastCommand == "" {
+			return m, nil
+		}
+		return m.runCommand(m.lastCommand)
+	}
+	m.lastCommand = value
+
+	if result.Error == nil && result.Action == commands.ActionRetry {
+		return m.retry()
+	}
+
+	if result.Error == nil && result.Action == commands.ActionQuit {
+		if m.streaming {
+			m.cancelStream()
+		}
+		return m, m.quit()
+	}
+
+	if result.Error == nil && result.Async != nil {
+		return m.runAsync(value, result.Async, nil)
+	}
+
+	// Model lookups ask the provider, so they run in the background too
+	if result.Error == nil && m.client != nil {
+		switch {
+		case result.Action == commands.ActionSetModel && result.Value != "":
+			return m.runAsync(value, m.resolveModel(result.Value), (*Model).setModel)
+		case result.Action == commands.ActionListModels:
+			return m.runAsync(value, m.listModels(), nil)
+		}
+	}
+
+	if result.Error == nil && result.Action != commands.ActionNone {
+		result = m.applyAction(result)
+	}
+
+	m.showResult(value, result)
+	return m, nil
+}
+
+// showResult adds a command's output, or its error, to the chat. Only
+// output added as context is a system message, sent to the model with the
+// command line shown above it; other output is a note for the user.
+func (m *Model) showResult(value string, result commands.CommandResult) {
+	if result.Error != nil {
+		m.messages.Add(components.RoleError, "Error: "+result.Error.Error())
+	} else if result.Output != "" {
+		if result.AddToChat {
+			m.messages.Add(components.RoleUser, value)
+			m.messages.Add(components.RoleSystem, result.Output)
+		} else {
+			m.messages.Add(components.RoleNote, result.Output)
+		}
+	}
+
+	m.refreshViewport()
+}
+
+// commandDoneMsg carries the result of a command that ran in the background
+type commandDoneMsg struct {
+	id     int
+	value  string
+	result commands.CommandResult
+	finish func(*Model, commands.CommandResult) commands.CommandResult
+}
+
+// runAsync runs a slow command's work off the event loop so the UI stays
+// responsive; Esc cancels it. Only one runs at a time. finish, when set,
+// applies a successful result to the model once it arrives.
+func (m Model) runAsync(value string, work func(context.Context) commands.CommandResult, finish func(*Model, commands.CommandResult) commands.CommandResult) (Model, tea.Cmd) {
+	if m.commandCancel != nil {
+		m.showResult(value, commands.CommandResult{
+			Error: fmt.Errorf("%s is still running; press Esc to cancel it", m.command),
+		})
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.commandID++
+	m.command = value
+	m.commandCancel = cancel
+	id := m.commandID
+	return m, func() tea.Msg {
+		return commandDoneMsg{id: id, value: value, result: work(ctx), finish: finish}
+	}
+}
+
+// handleCommandDone shows the result of the background command, unless it
+// was cancelled
+func (m Model) handleCommandDone(msg commandDoneMsg) (Model, tea.Cmd) {
+	if msg.id != m.commandID || m.commandCancel == nil {
+		return m, nil
+	}
+	m.commandCancel()
+	m.commandCancel = nil
+	m.command = ""
+
+	result := msg.result
+	if result.Error == nil && msg.finish != nil {
+		result = msg.finish(&m, result)
+	}
+	m.showResult(msg.value, result)
+	return m, nil
+}
+
+// cancelCommand stops the background command and drops its result
+func (m *Model) cancelCommand() {
+	m.commandCancel()
+	m.commandCancel = nil
+	m.command = ""
+	m.messages.Add(components.RoleNote, "(cancelled)")
+	m.refreshViewport()
+}
+
+// retry drops the last assistant reply and streams a new one for the same
+// conversation
+func (m Model) retry() (Model, tea.Cmd) {
+	var err error
+	switch {
+	case m.streaming:
+		err = fmt.Errorf("a response is still streaming; press Esc to cancel it first")
+	case m.client == nil:
+		err = fmt.Errorf("no AI client configured")
+	case !m.messages.PopAssistant():
+		err = fmt.Errorf("nothing to retry: the last message is not a response")
+	}
+	if err != nil {
+		m.messages.Add(components.RoleError, "Error: "+err.Error())
+		m.refreshViewport()
+		return m, nil
+	}
+
+	m.retriedEmpty = false
+	cmd := m.startStream()
+	m.refreshViewport()
+	return m, tea.Batch(cmd, m.spinner.Start())
+}
+
+// addAliases registers configured command aliases. Aliases are added in
+// dependency order so one alias may target another.
+func (m *Model) addAliases(aliases map[string]string) []error {
+	pending := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		pending = append(pending, alias)
+	}
+	sort.Strings(pending)
+
+	var errs []error
+	for len(pending) > 0 {
+		var retry []string
+		errs = nil
+		for _, alias := range pending {
+			if err := m.commands.AddAlias(alias, aliases[alias]); err != nil {
+				retry = append(retry, alias)
+				errs = append(errs, err)
+			}
+		}
+		if len(retry) == len(pending) {
+			break
+		}
+		pending = retry
+	}
+	return errs
+}
+
+// applyAction performs the UI-level effect a command requested and returns
+// the result to display
+func (m *Model) applyAction(result commands.CommandResult) commands.CommandResult {
+	switch result.Action {
+	case commands.ActionSetModel:
+		if m.client == nil {
+			return commands.CommandResult{Error: fmt.Errorf("no AI client configured")}
+		}
+		// A name to switch to is resolved in the background; see runCommand
+		return commands.CommandResult{
+			Output: fmt.Sprintf("Current model: %s/%s", m.client.Provider(), m.client.Model()),
+		}
+	case commands.ActionListModels:
+		// With a client the list is fetched in the background
+		return commands.CommandResult{Error: fmt.Errorf("no AI client configured")}
+	case commands.ActionSetProvider:
+		return m.setProvider(result.Value)
+	case commands.ActionSetPersona:
+		return m.setPersona(result.Value)
+	case commands.ActionDiffLast:
+		return commands.DiffLast(result.Value, m.assistantReplies())
+	case commands.ActionContext:
+		return m.context(result.Value)
+	case commands.ActionExport:
+		return commands.Export(result.Value, m.transcript(), m.apiKeys())
+	case commands.ActionReload:
+		return m.reload()
+	case commands.ActionConfig:
+		return m.configCommand(result.Value)
+	case commands.ActionTogglePaste:
+		return m.togglePaste()
+	case commands.ActionAttachImage:
+		m.pendingImages = append(m.pendingImages, result.Value)
+		return commands.CommandResult{Output: result.Output}
+	case commands.ActionRefreshGit:
+		m.statusBar.Update()
+		return commands.CommandResult{Output: result.Output}
+	case commands.ActionSetTemp:
+		return m.setTemperature(result.Value)
+	case commands.ActionLoad:
+		return m.loadSession(result.Value)
+	}
+
+	return result
+}
+
+// context reports how many attachments the next request will resend, and
+// roughly how many tokens they take, or with "clear" stops sending them. Only
+// output a command added to the chat counts; notes and errors are never
+// sent. The messages stay on screen and chat turns are still sent.
+func (m *Model) context(action string) commands.CommandResult {
+	items := m.messages.Items()
+	attached, tokens := 0, 0
+	for _, msg := range items[m.contextFrom:] {
+		if msg.Role == components.RoleSystem {
+			attached++
+			tokens += ai.EstimateTokens(msg.Content)
+		}
+	}
+
+	if action != "clear" {
+		if attached == 0 {
+			return commands.CommandResult{Output: "No context attached"}
+		}
+		return commands.CommandResult{Output: fmt.Sprintf("%d context attachment(s), about %d tokens, will be sent with the next message; /context clear drops them", attached, tokens)}
+	}
+
+	m.contextFrom = len(items)
+	return commands.CommandResult{Output: fmt.Sprintf("Cleared %d context attachment(s); chat history is kept", attached)}
+}
+
+// loadSession replaces the chat with the session saved under name. Loaded
+// messages are chat history like any other, so the next request sends them.
+func (m *Model) loadSession(name string) commands.CommandResult {
+	dir, err := session.Dir()
+	if err != nil {
+		return commands.CommandResult{Error: err}
+	}
+	title, turns, err := session.Load(dir, name)
+	if err != nil {
+		return commands.CommandResult{Error: err}
+	}
+
+	m.clearChat()
+	for _, t := range turns {
+		m.messages.Add(components.Role(t.Role), t.Content)
+	}
+	m.refreshViewport()
+	return commands.CommandResult{Output: fmt.Sprintf("Loaded %s: %s (%d messages)", strings.TrimSuffix(name, ".json"), title, len(turns))}
+}
+
+// modelListTimeout bounds the model lookups /model and /models make
+const modelListTimeout = 3 * time.Second
+
+// resolveModel returns work that matches a partial model name against the
+// provider's model list. A name that matches nothing, or a provider that
+// can't list models, is taken as typed.
+func (m Model) resolveModel(name string) func(context.Context) commands.CommandResult {
+	client := m.client
+	return func(ctx context.Context) commands.CommandResult {
+		ctx, cancel := context.WithTimeout(ctx, modelListTimeout)
+		defer cancel()
+		models, err := client.ListModels(ctx)
+		if err != nil || len(models) == 0 {
+			return commands.CommandResult{Value: name}
+		}
+		model, err := fuzzy.Resolve(name, models)
+		if errors.Is(err, fuzzy.ErrNoMatch) {
+			return commands.CommandResult{Value: name}
+		}
+		if err != nil {
+			return commands.CommandResult{Error: err}
+		}
+		return commands.CommandResult{Value: model}
+	}
+}
+
+// setModel switches to the model resolveModel found
+func (m *Model) setModel(result commands.CommandResult) commands.CommandResult {
+	if err := m.switchModel(result.Value); err != nil {
+		return commands.CommandResult{Error: err}
+	}
+	return commands.CommandResult{
+		Output: fmt.Sprintf("Switched model to %s", result.Value),
+	}
+}
+
+// listModels returns work that lists the models the current provider offers
+func (m Model) listModels() func(context.Context) commands.CommandResult {
+	provider, active := m.client.Provider(), m.client.Model()
+	client := m.client
+	return func(ctx context.Context) commands.CommandResult {
+		ctx, cancel := context.WithTimeout(ctx, modelListTimeout)
+		defer cancel()
+		models, err := client.ListModels(ctx)
+		if err != nil {
+			return commands.CommandResult{Error: err}
+		}
+		if len(models) == 0 {
+			return commands.CommandResult{Output: fmt.Sprintf("%s reported no models", provider)}
+		}
+		sort.Strings(models)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Models for %s:\n", provider)
+		for _, name := range models {
+			line := "  - " + name
+			if name == active {
+				line += " (active)"
+			}
+			b.WriteString(line + "\n")
+		}
+		return commands.CommandResult{Output: b.String()}
+	}
+}
+
+// setProvider switches to a configured provider, matching partial names
+func (m *Model) setProvider(name string) commands.CommandResult {
+	if m.cfg == nil || len(m.cfg.Providers) == 0 {
+		return commands.CommandResult{Error: fmt.Errorf("no providers configured")}
+	}
+
+	names := make([]string, 0, len(m.cfg.Providers))
+	for provider := range m.cfg.Providers {
+		names = append(names, provider)
+	}
+	sort.Strings(names)
+
+	if name == "" {
+		current := "none"
+		if m.client != nil {
+			current = m.client.Provider()
+		}
+		return commands.CommandResult{
+			Output: fmt.Sprintf("Current provider: %s\nConfigured: %s", current, strings.Join(names, ", ")),
+		}
+	}
+
+	provider, err := fuzzy.Resolve(name, names)
+	if err != nil {
+		return commands.CommandResult{Error: err}
+	}
+	next := *m.cfg
+	next.Provider = provider
+	if err := m.RebuildClient(&next); err != nil {
+		return commands.CommandResult{Error: fmt.Errorf("switch to %s: %w", provider, err)}
+	}
+
+	return commands.CommandResult{
+		Output: fmt.Sprintf("Switched provider to %s (%s)", provider, m.client.Model()),
+	}
+}
+
+// switchModel makes model the active one. With a config it becomes the
+// provider's model and the client is rebuilt; otherwise the client is
+// switched in place.
+func (m *Model) switchModel(model string) error {
+	var provider config.Provider
+	ok := false
+	if m.cfg != nil {
+		provider, ok = m.cfg.Providers[m.cfg.Provider]
+	}
+	if !ok {
+		m.client.SetModel(model)
+		m.statusBar.SetModel(m.client.Provider(), m.client.Model())
+		return nil
+	}
+
+	next := *m.cfg
+	next.Providers = maps.Clone(m.cfg.Providers)
+	provider.Model = model
+	next.Providers[next.Provider] = provider
+	return m.RebuildClient(&next)
+}
+
+// reload re-reads the config file and rebuilds the client from it
+func (m *Model) reload() commands.CommandResult {
+	cfg, err := config.Load()
+	if err != nil {
+		return commands.CommandResult{Error: fmt.Errorf("reload config: %w", err)}
+	}
+	if err := m.RebuildClient(cfg); err != nil {
+		return commands.CommandResult{Error: fmt.Errorf("reload config: %w", err)}
+	}
+	return commands.CommandResult{
+		Output: fmt.Sprintf("Reloaded config; using %s/%s", m.client.Provider(), m.client.Model()),
+	}
+}
+
+// setTemperature sets the temperature sent with the next requests, or shows
+// it when value is empty. "default" goes back to the configured temperature.
+func (m *Model) setTemperature(value string) commands.CommandResult {
+	switch value {
+	case "":
+		switch {
+		case m.temperature != nil:
+			return commands.CommandResult{Output: fmt.Sprintf("Temperature: %g (set with /temp)", *m.temperature)}
+		case m.configTemperature() > 0:
+			return commands.CommandResult{Output: fmt.Sprintf("Temperature: %g (from config)", m.configTemperature())}
+		default:
+			return commands.CommandResult{Output: "Temperature: provider default"}
+		}
+	case commands.DefaultTemperature:
+		m.temperature = nil
+		return commands.CommandResult{Output: "Temperature reset; requests use the configured or provider default"}
+	}
+
+	temp, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return commands.CommandResult{Error: err}
+	}
+	t := float32(temp)
+	m.temperature = &t
+	return commands.CommandResult{Output: fmt.Sprintf("Temperature set to %g for the next requests", t)}
+}
+
+// configTemperature returns the active provider's configured temperature,
+// or 0 when it has none. The provider is looked up by its config key, as
+// BuildActive does; the client's own name may differ, e.g. "custom".
+func (m Model) configTemperature() float32 {
+	if m.cfg == nil || m.client == nil {
+		return 0
+	}
+	return m.cfg.Providers[m.cfg.Provider].Temperature
+}
+
+// togglePaste switches the input between sending on Enter and paste mode
+func (m *Model) togglePaste() commands.CommandResult {
+	on := !m.input.PasteMode()
+	m.input.SetPasteMode(on)
+	m.statusBar.SetPasteMode(on)
+	if on {
+		return commands.CommandResult{Output: "Paste mode on: Enter adds a line, Ctrl+S or Alt+Enter sends. /paste turns it off."}
+	}
+	return commands.CommandResult{Output: "Paste mode off: Enter sends"}
+}
+
+// configCommand shows the effective config, or with "set <key> <value>"
+// changes one setting for this session. --save also writes it to the
+// config file.
+func (m *Model) configCommand(args string) commands.CommandResult {
+	if m.cfg == nil {
+		return commands.CommandResult{Error: fmt.Errorf("no config loaded")}
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return commands.CommandResult{Output: "## Config\n\n```yaml\n" + m.cfg.Describe() + "\n```"}
+	}
+
+	save := slices.Contains(fields, "--save")
+	fields = slices.DeleteFunc(fields, func(f string) bool { return f == "--save" })
+	if fields[0] != "set" || len(fields) < 3 {
+		return commands.CommandResult{Error: fmt.Errorf("usage: /config [set <key> <value> [--save]]")}
+	}
+	key, value := fields[1], strings.Join(fields[2:], " ")
+
+	next := *m.cfg
+	if err := next.Set(key, value); err != nil {
+		return commands.CommandResult{Error: err}
+	}
+
+	switch {
+	case strings.HasPrefix(key, "ui."):
+		if errs := m.applyUIConfig(next.UI); len(errs) > 0 {
+			m.applyUIConfig(m.cfg.UI)
+			return commands.CommandResult{Error: errors.Join(errs...)}
+		}
+		m.cfg = &next
+		m.handleResize()
+	case key == "provider" || key == "fallback":
+		if err := m.RebuildClient(&next); err != nil {
+			return commands.CommandResult{Error: err}
+		}
+	default:
+		m.cfg = &next
+	}
+	// Commands read their settings through config.Get. The loaded config is
+	// shared, so the change goes on a copy that replaces it.
+	if global := config.Get(); global != nil && global != m.cfg {
+		updated := *global
+		if updated.Set(key, value) == nil {
+			config.Replace(&updated)
+		}
+	}
+
+	output := fmt.Sprintf("Set %s to %s", key, value)
+	if save {
+		rewritten, err := m.cfg.Persist(key)
+		if err != nil {
+			return commands.CommandResult{Error: fmt.Errorf("%s; not saved: %w", output, err)}
+		}
+		output += " and saved it to the config file"
+		if rewritten {
+			output += " (the file was rewritten: comments are kept, blank lines and alignment are not)"
+		}
+	}
+	return commands.CommandResult{Output: output}
+}
+
+// transcript returns the chat as export turns
+func (m Model) transcript() []session.Turn {
+	items := m.messages.Items()
+	turns := make([]session.Turn, len(items))
+	for i, msg := range items {
+		turns[i] = session.Turn{Role: string(msg.Role), Content: msg.Content}
+	}
+	return turns
+}
+
+// apiKeys returns the configured provider keys so exports can mask them
+func (m Model) apiKeys() []string {
+	if m.cfg == nil {
+		return nil
+	}
+	var keys []string
+	for _, p := range m.cfg.Providers {
+		if p.APIKey != "" {
+			keys = append(keys, p.APIKey)
+		}
+	}
+	return keys
+}
+
+// assistantReplies returns assistant message contents, newest first
+func (m Model) assistantReplies() []string {
+	items := m.messages.Items()
+	var replies []string
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Role == components.RoleAssistant {
+			replies = append(replies, items[i].Content)
+		}
+	}
+	return replies
+}
+
+// setPersona swaps the system prompt for a configured persona. "default"
+// restores the configured system prompt unless a persona overrides it.
+func (m *Model) setPersona(name string) commands.CommandResult {
+	if name == "" {
+		return commands.CommandResult{Output: m.listPersonas()}
+	}
+
+	prompt, ok := m.personas[name]
+	switch {
+	case ok:
+	case name == "default":
+		prompt = m.defaultPrompt
+		name = ""
+	default:
+		return commands.CommandResult{
+			Error: fmt.Errorf("unknown persona %q\n\n%s", name, m.listPersonas()),
+		}
+	}
+
+	m.SetSystemPrompt(prompt)
+	m.persona = name
+	if name == "" {
+		return commands.CommandResult{Output: "Restored the default system prompt"}
+	}
+	return commands.CommandResult{Output: fmt.Sprintf("Switched persona to %s", name)}
+}
+
+func (m Model) listPersonas() string {
+	if len(m.personas) == 0 {
+		return "No personas configured. Add them under `personas:` in your config."
+	}
+
+	names := make([]string, 0, len(m.personas))
+	for name := range m.personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	builder.WriteString("Personas:\n")
+	for _, name := range names {
+		line := "  - " + name
+		if name == m.persona {
+			line += " (active)"
+		}
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
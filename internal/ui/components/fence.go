@@ -0,0 +1,60 @@
+package components
+
+import "strings"
+
+// balanceFences closes a code fence left open by a partial (streaming)
+// message so it renders as a code block instead of spilling into the rest
+// of the view. A trailing line that is the start of the closing fence, such
+// as two backticks still waiting for the third, is dropped. Balanced content
+// is returned unchanged; the stored message is never modified.
+func balanceFences(content string) string {
+	lines := strings.Split(content, "\n")
+
+	var open string // the fence marker of the open block, e.g. "```"
+	for _, line := range lines {
+		marker, info := fenceMarker(line)
+		switch {
+		case marker == "":
+		case open == "":
+			open = marker
+		case info == "" && marker[0] == open[0] && len(marker) >= len(open):
+			open = ""
+		}
+	}
+	if open == "" {
+		return content
+	}
+
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last != "" && len(lines) > 1 && strings.Trim(last, open[:1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n") + "\n" + open
+}
+
+// fenceMarker returns the run of backticks or tildes opening line, if it is
+// a fence, and the info string after it
+func fenceMarker(line string) (marker, info string) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return "", ""
+	}
+
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return "", ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == c {
+		n++
+	}
+	if n < 3 {
+		return "", ""
+	}
+
+	info = strings.TrimSpace(trimmed[n:])
+	if c == '`' && strings.Contains(info, "`") {
+		return "", "" // backtick fences can't have backticks in the info string
+	}
+	return trimmed[:n], info
+}
@@ -0,0 +1,52 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestBalanceFences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"balanced", "text\n```go\nx := 1\n```\nmore", "text\n```go\nx := 1\n```\nmore"},
+		{"no fences", "just text", "just text"},
+		{"open block", "text\n```go\nx := 1", "text\n```go\nx := 1\n```"},
+		{"partial close dropped", "```\nx := 1\n``", "```\nx := 1\n```"},
+		{"longer fence", "````md\n```\ninner", "````md\n```\ninner\n````"},
+		{"tilde fence", "~~~\ncode", "~~~\ncode\n~~~"},
+		{"second block open", "```\na\n```\n\n```sh\nls", "```\na\n```\n\n```sh\nls\n```"},
+		{"inline code ignored", "use `x` here", "use `x` here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := balanceFences(tt.content); got != tt.want {
+				t.Errorf("balanceFences(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPartialCodeBlock(t *testing.T) {
+	m := NewMessages(80)
+	partial := "Here is the fix:\n\n```go\nfunc main() {\n\tfmt.Println(\"hi\")\n``"
+	m.Add(RoleAssistant, partial)
+
+	out := ansi.Strip(m.Render())
+	if strings.Contains(out, "``") {
+		t.Errorf("fence markers should not leak into the rendered view:\n%s", out)
+	}
+	for _, want := range []string{"Here is the fix:", "func main() {", `fmt.Println("hi")`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered view should contain %q:\n%s", want, out)
+		}
+	}
+	if items := m.Items(); items[0].Content != partial {
+		t.Error("balancing must not change the stored message")
+	}
+}
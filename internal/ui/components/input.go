@@ -5,14 +5,23 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Placeholders for the normal, paste and search input modes
+const (
+	defaultPlaceholder = "Type your message..."
+	pastePlaceholder   = "Paste mode: Enter adds a line, Ctrl+S sends"
+	searchPlaceholder  = "Search messages: Enter finds, Esc cancels"
+)
+
 type Input struct {
-	textarea textarea.Model
-	focused  bool
+	textarea   textarea.Model
+	focused    bool
+	pasteMode  bool
+	searchMode bool
 }
 
 func NewInput() Input {
 	ta := textarea.New()
-	ta.Placeholder = "Type your message..."
+	ta.Placeholder = defaultPlaceholder
 	ta.Focus()
 	ta.CharLimit = 4000
 	ta.SetWidth(80)
@@ -39,6 +48,10 @@ func (i Input) Value() string {
 	return i.textarea.Value()
 }
 
+func (i *Input) SetValue(value string) {
+	i.textarea.SetValue(value)
+}
+
 func (i *Input) Reset() {
 	i.textarea.Reset()
 }
@@ -48,13 +61,44 @@ func (i *Input) SetWidth(w int) {
 }
 
 func (i *Input) Focus() tea.Cmd {
+	i.focused = true
 	return i.textarea.Focus()
 }
 
 func (i *Input) Blur() {
+	i.focused = false
 	i.textarea.Blur()
 }
 
 func (i Input) Focused() bool {
 	return i.focused
 }
+
+// SetPasteMode switches between sending on Enter and paste mode, where Enter
+// inserts a newline so multi-line text can be pasted.
+func (i *Input) SetPasteMode(on bool) {
+	i.pasteMode = on
+	i.setPlaceholder()
+}
+
+// PasteMode reports whether Enter inserts a newline instead of sending.
+func (i Input) PasteMode() bool {
+	return i.pasteMode
+}
+
+// SetSearchMode marks the input as a search prompt rather than a message.
+func (i *Input) SetSearchMode(on bool) {
+	i.searchMode = on
+	i.setPlaceholder()
+}
+
+func (i *Input) setPlaceholder() {
+	switch {
+	case i.searchMode:
+		i.textarea.Placeholder = searchPlaceholder
+	case i.pasteMode:
+		i.textarea.Placeholder = pastePlaceholder
+	default:
+		i.textarea.Placeholder = defaultPlaceholder
+	}
+}
@@ -1,11 +1,18 @@
 package components
 
 import (
+	"fmt"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/glamour"
+	gansi "github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
 )
 
 type Role string
@@ -14,64 +21,407 @@ const (
 	RoleUser      Role = "user"
 	RoleAssistant Role = "assistant"
 	RoleSystem    Role = "system"
+	RoleError     Role = "error"
+
+	// RoleDeveloper is OpenAI's successor to the system role; it renders
+	// like a system message
+	RoleDeveloper Role = "developer"
+
+	// RoleNote is a status line for the user, such as "(empty response)";
+	// it renders like a system message but is never sent to the model
+	RoleNote Role = "note"
 )
 
 type Message struct {
 	Role      Role
 	Content   string
+	Images    []string // data or https URLs sent with the message
+	Reasoning string   // a reasoning model's thinking before its answer
+	Cancelled bool     // the reply was stopped before it finished
 	Timestamp time.Time
 }
 
+// messageStore holds the conversation behind a lock, so a stream can grow
+// the last message from a goroutine while the view renders it. Copies of
+// Messages share one store.
+type messageStore struct {
+	mu    sync.RWMutex
+	items []Message
+}
+
 type Messages struct {
-	items    []Message
-	renderer *glamour.TermRenderer
-	width    int
+	store        *messageStore
+	renderer     *glamour.TermRenderer
+	width        int
+	groupContext bool
+	spacing      int
+	plainCode    bool   // syntax highlighting of code blocks is off
+	theme        string // "dark" or "light"; anything else follows the terminal
+	wordWrap     int    // caps the markdown wrap width; 0 wraps at the full width
+
+	showTimestamps bool
+	timestampColor lipgloss.Color
+	showReasoning  bool // reasoning is shown in full rather than summarized
+
+	// Search matches are marked in the margin; current is the one in focus
+	matches map[int]bool
+	current int
 }
 
-func NewMessages(width int) Messages {
-	r, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(width),
-	)
+// DefaultSpacing is the number of blank lines between rendered messages
+const DefaultSpacing = 1
 
+// MaxSpacing caps the configurable gap between messages
+const MaxSpacing = 2
+
+func NewMessages(width int) Messages {
 	return Messages{
-		items:    []Message{},
-		renderer: r,
+		store:    &messageStore{items: []Message{}},
+		renderer: newRenderer(width, "", false),
 		width:    width,
+		spacing:  DefaultSpacing,
+	}
+}
+
+// autoStyle picks the markdown style for the terminal the way glamour's
+// auto style does; tests replace it to force a colored style
+var autoStyle = func() gansi.StyleConfig {
+	switch {
+	case !term.IsTerminal(int(os.Stdout.Fd())):
+		return styles.NoTTYStyleConfig
+	case lipgloss.HasDarkBackground():
+		return styles.DarkStyleConfig
+	default:
+		return styles.LightStyleConfig
+	}
+}
+
+// newRenderer builds the markdown renderer for theme. With plainCode set,
+// code blocks keep the style's base color but skip per-token syntax coloring.
+func newRenderer(width int, theme string, plainCode bool) *glamour.TermRenderer {
+	var style gansi.StyleConfig
+	switch theme {
+	case styles.DarkStyle:
+		style = styles.DarkStyleConfig
+	case styles.LightStyle:
+		style = styles.LightStyleConfig
+	default:
+		style = autoStyle()
+	}
+	if plainCode {
+		style.CodeBlock.Chroma = nil
+		style.CodeBlock.Theme = ""
 	}
+
+	r, _ := glamour.NewTermRenderer(
+		glamour.WithStyles(style),
+		glamour.WithWordWrap(width),
+	)
+	return r
 }
 
 func (m *Messages) Add(role Role, content string) {
-	m.items = append(m.items, Message{
+	m.AddWithImages(role, content, nil)
+}
+
+// AddWithImages adds a message that carries images for vision models.
+func (m *Messages) AddWithImages(role Role, content string, images []string) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	m.store.items = append(m.store.items, Message{
 		Role:      role,
 		Content:   content,
+		Images:    images,
 		Timestamp: time.Now(),
 	})
 }
 
+// SetLastContent replaces the content of the most recent message.
+func (m *Messages) SetLastContent(content string) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if n := len(m.store.items); n > 0 {
+		m.store.items[n-1].Content = content
+	}
+}
+
+// AppendToLast adds content to the end of the most recent message. It does
+// nothing when there are no messages.
+func (m *Messages) AppendToLast(content string) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if n := len(m.store.items); n > 0 {
+		m.store.items[n-1].Content += content
+	}
+}
+
+// MarkLastCancelled flags the most recent message as a reply cut short. It
+// does nothing when there are no messages.
+func (m *Messages) MarkLastCancelled() {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if n := len(m.store.items); n > 0 {
+		m.store.items[n-1].Cancelled = true
+	}
+}
+
+// AppendReasoning adds content to the reasoning of the most recent message.
+// It does nothing when there are no messages.
+func (m *Messages) AppendReasoning(content string) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if n := len(m.store.items); n > 0 {
+		m.store.items[n-1].Reasoning += content
+	}
+}
+
+// PopAssistant removes the last message if it is an assistant reply and
+// reports whether it did.
+func (m *Messages) PopAssistant() bool {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	n := len(m.store.items)
+	if n == 0 || m.store.items[n-1].Role != RoleAssistant {
+		return false
+	}
+	m.store.items = m.store.items[:n-1]
+	return true
+}
+
+// Items returns a copy of the messages in order.
+func (m Messages) Items() []Message {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+	items := make([]Message, len(m.store.items))
+	copy(items, m.store.items)
+	return items
+}
+
+// LastContent returns the raw content of the most recent message with the
+// given role, and false if there is none.
+func (m Messages) LastContent(role Role) (string, bool) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+	for i := len(m.store.items) - 1; i >= 0; i-- {
+		if m.store.items[i].Role == role {
+			return m.store.items[i].Content, true
+		}
+	}
+	return "", false
+}
+
 func (m *Messages) Clear() {
-	m.items = []Message{}
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	m.store.items = []Message{}
 }
 
 func (m *Messages) Count() int {
-	return len(m.items)
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+	return len(m.store.items)
+}
+
+// SetGroupContext controls whether runs of context messages are rendered
+// under the user turn that follows them.
+func (m *Messages) SetGroupContext(group bool) {
+	m.groupContext = group
+}
+
+// SetSpacing sets how many blank lines separate messages, clamped to
+// 0..MaxSpacing.
+func (m *Messages) SetSpacing(lines int) {
+	m.spacing = min(max(lines, 0), MaxSpacing)
 }
 
 func (m Messages) Render() string {
-	var output strings.Builder
+	out, _ := m.RenderWithOffsets()
+	return out
+}
+
+// RenderWithOffsets renders like Render and also returns, for each message,
+// the line its block starts on, so the view can scroll to it.
+func (m Messages) RenderWithOffsets() (string, []int) {
+	items := m.Items()
+	var blocks []string
+	owner := make([]int, len(items)) // block index of each message
+
+	for i := 0; i < len(items); i++ {
+		msg := items[i]
+
+		if m.groupContext && msg.Role == RoleSystem {
+			end := i
+			for end < len(items) && items[end].Role == RoleSystem {
+				end++
+			}
+			if end < len(items) && items[end].Role == RoleUser {
+				for j := i; j <= end; j++ {
+					owner[j] = len(blocks)
+				}
+				blocks = append(blocks, m.markMatches(m.renderGroupedTurn(items[end], items[i:end]), i, end))
+				i = end
+				continue
+			}
+		}
+
+		owner[i] = len(blocks)
+		blocks = append(blocks, m.markMatches(m.renderMessage(msg), i, i))
+	}
+
+	out, starts := joinBlocks(blocks, m.spacing)
+	offsets := make([]int, len(items))
+	for i, b := range owner {
+		offsets[i] = starts[b]
+	}
+	return out, offsets
+}
+
+// joinBlocks trims the blank lines renderers leave around each block and
+// joins them with exactly spacing blank lines in between. It also returns
+// the line each block starts on.
+func joinBlocks(blocks []string, spacing int) (string, []int) {
+	sep := "\n" + strings.Repeat("\n", spacing)
 
-	for _, msg := range m.items {
-		switch msg.Role {
-		case RoleUser:
-			output.WriteString(m.renderUserMessage(msg))
-		case RoleAssistant:
-			output.WriteString(m.renderAssistantMessage(msg))
-		case RoleSystem:
-			output.WriteString(m.renderSystemMessage(msg))
+	var output strings.Builder
+	starts := make([]int, len(blocks))
+	line := 0
+	for i, block := range blocks {
+		block = trimBlankLines(block)
+		if block == "" {
+			starts[i] = line
+			continue
+		}
+		if output.Len() > 0 {
+			output.WriteString(sep)
+			line += 1 + spacing
 		}
+		starts[i] = line
+		output.WriteString(block)
+		line += strings.Count(block, "\n")
+	}
+	if output.Len() > 0 {
 		output.WriteString("\n")
 	}
 
+	return output.String(), starts
+}
+
+// Search returns the indices of messages whose content contains query,
+// ignoring case. An empty query matches nothing.
+func (m Messages) Search(query string) []int {
+	query = strings.ToLower(query)
+	if query == "" {
+		return nil
+	}
+
+	var matches []int
+	for i, msg := range m.Items() {
+		if strings.Contains(strings.ToLower(msg.Content), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// SetMatches marks the messages at the given indices as search matches,
+// with current drawn as the focused one. Nil clears the marks.
+func (m *Messages) SetMatches(matches []int, current int) {
+	m.matches = nil
+	if len(matches) > 0 {
+		m.matches = make(map[int]bool, len(matches))
+		for _, i := range matches {
+			m.matches[i] = true
+		}
+	}
+	m.current = current
+}
+
+// markMatches draws a bar beside a block holding messages first..last when
+// one of them matches the search.
+func (m Messages) markMatches(block string, first, last int) string {
+	color := lipgloss.Color("")
+	for i := first; i <= last; i++ {
+		switch {
+		case m.matches[i] && i == m.current:
+			color = lipgloss.Color("#FFD700")
+		case m.matches[i] && color == "":
+			color = lipgloss.Color("#7D56F4")
+		}
+	}
+	if color == "" {
+		return block
+	}
+
+	bar := lipgloss.NewStyle().Foreground(color).Render("▌")
+	lines := strings.Split(trimBlankLines(block), "\n")
+	for i, line := range lines {
+		lines[i] = bar + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// trimBlankLines drops leading and trailing lines that render as blank,
+// including lines holding only padding or escape codes.
+func trimBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	start, end := 0, len(lines)
+	for start < end && isBlankLine(lines[start]) {
+		start++
+	}
+	for end > start && isBlankLine(lines[end-1]) {
+		end--
+	}
+	return strings.Join(lines[start:end], "\n")
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(ansi.Strip(line)) == ""
+}
+
+func (m Messages) renderMessage(msg Message) string {
+	switch msg.Role {
+	case RoleUser:
+		return m.renderUserMessage(msg)
+	case RoleAssistant:
+		return m.renderAssistantMessage(msg)
+	case RoleSystem, RoleDeveloper, RoleNote:
+		return m.renderSystemMessage(msg)
+	case RoleError:
+		return m.renderErrorMessage(msg)
+	default:
+		return m.renderOtherMessage(msg)
+	}
+}
+
+// renderGroupedTurn renders a user message with the context messages that
+// preceded it nested underneath.
+func (m Messages) renderGroupedTurn(user Message, context []Message) string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		PaddingLeft(2)
+
+	groupStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).
+		BorderForeground(lipgloss.Color("#626262")).
+		MarginLeft(2).
+		PaddingLeft(1)
+
+	var output strings.Builder
+	output.WriteString(m.renderUserMessage(user))
+
+	label := "context"
+	if len(context) > 1 {
+		label = fmt.Sprintf("context (%d)", len(context))
+	}
+	output.WriteString(labelStyle.Render("┊ "+label) + "\n")
+
+	for _, msg := range context {
+		output.WriteString(groupStyle.Render(msg.Content) + "\n")
+	}
+
 	return output.String()
 }
 
@@ -84,8 +434,12 @@ func (m Messages) renderUserMessage(msg Message) string {
 		Foreground(lipgloss.Color("#FAFAFA")).
 		PaddingLeft(2)
 
-	header := headerStyle.Render("You")
-	content := contentStyle.Render(msg.Content)
+	header := headerStyle.Render("You") + m.timestamp(msg)
+	text := msg.Content
+	if n := len(msg.Images); n > 0 {
+		text += fmt.Sprintf("\n[%d image(s) attached]", n)
+	}
+	content := contentStyle.Render(text)
 
 	return header + "\n" + content + "\n"
 }
@@ -95,17 +449,58 @@ func (m Messages) renderAssistantMessage(msg Message) string {
 		Bold(true).
 		Foreground(lipgloss.Color("#00D4AA"))
 
-	header := headerStyle.Render("Assistant")
+	header := headerStyle.Render("Assistant") + m.timestamp(msg)
+	if msg.Reasoning != "" {
+		header += "\n" + m.renderReasoning(msg.Reasoning)
+	}
+
+	// Render markdown, closing any fence a streaming reply has left open.
+	// Tables are laid out separately so word wrap can't split their cells.
+	var parts []string
+	for _, segment := range splitTables(balanceFences(msg.Content)) {
+		if segment.table {
+			parts = append(parts, renderTable(segment.text))
+			continue
+		}
+		rendered, err := m.renderer.Render(segment.text)
+		if err != nil {
+			rendered = segment.text
+		}
+		if rendered = trimBlankLines(rendered); rendered != "" {
+			parts = append(parts, rendered)
+		}
+	}
+	if msg.Cancelled {
+		parts = append(parts, m.renderSystemMessage(Message{Content: "(cancelled)"}))
+	}
+	return header + "\n" + strings.Join(parts, "\n\n") + "\n"
+}
+
+// renderReasoning shows a reply's reasoning dimmed: one summary line, or
+// the full text when reasoning is expanded
+func (m Messages) renderReasoning(reasoning string) string {
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
+		PaddingLeft(2)
 
-	// Render markdown
-	rendered, err := m.renderer.Render(msg.Content)
-	if err != nil {
-		rendered = msg.Content
+	words := len(strings.Fields(reasoning))
+	if !m.showReasoning {
+		return style.Render(fmt.Sprintf("▸ Reasoning (%d words) • Ctrl+T to expand", words))
 	}
-	// Trim extra newlines from glamour
-	rendered = strings.TrimSpace(rendered)
+	body := style.Width(max(m.wrapWidth(), 20)).Render(strings.TrimSpace(reasoning))
+	return style.Render("▾ Reasoning") + "\n" + body
+}
+
+// SetShowReasoning chooses between the full reasoning of replies and a
+// one-line summary
+func (m *Messages) SetShowReasoning(show bool) {
+	m.showReasoning = show
+}
 
-	return header + "\n" + rendered + "\n"
+// ShowReasoning reports whether reasoning is shown in full
+func (m Messages) ShowReasoning() bool {
+	return m.showReasoning
 }
 
 func (m Messages) renderSystemMessage(msg Message) string {
@@ -117,10 +512,77 @@ func (m Messages) renderSystemMessage(msg Message) string {
 	return style.Render(msg.Content) + "\n"
 }
 
+// renderOtherMessage shows a message with a role the UI has no style for,
+// such as "tool", under its role name.
+func (m Messages) renderOtherMessage(msg Message) string {
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#626262"))
+
+	contentStyle := lipgloss.NewStyle().
+		PaddingLeft(2)
+
+	role := string(msg.Role)
+	if role == "" {
+		role = "message"
+	}
+	return headerStyle.Render(role) + "\n" + contentStyle.Render(msg.Content) + "\n"
+}
+
+func (m Messages) renderErrorMessage(msg Message) string {
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B6B")).
+		PaddingLeft(2)
+
+	return style.Render(msg.Content) + "\n"
+}
+
+// SetTimestamps turns the time shown beside message headers on or off,
+// drawn in color.
+func (m *Messages) SetTimestamps(show bool, color lipgloss.Color) {
+	m.showTimestamps = show
+	m.timestampColor = color
+}
+
+// timestamp returns the " [15:04]" suffix for msg's header, or "" when
+// timestamps are off
+func (m Messages) timestamp(msg Message) string {
+	if !m.showTimestamps || msg.Timestamp.IsZero() {
+		return ""
+	}
+	style := lipgloss.NewStyle().Foreground(m.timestampColor)
+	return " " + style.Render(msg.Timestamp.Format("[15:04]"))
+}
+
 func (m *Messages) SetWidth(w int) {
 	m.width = w
-	m.renderer, _ = glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(w),
-	)
+	m.renderer = newRenderer(m.wrapWidth(), m.theme, m.plainCode)
+}
+
+// SetSyntaxHighlighting turns syntax coloring of code blocks on or off.
+func (m *Messages) SetSyntaxHighlighting(enabled bool) {
+	m.plainCode = !enabled
+	m.renderer = newRenderer(m.wrapWidth(), m.theme, m.plainCode)
+}
+
+// SetWordWrap caps the column markdown wraps at, however wide the
+// terminal. Zero or less wraps at the full width.
+func (m *Messages) SetWordWrap(cols int) {
+	m.wordWrap = max(cols, 0)
+	m.renderer = newRenderer(m.wrapWidth(), m.theme, m.plainCode)
+}
+
+// wrapWidth is the width markdown is wrapped to
+func (m Messages) wrapWidth() int {
+	if m.wordWrap > 0 {
+		return min(m.width, m.wordWrap)
+	}
+	return m.width
+}
+
+// SetTheme selects the dark or light markdown style. Other names follow
+// the terminal background.
+func (m *Messages) SetTheme(theme string) {
+	m.theme = strings.ToLower(theme)
+	m.renderer = newRenderer(m.wrapWidth(), m.theme, m.plainCode)
 }
@@ -1,8 +1,13 @@
 package components
 
 import (
+	"slices"
 	"strings"
 	"testing"
+
+	gansi "github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestNewMessages(t *testing.T) {
@@ -64,9 +69,343 @@ func TestMessagesRenderAssistant(t *testing.T) {
 	}
 }
 
+func TestMessagesRenderTimestamps(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.Add(RoleUser, "hello")
+	msgs.Add(RoleAssistant, "hi")
+	items := msgs.Items()
+
+	if rendered := ansi.Strip(msgs.Render()); strings.Contains(rendered, "[") {
+		t.Errorf("timestamps are off by default, got:\n%s", rendered)
+	}
+
+	msgs.SetTimestamps(true, "#626262")
+	rendered := ansi.Strip(msgs.Render())
+	for _, item := range items {
+		if stamp := item.Timestamp.Format("[15:04]"); !strings.Contains(rendered, stamp) {
+			t.Errorf("want %s beside the %s header, got:\n%s", stamp, item.Role, rendered)
+		}
+	}
+}
+
+func TestMessagesRenderCustomRoles(t *testing.T) {
+	msgs := NewMessages(80)
+
+	msgs.Add(RoleDeveloper, "Follow the style guide")
+	msgs.Add(Role("tool"), "exit status 0")
+	rendered := msgs.Render()
+
+	if !strings.Contains(rendered, "Follow the style guide") {
+		t.Errorf("developer message should be rendered, got:\n%s", rendered)
+	}
+	if !strings.Contains(rendered, "tool") || !strings.Contains(rendered, "exit status 0") {
+		t.Errorf("unknown role should render under its name, got:\n%s", rendered)
+	}
+}
+
+func TestMessagesSyntaxHighlightingToggle(t *testing.T) {
+	// Force a colored style; tests have no terminal so auto would pick notty
+	orig := autoStyle
+	autoStyle = func() gansi.StyleConfig { return styles.DarkStyleConfig }
+	t.Cleanup(func() { autoStyle = orig })
+
+	code := "```go\nfunc main() { return }\n```"
+	render := func(highlight bool) string {
+		msgs := NewMessages(80)
+		msgs.SetSyntaxHighlighting(highlight)
+		msgs.Add(RoleAssistant, code)
+		return msgs.Render()
+	}
+
+	if out := render(true); strings.Contains(out, "func main() { return }") {
+		t.Errorf("highlighted code should be split by color codes, got %q", out)
+	}
+	out := render(false)
+	if !strings.Contains(out, "func main() { return }") {
+		t.Errorf("code should render without per-token styling when highlighting is off, got %q", out)
+	}
+}
+
+func TestMessagesWordWrapCapsWidth(t *testing.T) {
+	prose := strings.Repeat("lorem ipsum dolor sit amet ", 30)
+
+	widest := func(wrap int) int {
+		msgs := NewMessages(80)
+		msgs.SetWidth(200)
+		msgs.SetWordWrap(wrap)
+		msgs.Add(RoleAssistant, prose)
+		w := 0
+		for _, line := range strings.Split(msgs.Render(), "\n") {
+			w = max(w, ansi.StringWidth(line))
+		}
+		return w
+	}
+
+	if w := widest(40); w > 40 {
+		t.Errorf("lines should wrap at the configured 40 columns, widest is %d", w)
+	}
+	if w := widest(0); w <= 40 {
+		t.Errorf("without a cap lines should use the terminal width, widest is %d", w)
+	}
+}
+
 func TestMessagesSetWidth(t *testing.T) {
 	msgs := NewMessages(80)
 
 	// Should not panic
 	msgs.SetWidth(120)
 }
+
+func TestMessagesRenderGroupedContext(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.Add(RoleSystem, "diff context")
+	msgs.Add(RoleSystem, "log context")
+	msgs.Add(RoleUser, "review this")
+
+	ungrouped := msgs.Render()
+	if strings.Index(ungrouped, "diff context") > strings.Index(ungrouped, "You") {
+		t.Error("without grouping, context should render before the user turn")
+	}
+
+	msgs.SetGroupContext(true)
+	grouped := msgs.Render()
+
+	you := strings.Index(grouped, "You")
+	diff := strings.Index(grouped, "diff context")
+	log := strings.Index(grouped, "log context")
+	if you == -1 || diff == -1 || log == -1 {
+		t.Fatalf("grouped render missing content:\n%s", grouped)
+	}
+	if !(you < diff && diff < log) {
+		t.Errorf("context should be grouped under the user turn in order:\n%s", grouped)
+	}
+	if !strings.Contains(grouped, "context (2)") {
+		t.Errorf("grouped render should label the context group:\n%s", grouped)
+	}
+}
+
+func TestMessagesRenderTrailingContextUngrouped(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.SetGroupContext(true)
+	msgs.Add(RoleUser, "hello")
+	msgs.Add(RoleSystem, "trailing context")
+
+	rendered := msgs.Render()
+	if !strings.Contains(rendered, "trailing context") {
+		t.Error("context without a following user turn should still render")
+	}
+	if strings.Contains(rendered, "┊ context") {
+		t.Error("trailing context should not be grouped")
+	}
+}
+
+// blankRuns returns the length of each run of blank lines between content.
+func blankRuns(rendered string) []int {
+	var runs []int
+	run := 0
+	for _, line := range strings.Split(strings.TrimRight(rendered, "\n"), "\n") {
+		if isBlankLine(line) {
+			run++
+			continue
+		}
+		if run > 0 {
+			runs = append(runs, run)
+			run = 0
+		}
+	}
+	return runs
+}
+
+func TestMessagesRenderSpacing(t *testing.T) {
+	for _, spacing := range []int{0, 1, 2} {
+		msgs := NewMessages(80)
+		msgs.SetSpacing(spacing)
+		msgs.Add(RoleUser, "first")
+		msgs.Add(RoleAssistant, "second\n\n\n")
+		msgs.Add(RoleSystem, "third\n")
+		msgs.Add(RoleError, "fourth")
+		msgs.Add(RoleAssistant, "fifth")
+
+		rendered := msgs.Render()
+		if strings.HasPrefix(rendered, "\n") || strings.HasSuffix(rendered, "\n\n") {
+			t.Errorf("spacing %d: output should not start or end with blank lines: %q", spacing, rendered)
+		}
+
+		runs := blankRuns(rendered)
+		if spacing == 0 {
+			if len(runs) != 0 {
+				t.Errorf("spacing 0: expected no blank lines, got runs %v", runs)
+			}
+			continue
+		}
+		if len(runs) != 4 {
+			t.Fatalf("spacing %d: expected 4 gaps between 5 messages, got %v", spacing, runs)
+		}
+		for _, r := range runs {
+			if r != spacing {
+				t.Errorf("spacing %d: inconsistent gaps %v", spacing, runs)
+				break
+			}
+		}
+	}
+}
+
+func TestMessagesSetSpacingClamps(t *testing.T) {
+	msgs := NewMessages(80)
+
+	msgs.SetSpacing(5)
+	if msgs.spacing != MaxSpacing {
+		t.Errorf("expected spacing clamped to %d, got %d", MaxSpacing, msgs.spacing)
+	}
+	msgs.SetSpacing(-1)
+	if msgs.spacing != 0 {
+		t.Errorf("expected spacing clamped to 0, got %d", msgs.spacing)
+	}
+}
+
+func TestMessagesLastContent(t *testing.T) {
+	m := NewMessages(80)
+	if _, ok := m.LastContent(RoleAssistant); ok {
+		t.Fatal("empty history should have no assistant message")
+	}
+
+	m.Add(RoleUser, "first question")
+	m.Add(RoleAssistant, "first **answer**")
+	m.Add(RoleUser, "second question")
+	m.Add(RoleAssistant, "```go\nfmt.Println(1)\n```")
+	m.Add(RoleError, "boom")
+
+	got, ok := m.LastContent(RoleAssistant)
+	if !ok {
+		t.Fatal("expected an assistant message")
+	}
+	if got != "```go\nfmt.Println(1)\n```" {
+		t.Errorf("expected raw markdown of the latest reply, got %q", got)
+	}
+}
+
+func TestMessagesPopAssistant(t *testing.T) {
+	m := NewMessages(80)
+	if m.PopAssistant() {
+		t.Error("empty history has nothing to pop")
+	}
+
+	m.Add(RoleUser, "question")
+	m.Add(RoleAssistant, "answer")
+	if !m.PopAssistant() {
+		t.Fatal("expected the trailing assistant reply to be popped")
+	}
+	items := m.Items()
+	if len(items) != 1 || items[0].Content != "question" {
+		t.Errorf("expected only the user turn to remain, got %+v", items)
+	}
+
+	if m.PopAssistant() {
+		t.Error("should not pop when the last message is from the user")
+	}
+	if m.Count() != 1 {
+		t.Errorf("user turn should be kept, got %d messages", m.Count())
+	}
+}
+
+func TestMessagesSearch(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.Add(RoleUser, "How do I parse JSON?")
+	msgs.Add(RoleAssistant, "Use encoding/json.")
+	msgs.Add(RoleUser, "And YAML?")
+	msgs.Add(RoleAssistant, "Try a json-compatible YAML library.")
+
+	if got := msgs.Search("json"); !slices.Equal(got, []int{0, 1, 3}) {
+		t.Errorf("Search(json) = %v, want [0 1 3]", got)
+	}
+	if got := msgs.Search("yaml"); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("Search(yaml) = %v, want [2 3]", got)
+	}
+	if got := msgs.Search("toml"); got != nil {
+		t.Errorf("Search(toml) = %v, want none", got)
+	}
+	if got := msgs.Search(""); got != nil {
+		t.Errorf("an empty query should match nothing, got %v", got)
+	}
+}
+
+func TestMessagesRenderWithOffsets(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.Add(RoleUser, "first")
+	msgs.Add(RoleSystem, "second\nspans two lines")
+	msgs.Add(RoleUser, "third")
+
+	out, offsets := msgs.RenderWithOffsets()
+	lines := strings.Split(ansi.Strip(out), "\n")
+	for i, want := range []string{"You", "second", "You"} {
+		if got := strings.TrimSpace(lines[offsets[i]]); got != want {
+			t.Errorf("message %d should start at line %d with %q, got %q", i, offsets[i], want, got)
+		}
+	}
+
+	msgs.SetMatches([]int{1}, 1)
+	marked := ansi.Strip(msgs.Render())
+	if !strings.Contains(marked, "▌  second") || strings.Contains(marked, "▌You") {
+		t.Errorf("only the matching message should be marked:\n%s", marked)
+	}
+}
+
+func TestMessagesAppendToLast(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.Add(RoleUser, "question")
+	msgs.Add(RoleAssistant, "Hel")
+	msgs.AppendToLast("lo, ")
+	msgs.AppendToLast("world")
+
+	items := msgs.Items()
+	if len(items) != 2 {
+		t.Fatalf("appending should not add messages, got %d", len(items))
+	}
+	if items[1].Content != "Hello, world" {
+		t.Errorf("last content = %q, want %q", items[1].Content, "Hello, world")
+	}
+	if items[0].Content != "question" {
+		t.Errorf("earlier messages should be untouched, got %q", items[0].Content)
+	}
+}
+
+func TestMessagesAppendToLastEmpty(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.AppendToLast("orphan")
+
+	if msgs.Count() != 0 {
+		t.Errorf("appending with no messages should do nothing, got %+v", msgs.Items())
+	}
+}
+
+// Run with -race: a stream appending from a goroutine must not race with
+// rendering
+func TestMessagesConcurrentAppendAndRender(t *testing.T) {
+	msgs := NewMessages(80)
+	msgs.Add(RoleUser, "hello")
+	msgs.Add(RoleAssistant, "")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			msgs.AppendToLast("word ")
+		}
+		msgs.Add(RoleSystem, "finished")
+	}()
+
+	for {
+		select {
+		case <-done:
+			items := msgs.Items()
+			if len(items) != 3 || items[1].Content != strings.Repeat("word ", 100) {
+				t.Errorf("appends were lost: %+v", items)
+			}
+			return
+		default:
+			msgs.Render()
+			msgs.Search("word")
+			msgs.Count()
+		}
+	}
+}
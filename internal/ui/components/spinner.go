@@ -0,0 +1,126 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultThinkingText is shown next to the spinner while waiting
+const DefaultThinkingText = "Thinking…"
+
+// spinnerStyles maps config names to bubbles spinner frame sets
+var spinnerStyles = map[string]spinner.Spinner{
+	"line":    spinner.Line,
+	"dot":     spinner.Dot,
+	"minidot": spinner.MiniDot,
+	"jump":    spinner.Jump,
+	"pulse":   spinner.Pulse,
+	"points":  spinner.Points,
+	"globe":   spinner.Globe,
+	"moon":    spinner.Moon,
+	"meter":   spinner.Meter,
+	"hellip":  spinner.Ellipsis,
+}
+
+// Spinner shows activity while waiting on the assistant. It only keeps
+// ticking while active, so stopping it ends the tick loop.
+type Spinner struct {
+	spinner spinner.Model
+	frames  spinner.Spinner
+	text    string
+	active  bool
+}
+
+func NewSpinner() Spinner {
+	s := Spinner{frames: spinner.Dot, text: DefaultThinkingText}
+	s.spinner = s.newModel()
+	return s
+}
+
+func (s Spinner) newModel() spinner.Model {
+	return spinner.New(
+		spinner.WithSpinner(s.frames),
+		spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("#00D4AA"))),
+	)
+}
+
+// SetStyle selects a named frame set. An empty name keeps the current one.
+func (s *Spinner) SetStyle(name string) error {
+	if name == "" {
+		return nil
+	}
+	frames, ok := spinnerStyles[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown spinner %q (available: %s)", name, strings.Join(SpinnerStyles(), ", "))
+	}
+	s.frames = frames
+	s.spinner = s.newModel()
+	return nil
+}
+
+// SetText sets the label shown next to the spinner. An empty text restores
+// the default.
+func (s *Spinner) SetText(text string) {
+	if text == "" {
+		text = DefaultThinkingText
+	}
+	s.text = text
+}
+
+// SpinnerStyles returns the available spinner names, sorted
+func SpinnerStyles() []string {
+	names := make([]string, 0, len(spinnerStyles))
+	for name := range spinnerStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start activates the spinner and returns the command that begins ticking.
+func (s *Spinner) Start() tea.Cmd {
+	if s.active {
+		return nil
+	}
+	// A fresh model gets a new ID, so ticks left over from a previous run
+	// are rejected instead of doubling the animation speed
+	s.spinner = s.newModel()
+	s.active = true
+	return s.spinner.Tick
+}
+
+// Stop deactivates the spinner; pending ticks are dropped.
+func (s *Spinner) Stop() {
+	s.active = false
+}
+
+func (s Spinner) Active() bool {
+	return s.active
+}
+
+func (s Spinner) Update(msg tea.Msg) (Spinner, tea.Cmd) {
+	if !s.active {
+		return s, nil
+	}
+	var cmd tea.Cmd
+	s.spinner, cmd = s.spinner.Update(msg)
+	return s, cmd
+}
+
+// Tick returns a tick message for the current spinner.
+func (s Spinner) Tick() tea.Msg {
+	return s.spinner.Tick()
+}
+
+// View renders the current frame followed by the thinking text.
+func (s Spinner) View() string {
+	if !s.active {
+		return ""
+	}
+	return s.spinner.View() + " " + s.text
+}
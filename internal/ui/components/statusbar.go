@@ -3,8 +3,10 @@ package components
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kbesada/flux-code-cli/internal/ai"
 	"github.com/kbesada/flux-code-cli/internal/git"
 )
 
@@ -13,6 +15,14 @@ type StatusBar struct {
 	gitStatus string
 	model     string
 	provider  string
+	timing    string
+	progress  string
+	usage     string
+	estimate  string
+	warning   string
+	scroll    string
+	notice    string
+	pasteMode bool
 }
 
 func NewStatusBar() StatusBar {
@@ -40,10 +50,36 @@ func (s StatusBar) View() string {
 		Foreground(lipgloss.Color("#7D56F4"))
 
 	left := leftStyle.Render("Ctrl+C quit • Enter send • /help commands")
+	if s.pasteMode {
+		left = gitStyle.Render("PASTE") + leftStyle.Render(" Enter newline • Ctrl+S send • /paste to leave")
+	}
+
+	warnStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFB86C"))
 
 	var right string
+	if s.notice != "" {
+		right = gitStyle.Render(s.notice) + " │ "
+	}
+	if s.scroll != "" {
+		right += gitStyle.Render(s.scroll) + " │ "
+	}
+	if s.warning != "" {
+		right += warnStyle.Render("⚠ "+s.warning) + " │ "
+	}
+	if s.estimate != "" {
+		right += leftStyle.Render(s.estimate) + " │ "
+	}
+	if s.usage != "" {
+		right += leftStyle.Render(s.usage) + " │ "
+	}
+	if s.progress != "" {
+		right += leftStyle.Render(s.progress) + " │ "
+	} else if s.timing != "" {
+		right += leftStyle.Render(s.timing) + " │ "
+	}
 	if s.gitStatus != "" {
-		right = gitStyle.Render(" "+s.gitStatus) + " │ "
+		right += gitStyle.Render(" "+s.gitStatus) + " │ "
 	}
 	right += modelStyle.Render(s.model)
 
@@ -64,3 +100,58 @@ func (s *StatusBar) SetModel(provider, model string) {
 	s.provider = provider
 	s.model = fmt.Sprintf("%s/%s", provider, model)
 }
+
+// SetTiming shows time-to-first-token and total time for the last response.
+// A zero ttft means no content arrived.
+func (s *StatusBar) SetTiming(ttft, total time.Duration) {
+	if ttft > 0 {
+		s.timing = fmt.Sprintf("TTFT %.1fs · total %.1fs", ttft.Seconds(), total.Seconds())
+	} else {
+		s.timing = fmt.Sprintf("total %.1fs", total.Seconds())
+	}
+}
+
+// SetProgress shows how much of an in-flight response has arrived and how
+// long it has been running. It replaces the timing until cleared.
+func (s *StatusBar) SetProgress(chars int, elapsed time.Duration) {
+	s.progress = fmt.Sprintf("↓ %d chars · %ds", chars, int(elapsed.Seconds()))
+}
+
+// ClearProgress hides the in-flight indicator.
+func (s *StatusBar) ClearProgress() {
+	s.progress = ""
+}
+
+// SetUsage shows token counts reported for the last response.
+func (s *StatusBar) SetUsage(u ai.Usage) {
+	s.usage = fmt.Sprintf("%d↑ %d↓ %d tok", u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+}
+
+// SetEstimate shows the approximate prompt size of the message being typed;
+// zero hides it.
+func (s *StatusBar) SetEstimate(tokens int) {
+	s.estimate = ""
+	if tokens > 0 {
+		s.estimate = fmt.Sprintf("~%d tok to send", tokens)
+	}
+}
+
+// SetWarning shows a short notice about the last response; empty clears it.
+func (s *StatusBar) SetWarning(warning string) {
+	s.warning = warning
+}
+
+// SetScroll shows a scroll position indicator; empty hides it.
+func (s *StatusBar) SetScroll(indicator string) {
+	s.scroll = indicator
+}
+
+// SetPasteMode shows the paste mode key hints in place of the defaults.
+func (s *StatusBar) SetPasteMode(on bool) {
+	s.pasteMode = on
+}
+
+// SetNotice shows a brief confirmation such as "copied"; empty clears it.
+func (s *StatusBar) SetNotice(notice string) {
+	s.notice = notice
+}
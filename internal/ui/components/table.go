@@ -0,0 +1,173 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// markdownSegment is a run of message lines that is either a markdown
+// table or other markdown
+type markdownSegment struct {
+	text  string
+	table bool
+}
+
+// splitTables separates markdown tables, outside code fences, from the rest
+// of content. A table is a header row followed by a delimiter row such as
+// |---|:--:|, and runs until a line without a pipe.
+func splitTables(content string) []markdownSegment {
+	lines := strings.Split(content, "\n")
+
+	var segments []markdownSegment
+	var text []string
+	var open string // marker of the open code fence
+	flush := func() {
+		if len(text) > 0 {
+			segments = append(segments, markdownSegment{text: strings.Join(text, "\n")})
+			text = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if marker, info := fenceMarker(line); marker != "" {
+			switch {
+			case open == "":
+				open = marker
+			case info == "" && marker[0] == open[0] && len(marker) >= len(open):
+				open = ""
+			}
+		}
+
+		if open != "" || i+1 >= len(lines) || !strings.Contains(line, "|") || !isDelimiterRow(lines[i+1]) {
+			text = append(text, line)
+			continue
+		}
+
+		end := i + 2
+		for end < len(lines) && strings.Contains(lines[end], "|") && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		flush()
+		segments = append(segments, markdownSegment{text: strings.Join(lines[i:end], "\n"), table: true})
+		i = end - 1
+	}
+	flush()
+	return segments
+}
+
+// isDelimiterRow reports whether line is a table's header delimiter, such
+// as "| --- | :---: |"
+func isDelimiterRow(line string) bool {
+	cells := tableCells(line)
+	if len(cells) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		cell = strings.TrimSuffix(strings.TrimPrefix(cell, ":"), ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// escapedPipe stands in for a pipe escaped as \| inside a cell, so it
+// can't be mistaken for a column border
+const escapedPipe = "¦"
+
+// tableCells splits a table row on pipes not escaped with a backslash,
+// dropping the optional outer pipes and trimming each cell. Escaped pipes
+// become escapedPipe.
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	if strings.TrimSpace(row) == "" {
+		return nil
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteString(escapedPipe)
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderTable lays out a markdown table with aligned columns. Rows are
+// never wrapped, so cells stay whole; tables wider than the view scroll
+// sideways instead.
+func renderTable(table string) string {
+	lines := strings.Split(table, "\n")
+	header := tableCells(lines[0])
+	delimiters := tableCells(lines[1])
+	rows := [][]string{header}
+	for _, line := range lines[2:] {
+		rows = append(rows, tableCells(line))
+	}
+
+	cols := len(header)
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for c := 0; c < cols && c < len(row); c++ {
+			widths[c] = max(widths[c], lipgloss.Width(row[c]), 3)
+		}
+	}
+
+	align := make([]lipgloss.Position, cols)
+	for c := range align {
+		align[c] = lipgloss.Left
+		if c < len(delimiters) {
+			d := delimiters[c]
+			switch {
+			case strings.HasPrefix(d, ":") && strings.HasSuffix(d, ":"):
+				align[c] = lipgloss.Center
+			case strings.HasSuffix(d, ":"):
+				align[c] = lipgloss.Right
+			}
+		}
+	}
+
+	pipe := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render("|")
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	formatRow := func(row []string, style *lipgloss.Style) string {
+		var b strings.Builder
+		b.WriteString("  " + pipe)
+		for c := range cols {
+			var cell string
+			if c < len(row) {
+				cell = row[c]
+			}
+			cell = lipgloss.PlaceHorizontal(widths[c], align[c], cell)
+			if style != nil {
+				cell = style.Render(cell)
+			}
+			b.WriteString(" " + cell + " " + pipe)
+		}
+		return b.String()
+	}
+
+	out := []string{formatRow(header, &headerStyle)}
+	rule := make([]string, cols)
+	for c := range rule {
+		rule[c] = strings.Repeat("-", widths[c])
+	}
+	out = append(out, formatRow(rule, nil))
+	for _, row := range rows[1:] {
+		out = append(out, formatRow(row, nil))
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,54 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderTableKeepsRowsWhole(t *testing.T) {
+	msgs := NewMessages(30)
+	msgs.Add(RoleAssistant, "Options:\n\n"+
+		"| Name | Description | Default |\n"+
+		"|------|-------------|--------:|\n"+
+		"| timeout | How long to wait for the server | 30s |\n"+
+		"| retries | Attempts \\| tries | 3 |\n\n"+
+		"Done.")
+
+	out := ansi.Strip(msgs.Render())
+	var rows []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "|") {
+			rows = append(rows, strings.TrimSpace(line))
+		}
+	}
+
+	want := []string{
+		"| Name    | Description                     | Default |",
+		"| ------- | ------------------------------- | ------- |",
+		"| timeout | How long to wait for the server |     30s |",
+		"| retries | Attempts ¦ tries                |       3 |",
+	}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Errorf("table rows =\n%s\nwant\n%s\nfull output:\n%s", strings.Join(rows, "\n"), strings.Join(want, "\n"), out)
+	}
+	if !strings.Contains(out, "Options:") || !strings.Contains(out, "Done.") {
+		t.Errorf("text around the table should still render:\n%s", out)
+	}
+}
+
+func TestSplitTablesIgnoresCodeBlocks(t *testing.T) {
+	content := "```\n| a | b |\n|---|---|\n```\n\n| a | b |\n|---|---|\n| 1 | 2 |\nafter"
+
+	segments := splitTables(content)
+	if len(segments) != 3 || segments[0].table || !segments[1].table || segments[2].table {
+		t.Fatalf("expected text, table, text segments, got %+v", segments)
+	}
+	if segments[1].text != "| a | b |\n|---|---|\n| 1 | 2 |" {
+		t.Errorf("unexpected table segment %q", segments[1].text)
+	}
+	if segments[2].text != "after" {
+		t.Errorf("unexpected trailing segment %q", segments[2].text)
+	}
+}
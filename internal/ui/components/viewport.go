@@ -5,6 +5,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// horizontalStep is how many columns Left and Right scroll the view
+const horizontalStep = 8
+
 type Viewport struct {
 	viewport viewport.Model
 	ready    bool
@@ -13,6 +16,8 @@ type Viewport struct {
 func NewViewport(width, height int) Viewport {
 	vp := viewport.New(width, height)
 	vp.YPosition = 0
+	// Wide content such as tables isn't wrapped; Left/Right scroll it
+	vp.SetHorizontalStep(horizontalStep)
 
 	return Viewport{
 		viewport: vp,
@@ -43,10 +48,49 @@ func (v *Viewport) GotoBottom() {
 	v.viewport.GotoBottom()
 }
 
+func (v *Viewport) GotoTop() {
+	v.viewport.GotoTop()
+}
+
+func (v *Viewport) PageUp() {
+	v.viewport.PageUp()
+}
+
+func (v *Viewport) PageDown() {
+	v.viewport.PageDown()
+}
+
+func (v *Viewport) HalfPageUp() {
+	v.viewport.HalfPageUp()
+}
+
+func (v *Viewport) HalfPageDown() {
+	v.viewport.HalfPageDown()
+}
+
+// Scrollable reports whether the content is taller than the viewport
+func (v Viewport) Scrollable() bool {
+	return v.viewport.TotalLineCount() > v.viewport.Height
+}
+
+func (v Viewport) AtBottom() bool {
+	return v.viewport.AtBottom()
+}
+
 func (v Viewport) ScrollPercent() float64 {
 	return v.viewport.ScrollPercent()
 }
 
+func (v Viewport) YOffset() int {
+	return v.viewport.YOffset
+}
+
+// ScrollTo shows line at the top of the view, or as close as the content
+// allows
+func (v *Viewport) ScrollTo(line int) {
+	v.viewport.SetYOffset(line)
+}
+
 func (v Viewport) Ready() bool {
 	return v.ready
 }
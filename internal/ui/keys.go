@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
+)
+
+// keyAction is a UI action that can be bound to keys in the config
+type keyAction int
+
+const (
+	keyNone keyAction = iota
+	keySend
+	keyQuit
+	keyClear
+	keyScrollUp
+	keyScrollDown
+)
+
+// keyMap finds the action bound to a key, by the key's Bubble Tea name
+type keyMap map[string]keyAction
+
+// newKeyMap builds the key map from the keybindings config. An empty config
+// gives the defaults. A key bound to two actions keeps its first, and send
+// and quit fall back to their defaults when left with no keys, so the UI
+// can't be locked.
+func newKeyMap(cfg config.KeybindingsConfig) (keyMap, []error) {
+	defaults := config.DefaultKeybindings()
+	if cfg.Send == nil && cfg.Quit == nil && cfg.Clear == nil && cfg.ScrollUp == nil && cfg.ScrollDown == nil {
+		cfg = defaults
+	}
+
+	var errs []error
+	if len(cfg.Send) == 0 {
+		if cfg.Send != nil {
+			errs = append(errs, fmt.Errorf("keybindings.send has no keys; using %s", strings.Join(defaults.Send, ", ")))
+		}
+		cfg.Send = defaults.Send
+	}
+	if len(cfg.Quit) == 0 {
+		if cfg.Quit != nil {
+			errs = append(errs, fmt.Errorf("keybindings.quit has no keys; using %s", strings.Join(defaults.Quit, ", ")))
+		}
+		cfg.Quit = defaults.Quit
+	}
+
+	keys := keyMap{}
+	bind := func(name string, action keyAction, bound []string) {
+		for _, key := range bound {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if other, ok := keys[key]; ok && other != action {
+				errs = append(errs, fmt.Errorf("keybindings.%s: %s is already bound", name, key))
+				continue
+			}
+			keys[key] = action
+		}
+	}
+	bind("send", keySend, cfg.Send)
+	bind("quit", keyQuit, cfg.Quit)
+	bind("clear", keyClear, cfg.Clear)
+	bind("scroll_up", keyScrollUp, cfg.ScrollUp)
+	bind("scroll_down", keyScrollDown, cfg.ScrollDown)
+	return keys, errs
+}
+
+// action returns what key does. Keys that type a single character only act
+// while the viewport has focus, so binding "k" doesn't get in the way of
+// typing.
+func (k keyMap) action(key string, focus focusArea) keyAction {
+	action := k[key]
+	if action != keyNone && focus == focusInput && utf8.RuneCountInString(key) == 1 {
+		return keyNone
+	}
+	return action
+}
@@ -1,43 +1,198 @@
 package ui
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/lipgloss"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/kbesada/flux-code-cli/internal/ai"
 	"github.com/kbesada/flux-code-cli/internal/commands"
+	"github.com/kbesada/flux-code-cli/internal/config"
+	"github.com/kbesada/flux-code-cli/internal/session"
 	"github.com/kbesada/flux-code-cli/internal/ui/components"
+	"github.com/kbesada/flux-code-cli/internal/usage"
 )
 
-const exitPromptTimeout = 2 * time.Second
+const (
+	exitPromptTimeout = 2 * time.Second
+	noticeTimeout     = 2 * time.Second
+	progressInterval  = time.Second
+	// titleTimeout bounds asking the model for a title when quitting
+	titleTimeout = 5 * time.Second
+)
+
+// Default minimum terminal size; below it the layout is replaced by a notice
+const (
+	DefaultMinWidth  = 60
+	DefaultMinHeight = 10
+)
+
+// ActiveFileEnv names the variable editors can set to the file being edited.
+// File commands run without arguments target it.
+const ActiveFileEnv = "FLUX_ACTIVE_FILE"
 
 type clearExitPromptMsg struct{}
 
+type clearNoticeMsg struct{}
+
+// writeClipboard copies text to the system clipboard; tests replace it
+var writeClipboard = clipboard.WriteAll
+
+// focusArea identifies which pane receives key input
+type focusArea int
+
+const (
+	focusInput focusArea = iota
+	focusViewport
+)
+
 type Model struct {
 	// Components
 	input     components.Input
 	viewport  components.Viewport
 	messages  components.Messages
 	statusBar components.StatusBar
+	spinner   components.Spinner
+	commands  *commands.Registry
+
+	// AI
+	cfg           *config.Config
+	registry      *ai.Registry
+	client        ai.Client
+	system        ai.SystemPrompt
+	defaultPrompt string
+	personas      map[string]string
+	persona       string
+	postProc      ai.Pipeline
+	temperature   *float32 // set with /temp; nil uses the client's default
+	showTokens    bool
+	maxContext    int
+	usageLog      *usage.Recorder
+	saveSession   func(title string, turns []session.Turn) error // called with the chat on quit
+	sessionErr    error                                          // why the chat was not saved on quit
+	titleCancel   context.CancelFunc                             // stops waiting for the model's title on quit
+	retryEmpty    bool
+	retriedEmpty  bool
+	streaming     bool
+	streamBuf     string
+	replyStarted  bool // the streaming reply's message has been added
+	streamChars   int  // characters received on the current stream
+	streamID      int
+	stream        <-chan ai.StreamEvent
+	cancel        context.CancelFunc
+
+	// Timing for the current/last response
+	now          func() time.Time
+	streamStart  time.Time
+	firstChunkAt time.Time
+	ttft         time.Duration
+	totalTime    time.Duration
 
 	// State
+	currentFile   string
+	pendingImages []string // attached with /image; sent with the next message
+	lastCommand   string
+	contextFrom   int // messages before this index no longer send their attachments
+	completions   []string
+	completionIdx int
+
+	// Command running in the background; see runAsync
+	command       string // its command line
+	commandID     int    // bumped per command so stale results are dropped
+	commandCancel context.CancelFunc
+
+	// Message search; see search.go
+	searching    bool   // the input holds a search query
+	searchDraft  string // message being typed, set aside while searching
+	searchQuery  string
+	matches      []int // indices of the messages matching searchQuery
+	matchIdx     int
+	messageLines []int // line each message starts on in the viewport
+
+	keys           keyMap
+	focus          focusArea
 	width          int
 	height         int
+	minWidth       int
+	minHeight      int
 	ready          bool
 	quitting       bool
 	lastCtrlC      time.Time
 	showExitPrompt bool
 }
 
-func NewModel() Model {
-	return Model{
+func NewModel(cfg *config.Config, client ai.Client) Model {
+	m := Model{
 		input:     components.NewInput(),
+		viewport:  components.NewViewport(80, 20),
 		messages:  components.NewMessages(80),
 		statusBar: components.NewStatusBar(),
+		spinner:   components.NewSpinner(),
+		commands:  commands.NewRegistry(),
+		cfg:       cfg,
+		registry:  ai.NewRegistry(),
+		minWidth:  DefaultMinWidth,
+		minHeight: DefaultMinHeight,
+		client:    client,
+		now:       time.Now,
+	}
+	m.saveSession = saveLastSession
+	m.keys, _ = newKeyMap(config.KeybindingsConfig{})
+	m.SetCurrentFile(os.Getenv(ActiveFileEnv))
+	m.SetSystemPrompt("")
+
+	if client != nil {
+		m.statusBar.SetModel(client.Provider(), client.Model())
+	}
+
+	if cfg != nil {
+		m.SetSystemPrompt(cfg.System.Prompt)
+		m.defaultPrompt = cfg.System.Prompt
+		m.personas = cfg.Personas
+		m.maxContext = cfg.Context.MaxContextTokens
+		for _, err := range m.applyUIConfig(cfg.UI) {
+			m.messages.Add(components.RoleError, "Config: "+err.Error())
+		}
+		m.commands.Disable(cfg.Commands.Disabled...)
+		var errs []error
+		m.keys, errs = newKeyMap(cfg.Keybindings)
+		for _, err := range errs {
+			m.messages.Add(components.RoleError, "Config: "+err.Error())
+		}
+		for _, err := range m.addAliases(cfg.Commands.Aliases) {
+			m.messages.Add(components.RoleError, "Config: "+err.Error())
+		}
+
+		if cfg.Usage.Log {
+			if path, err := usagePath(cfg.Usage.Path); err != nil {
+				m.messages.Add(components.RoleError, "Config: usage log: "+err.Error())
+			} else {
+				m.usageLog = usage.NewRecorder(path)
+			}
+		}
+
+		for _, warning := range cfg.Warnings {
+			m.messages.Add(components.RoleError, "Config warning: "+warning)
+		}
+
+		pipeline, err := ai.NewPipeline(cfg.System.PostProcess)
+		if err != nil {
+			m.messages.Add(components.RoleError, "Config: "+err.Error())
+		}
+		m.postProc = pipeline
 	}
+
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
@@ -50,59 +205,141 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		if m.quitting {
+			// Saving on quit: any key stops waiting for the model's title
+			if m.titleCancel != nil {
+				m.titleCancel()
+			}
+			return m, nil
+		}
+		key := msg.String()
+		switch m.keys.action(key, m.focus) {
+		case keyQuit:
+			if m.streaming {
+				m.cancelStream()
+				return m, nil
+			}
+			if m.commandCancel != nil {
+				m.cancelCommand()
+				return m, nil
+			}
 			now := time.Now()
 			if m.showExitPrompt && now.Sub(m.lastCtrlC) < exitPromptTimeout {
-				m.quitting = true
-				return m, tea.Quit
+				return m, m.quit()
 			}
 			m.lastCtrlC = now
 			m.showExitPrompt = true
 			return m, tea.Tick(exitPromptTimeout, func(t time.Time) tea.Msg {
 				return clearExitPromptMsg{}
 			})
-		case "enter":
-			value := m.input.Value()
-			if value == "" {
-				return m, nil
+		case keySend:
+			if m.searching {
+				return m.runSearch()
 			}
+			// In paste mode the textarea gets Enter and inserts a newline
+			if m.focus != focusInput || (key == "enter" && m.input.PasteMode()) {
+				break
+			}
+			return m.submit()
+		case keyClear:
+			m.showExitPrompt = false
+			m.clearChat()
+			return m, nil
+		case keyScrollUp:
+			m.scroll("pgup")
+			return m, nil
+		case keyScrollDown:
+			m.scroll("pgdown")
+			return m, nil
+		}
 
-			// Check for commands
-			if commands.IsCommand(value) {
-				m.input.Reset()
-				cmd := commands.Parse(value)
-				result := commands.ExecuteGitCommand(cmd)
-
-				if result.Error != nil {
-					m.messages.Add(components.RoleSystem, "Error: "+result.Error.Error())
-				} else if result.Output != "" {
-					if result.AddToChat {
-						m.messages.Add(components.RoleUser, value)
-						m.messages.Add(components.RoleSystem, result.Output)
-					} else {
-						m.messages.Add(components.RoleSystem, result.Output)
-					}
+		switch key {
+		case "esc":
+			// Cancels a search prompt, then a stream, then a background
+			// command, then search results; it always dismisses the exit
+			// prompt
+			m.showExitPrompt = false
+			switch {
+			case m.searching:
+				m.endSearchPrompt()
+			case m.streaming:
+				m.cancelStream()
+			case m.commandCancel != nil:
+				m.cancelCommand()
+			case len(m.matches) > 0:
+				m.clearSearch()
+			}
+			return m, nil
+		case "ctrl+f":
+			m.showExitPrompt = false
+			return m, m.startSearch()
+		case "/":
+			if m.focus == focusViewport {
+				return m, m.startSearch()
+			}
+		case "n", "N":
+			if m.focus == focusViewport && len(m.matches) > 0 {
+				if msg.String() == "n" {
+					m.nextMatch(1)
+				} else {
+					m.nextMatch(-1)
 				}
-				m.viewport.SetContent(m.messages.Render())
-				m.viewport.GotoBottom()
-				m.showExitPrompt = false
 				return m, nil
 			}
-
-			// Send regular message
-			m.messages.Add(components.RoleUser, value)
-			m.input.Reset()
-			m.viewport.SetContent(m.messages.Render())
-			m.viewport.GotoBottom()
+		case "tab":
 			m.showExitPrompt = false
+			if m.focus == focusInput && isCommandPrefix(m.input.Value()) {
+				m.completeCommand()
+				return m, nil
+			}
+			return m, m.toggleFocus()
+		case "ctrl+y":
+			m.showExitPrompt = false
+			return m, m.copyLastReply()
+		case "ctrl+t":
+			m.showExitPrompt = false
+			m.messages.SetShowReasoning(!m.messages.ShowReasoning())
+			m.renderMessages()
+			return m, nil
+		case "ctrl+u", "ctrl+d":
+			m.scroll(key)
 			return m, nil
+		case "home", "end":
+			// While typing, Home/End move the cursor unless the input is empty
+			if m.focus == focusViewport || m.input.Value() == "" {
+				m.scroll(key)
+				return m, nil
+			}
+		case "enter":
+			// Enter finds even when it isn't bound to send
+			if m.searching {
+				return m.runSearch()
+			}
 
 		default:
 			m.showExitPrompt = false
 		}
+		m.completions = nil
 	case clearExitPromptMsg:
 		m.showExitPrompt = false
+	case clearNoticeMsg:
+		m.statusBar.SetNotice("")
+	case sessionSavedMsg:
+		m.sessionErr = msg.err
+		m.titleCancel = nil
+		return m, tea.Quit
+	case commandDoneMsg:
+		return m.handleCommandDone(msg)
+	case streamStartedMsg:
+		return m.handleStreamStarted(msg)
+	case streamEventMsg:
+		return m.handleStreamEvent(msg)
+	case progressTickMsg:
+		return m.handleProgressTick(msg)
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -110,13 +347,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 	}
 
-	// Update components
+	// Update components; keys only go to the focused pane
+	_, isKey := msg.(tea.KeyMsg)
 	var cmd tea.Cmd
-	m.input, cmd = m.input.Update(msg)
-	cmds = append(cmds, cmd)
+	if !isKey || m.focus == focusInput {
+		m.input, cmd = m.input.Update(msg)
+		cmds = append(cmds, cmd)
+		if isKey && !m.searching {
+			m.updateEstimate()
+		}
+	}
 
-	m.viewport, cmd = m.viewport.Update(msg)
-	cmds = append(cmds, cmd)
+	if !isKey || m.focus == focusViewport {
+		m.viewport, cmd = m.viewport.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
 	// In a real app we might want to update status bar on certain events
 	// m.statusBar.Update()
@@ -124,24 +369,346 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// submit runs the input as a command or sends it as a chat message
+func (m Model) submit() (tea.Model, tea.Cmd) {
+	value := m.input.Value()
+	if value == "" {
+		return m, nil
+	}
+	m.statusBar.SetEstimate(0)
+
+	// Check for commands
+	if commands.IsCommand(value) {
+		m.input.Reset()
+		m.showExitPrompt = false
+		return m.runCommand(value)
+	}
+
+	if m.streaming {
+		return m, nil
+	}
+
+	// Send regular message
+	m.messages.AddWithImages(components.RoleUser, value, m.pendingImages)
+	m.pendingImages = nil
+	m.input.Reset()
+	m.showExitPrompt = false
+	if m.client == nil {
+		m.addError(fmt.Errorf("no AI client configured; check the provider settings in your config"))
+		return m, nil
+	}
+	m.retriedEmpty = false
+	cmd := m.startStream()
+	m.refreshViewport()
+	return m, tea.Batch(cmd, m.spinner.Start())
+}
+
 func (m Model) View() string {
 	if m.quitting {
+		if m.titleCancel != nil {
+			return "Saving session... (press any key to skip the title)\n"
+		}
 		return "Goodbye!\n"
 	}
 	if !m.ready {
 		return "Initializing..."
 	}
+	if m.tooSmall() {
+		notice := fmt.Sprintf("Terminal too small (need at least %dx%d)", m.minWidth, m.minHeight)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+			StatusBarStyle.Render(notice))
+	}
 
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		m.renderHeader(),
 		m.viewport.View(),
-		m.input.View(),
+		m.renderInput(),
 		m.renderStatusBar(),
 	)
 }
 
+// SetRegistry sets the registry used to build clients when switching
+// providers or reloading the config.
+func (m *Model) SetRegistry(r *ai.Registry) {
+	m.registry = r
+}
+
+// RebuildClient replaces the AI client with one built from cfg for its
+// active provider. The conversation is kept; on error nothing changes.
+func (m *Model) RebuildClient(cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("no config loaded")
+	}
+	client, err := m.registry.BuildActive(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	m.cfg = cfg
+	m.client = client
+	m.statusBar.SetModel(client.Provider(), client.Model())
+	return nil
+}
+
+// applyUIConfig applies the ui section of the config. Invalid values fall
+// back to their defaults and are returned as errors.
+func (m *Model) applyUIConfig(ui config.UIConfig) []error {
+	var errs []error
+
+	m.showTokens = ui.ShowTokens
+	m.retryEmpty = ui.EmptyResponse == config.EmptyResponseRetry
+	m.messages.SetGroupContext(ui.GroupContext)
+	m.messages.SetSyntaxHighlighting(ui.SyntaxHighlighting)
+	theme, err := ThemeByName(ui.Theme)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	ApplyTheme(theme)
+	m.messages.SetTheme(ui.Theme)
+	m.messages.SetTimestamps(ui.ShowTimestamps, MutedColor)
+	m.messages.SetSpacing(ui.MessageSpacing)
+	m.messages.SetWordWrap(ui.WordWrap)
+	m.minWidth, m.minHeight = DefaultMinWidth, DefaultMinHeight
+	if ui.MinWidth > 0 {
+		m.minWidth = ui.MinWidth
+	}
+	if ui.MinHeight > 0 {
+		m.minHeight = ui.MinHeight
+	}
+	m.spinner.SetText(ui.ThinkingText)
+	if err := m.spinner.SetStyle(ui.Spinner); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// systemPromptPart names the configured or persona prompt among the system
+// contributions. NewModel reserves it first so it leads the request.
+const systemPromptPart = "prompt"
+
+// SetSystemPrompt replaces the system prompt sent with subsequent requests.
+// An empty prompt disables it.
+func (m *Model) SetSystemPrompt(prompt string) {
+	m.system.Set(systemPromptPart, prompt)
+}
+
+// SetSystemContext adds, replaces, or (with empty content) removes a named
+// system contribution such as project or git context. Contributions are sent
+// after the system prompt in the order they were first set.
+func (m *Model) SetSystemContext(name, content string) {
+	m.system.Set(name, content)
+}
+
+// sessionSavedMsg reports the save started by quit when the session is
+// titled by the model
+type sessionSavedMsg struct {
+	err error
+}
+
+// quit saves the chat as the last session and exits. An empty chat leaves
+// the previous save in place. A failed save is kept for SessionError, since
+// the screen is about to close. The session is titled from its first
+// message, or with session.ai_titles set by the model; that request runs off
+// the event loop and exits once the session is saved.
+func (m *Model) quit() tea.Cmd {
+	m.quitting = true
+	if m.commandCancel != nil {
+		m.commandCancel()
+	}
+	turns := m.transcript()
+	if len(turns) == 0 || m.saveSession == nil {
+		return tea.Quit
+	}
+	first := session.FirstMessage(turns)
+	if m.cfg == nil || !m.cfg.Session.AITitles || m.client == nil {
+		m.sessionErr = m.saveSession(session.DeriveTitle(first), turns)
+		return tea.Quit
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), titleTimeout)
+	m.titleCancel = cancel
+	client, save := m.client, m.saveSession
+	return func() tea.Msg {
+		defer cancel()
+		return sessionSavedMsg{err: save(session.GenerateTitle(ctx, client, first), turns)}
+	}
+}
+
+// clearChat empties the conversation, along with its attachments and any
+// pending images, so the next message starts fresh. The system prompt is
+// kept.
+func (m *Model) clearChat() {
+	if m.streaming {
+		m.finishStream()
+	}
+	m.messages.Clear()
+	m.contextFrom = 0
+	m.pendingImages = nil
+	m.searchQuery = ""
+	m.matches = nil
+	m.matchIdx = 0
+	m.refreshViewport()
+}
+
+// saveLastSession saves turns under session.LastSession in the sessions dir
+func saveLastSession(title string, turns []session.Turn) error {
+	dir, err := session.Dir()
+	if err != nil {
+		return err
+	}
+	_, err = session.Save(dir, session.LastSession, title, turns, time.Now())
+	return err
+}
+
+// SessionError reports why the chat could not be saved on quit, if it
+// could not
+func (m Model) SessionError() error {
+	return m.sessionErr
+}
+
+// usagePath returns the configured usage log path, or the default in the data dir
+func usagePath(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, usage.DefaultFile), nil
+}
+
+// SetCurrentFile sets the implicit target for file commands run without
+// arguments. An empty path clears it.
+func (m *Model) SetCurrentFile(path string) {
+	m.currentFile = strings.TrimSpace(path)
+}
+
+// CurrentFile returns the implicit target for file commands.
+func (m Model) CurrentFile() string {
+	return m.currentFile
+}
+
+// copyLastReply copies the raw markdown of the latest assistant message to
+// the clipboard and flashes a confirmation in the status bar
+func (m *Model) copyLastReply() tea.Cmd {
+	content, ok := m.messages.LastContent(components.RoleAssistant)
+	if !ok {
+		m.statusBar.SetNotice("nothing to copy")
+	} else if err := writeClipboard(content); err != nil {
+		m.addError(fmt.Errorf("copy to clipboard: %w", err))
+		return nil
+	} else {
+		m.statusBar.SetNotice("copied")
+	}
+	return tea.Tick(noticeTimeout, func(t time.Time) tea.Msg {
+		return clearNoticeMsg{}
+	})
+}
+
+// isCommandPrefix reports whether value is a slash command name still being typed
+func isCommandPrefix(value string) bool {
+	return strings.HasPrefix(value, "/") && !strings.ContainsAny(value, " \t\n")
+}
+
+// completeCommand completes the slash command in the input. Repeated Tab
+// presses cycle through the candidates; a unique match gets a trailing space.
+func (m *Model) completeCommand() {
+	value := m.input.Value()
+	if len(m.completions) > 1 && value == "/"+m.completions[m.completionIdx] {
+		m.completionIdx = (m.completionIdx + 1) % len(m.completions)
+		m.input.SetValue("/" + m.completions[m.completionIdx])
+		return
+	}
+
+	m.completions = m.commands.Complete(value)
+	m.completionIdx = 0
+	switch len(m.completions) {
+	case 0:
+		return
+	case 1:
+		m.input.SetValue("/" + m.completions[0] + " ")
+		m.completions = nil
+	default:
+		m.input.SetValue("/" + m.completions[0])
+	}
+}
+
+// renderCompletions lists completion candidates, highlighting the current one
+func (m Model) renderCompletions() string {
+	names := make([]string, len(m.completions))
+	for i, name := range m.completions {
+		names[i] = "/" + name
+		if i == m.completionIdx {
+			names[i] = ExitPromptStyle.Render(names[i])
+		}
+	}
+	return strings.Join(names, "  ")
+}
+
+// scroll moves the message viewport for a scroll key, whichever pane has focus
+func (m *Model) scroll(key string) {
+	switch key {
+	case "pgup":
+		m.viewport.PageUp()
+	case "pgdown":
+		m.viewport.PageDown()
+	case "ctrl+u":
+		m.viewport.HalfPageUp()
+	case "ctrl+d":
+		m.viewport.HalfPageDown()
+	case "home":
+		m.viewport.GotoTop()
+	case "end":
+		m.viewport.GotoBottom()
+	}
+}
+
+// scrollIndicator shows how far through the transcript the view is, or
+// nothing when following the latest message
+func (m Model) scrollIndicator() string {
+	if !m.viewport.Scrollable() || m.viewport.AtBottom() {
+		return ""
+	}
+	return fmt.Sprintf("↕ %d%%", int(m.viewport.ScrollPercent()*100))
+}
+
+// toggleFocus moves key focus between the input and the message viewport
+func (m *Model) toggleFocus() tea.Cmd {
+	if m.focus == focusInput {
+		m.focus = focusViewport
+		m.input.Blur()
+		return nil
+	}
+	m.focus = focusInput
+	return m.input.Focus()
+}
+
+// updateEstimate shows roughly how many tokens sending the input would use:
+// the conversation as it would be sent plus the input itself. It is hidden
+// while the input is empty, is a command, or token display is off.
+func (m *Model) updateEstimate() {
+	value := m.input.Value()
+	if !m.showTokens || strings.TrimSpace(value) == "" || commands.IsCommand(value) {
+		m.statusBar.SetEstimate(0)
+		return
+	}
+	history := append(m.buildHistory(), ai.ChatMessage{Role: string(components.RoleUser), Content: value})
+	m.statusBar.SetEstimate(ai.CountTokens(history, nil))
+}
+
+// tooSmall reports whether the terminal is below the minimum usable size
+func (m Model) tooSmall() bool {
+	return m.width < m.minWidth || m.height < m.minHeight
+}
+
 func (m *Model) handleResize() {
+	if m.tooSmall() {
+		// View shows a notice instead; lay out again once there is room
+		return
+	}
+
 	headerHeight := 1
 	statusHeight := 1
 	inputHeight := 5
@@ -152,9 +719,9 @@ func (m *Model) handleResize() {
 	}
 
 	m.viewport.SetSize(m.width, viewportHeight)
-	m.input.SetWidth(m.width - 4)
+	m.input.SetWidth(m.width - InputStyle.GetHorizontalFrameSize())
 	m.messages.SetWidth(m.width - 4)
-	m.viewport.SetContent(m.messages.Render())
+	m.renderMessages()
 	m.statusBar.SetWidth(m.width)
 }
 
@@ -163,9 +730,38 @@ func (m Model) renderHeader() string {
 	return HeaderStyle.Width(m.width).Render(title)
 }
 
+func (m Model) renderInput() string {
+	style := InputStyle
+	if m.focus != focusInput {
+		style = style.BorderForeground(MutedColor)
+	}
+	return style.Render(m.input.View())
+}
+
 func (m Model) renderStatusBar() string {
 	if m.showExitPrompt {
 		return StatusBarStyle.Width(m.width).Render("Press Ctrl+C again to exit")
 	}
-	return m.statusBar.View()
+	if len(m.completions) > 1 {
+		return StatusBarStyle.Width(m.width).Render(m.renderCompletions())
+	}
+	if m.focus == focusViewport {
+		status := ExitPromptStyle.Render("SCROLL") + "  ↑↓←→ scroll • / search • Tab back to input"
+		if len(m.matches) > 0 {
+			status = ExitPromptStyle.Render("SEARCH") + "  " + m.searchStatus()
+		}
+		if indicator := m.scrollIndicator(); indicator != "" {
+			status += "  " + indicator
+		}
+		return StatusBarStyle.Width(m.width).Render(status)
+	}
+	if m.spinner.Active() {
+		return StatusBarStyle.Width(m.width).Render(m.spinner.View() + " (Esc to cancel)")
+	}
+	if m.commandCancel != nil {
+		return StatusBarStyle.Width(m.width).Render("Running " + m.command + " (Esc to cancel)")
+	}
+	statusBar := m.statusBar
+	statusBar.SetScroll(m.scrollIndicator())
+	return statusBar.View()
 }
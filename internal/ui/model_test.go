@@ -1,15 +1,32 @@
 package ui
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+	"github.com/kbesada/flux-code-cli/internal/commands"
+	"github.com/kbesada/flux-code-cli/internal/config"
+	"github.com/kbesada/flux-code-cli/internal/session"
+	"github.com/kbesada/flux-code-cli/internal/ui/components"
+	"github.com/kbesada/flux-code-cli/internal/usage"
 )
 
 func TestNewModel(t *testing.T) {
-	m := NewModel()
+	m := NewModel(nil, nil)
 
 	if m.ready {
 		t.Error("NewModel should not be ready initially")
@@ -26,7 +43,7 @@ func TestNewModel(t *testing.T) {
 }
 
 func TestModelInit(t *testing.T) {
-	m := NewModel()
+	m := NewModel(nil, nil)
 	cmd := m.Init()
 
 	// Init now returns textarea.Blink command
@@ -38,7 +55,7 @@ func TestModelInit(t *testing.T) {
 func TestModelUpdateQuitKeys(t *testing.T) {
 	// Test that single Ctrl+C shows exit prompt
 	t.Run("single_ctrl+c_shows_prompt", func(t *testing.T) {
-		m := NewModel()
+		m := NewModel(nil, nil)
 		msg := tea.KeyMsg{Type: tea.KeyCtrlC}
 
 		newModel, cmd := m.Update(msg)
@@ -57,7 +74,7 @@ func TestModelUpdateQuitKeys(t *testing.T) {
 
 	// Test that double Ctrl+C quits
 	t.Run("double_ctrl+c_quits", func(t *testing.T) {
-		m := NewModel()
+		m := NewModel(nil, nil)
 		m.showExitPrompt = true
 		m.lastCtrlC = time.Now()
 
@@ -75,7 +92,7 @@ func TestModelUpdateQuitKeys(t *testing.T) {
 
 	// Test that esc/q reset exit prompt
 	t.Run("esc_resets_prompt", func(t *testing.T) {
-		m := NewModel()
+		m := NewModel(nil, nil)
 		m.showExitPrompt = true
 
 		msg := tea.KeyMsg{Type: tea.KeyEsc}
@@ -88,8 +105,180 @@ func TestModelUpdateQuitKeys(t *testing.T) {
 	})
 }
 
+func TestModelQuitSavesSession(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleUser, "hello")
+	m.messages.Add(components.RoleAssistant, "hi there")
+	var saved []session.Turn
+	m.saveSession = func(_ string, turns []session.Turn) error {
+		saved = turns
+		return nil
+	}
+
+	m.showExitPrompt = true
+	m.lastCtrlC = time.Now()
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !newModel.(Model).quitting {
+		t.Fatal("double Ctrl+C should quit")
+	}
+
+	want := []session.Turn{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "hi there"}}
+	if !slices.Equal(saved, want) {
+		t.Errorf("saved %+v, want %+v", saved, want)
+	}
+}
+
+func TestModelQuitTitlesSession(t *testing.T) {
+	client := &fakeClient{reply: "Greeting the assistant"}
+	var title string
+	save := func(got string, _ []session.Turn) error {
+		title = got
+		return nil
+	}
+
+	m := NewModel(&config.Config{}, client)
+	m.messages.Add(components.RoleUser, "hello there")
+	m.saveSession = save
+	m.quit()
+	if title != "hello there" {
+		t.Errorf("title = %q, want it taken from the first message", title)
+	}
+
+	m = NewModel(&config.Config{Session: config.SessionConfig{AITitles: true}}, client)
+	m.messages.Add(components.RoleUser, "hello there")
+	m.saveSession = save
+	title = ""
+	cmd := m.quit()
+	if title != "" {
+		t.Fatal("the model's title should be asked for off the event loop")
+	}
+	if !strings.Contains(m.View(), "Saving session") {
+		t.Errorf("the view should say the session is being saved, got %q", m.View())
+	}
+
+	msg := execCmd(cmd)
+	if _, ok := msg.(sessionSavedMsg); !ok {
+		t.Fatalf("quit should save the session in the background, got %T", msg)
+	}
+	newModel, cmd := m.Update(msg)
+	if title != "Greeting the assistant" {
+		t.Errorf("title = %q, want the model's title with session.ai_titles set", title)
+	}
+	if _, ok := execCmd(cmd).(tea.QuitMsg); !ok || newModel.(Model).titleCancel != nil {
+		t.Error("flux should exit once the session is saved")
+	}
+}
+
+// hangingClient never answers Complete until its context ends
+type hangingClient struct {
+	fakeClient
+}
+
+func (c *hangingClient) Complete(ctx context.Context, req ai.ChatRequest) (ai.ChatResponse, error) {
+	<-ctx.Done()
+	return ai.ChatResponse{}, ctx.Err()
+}
+
+func TestModelQuitKeySkipsTitle(t *testing.T) {
+	var title string
+	m := NewModel(&config.Config{Session: config.SessionConfig{AITitles: true}}, &hangingClient{})
+	m.messages.Add(components.RoleUser, "hello there")
+	m.saveSession = func(got string, _ []session.Turn) error {
+		title = got
+		return nil
+	}
+
+	cmd := m.quit()
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	done := make(chan tea.Msg)
+	go func() { done <- execCmd(cmd) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a key press should stop waiting for the title")
+	}
+	if title != "hello there" {
+		t.Errorf("title = %q, want the first message once the title is skipped", title)
+	}
+}
+
+func TestModelQuitReportsSaveError(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleUser, "hello")
+	m.saveSession = func(string, []session.Turn) error { return errors.New("disk full") }
+
+	m.quit()
+	if err := m.SessionError(); err == nil || err.Error() != "disk full" {
+		t.Errorf("SessionError() = %v, want disk full", err)
+	}
+}
+
+func TestModelQuitCommand(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleUser, "hello")
+	saves := 0
+	m.saveSession = func(string, []session.Turn) error {
+		saves++
+		return nil
+	}
+
+	m, cmd := sendInput(m, "/quit")
+	if !m.quitting {
+		t.Error("/quit should set the quitting state")
+	}
+	if saves != 1 {
+		t.Errorf("/quit should save the session once, saved %d times", saves)
+	}
+	if cmd == nil {
+		t.Fatal("/quit should return a command")
+	}
+	if _, ok := execCmd(cmd).(tea.QuitMsg); !ok {
+		t.Error("/quit should return tea.Quit")
+	}
+}
+
+func TestModelLoadRestoresSavedSession(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleUser, "hello")
+	m.messages.Add(components.RoleAssistant, "hi there")
+	m.quit()
+	if err := m.SessionError(); err != nil {
+		t.Fatalf("saving on quit: %v", err)
+	}
+
+	m = NewModel(nil, nil)
+	m.messages.Add(components.RoleUser, "something else")
+	m, _ = sendInput(m, "/load")
+
+	want := []session.Turn{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "hi there"}}
+	if got := m.transcript(); len(got) < 2 || !slices.Equal(got[:2], want) {
+		t.Errorf("after /load the chat is %+v, want it to start with %+v", got, want)
+	}
+	if history := m.buildHistory(); len(history) < 2 || history[1].Content != "hi there" {
+		t.Errorf("loaded messages should be sent as history, got %+v", history)
+	}
+
+	m, _ = sendInput(m, "/load nothing-here")
+	items := m.messages.Items()
+	if last := items[len(items)-1].Content; !strings.Contains(last, "no saved session named nothing-here") {
+		t.Errorf("/load of a missing session should report an error, got %q", last)
+	}
+}
+
+func TestModelQuitSkipsEmptySession(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.saveSession = func(string, []session.Turn) error {
+		t.Error("an empty chat should not replace the last session")
+		return nil
+	}
+	m.quit()
+}
+
 func TestModelUpdateWindowResize(t *testing.T) {
-	m := NewModel()
+	m := NewModel(nil, nil)
 	msg := tea.WindowSizeMsg{Width: 100, Height: 50}
 
 	newModel, _ := m.Update(msg)
@@ -107,7 +296,7 @@ func TestModelUpdateWindowResize(t *testing.T) {
 }
 
 func TestModelViewNotReady(t *testing.T) {
-	m := NewModel()
+	m := NewModel(nil, nil)
 	view := m.View()
 
 	if view != "Initializing..." {
@@ -116,7 +305,7 @@ func TestModelViewNotReady(t *testing.T) {
 }
 
 func TestModelViewQuitting(t *testing.T) {
-	m := NewModel()
+	m := NewModel(nil, nil)
 	m.quitting = true
 	view := m.View()
 
@@ -125,8 +314,35 @@ func TestModelViewQuitting(t *testing.T) {
 	}
 }
 
+func TestModelViewTooSmall(t *testing.T) {
+	m := NewModel(nil, nil)
+
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 30, Height: 6})
+	m = newModel.(Model)
+	view := m.View()
+	if !strings.Contains(view, "Terminal too small (need at least 60x10)") {
+		t.Errorf("expected too-small notice, got %q", view)
+	}
+	if strings.Contains(view, "Ctrl+C quit") {
+		t.Error("the regular layout should not render when too small")
+	}
+
+	newModel, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = newModel.(Model)
+	if view := m.View(); strings.Contains(view, "too small") || !strings.Contains(view, "flux") {
+		t.Errorf("layout should return after growing, got %q", view)
+	}
+
+	cfg := &config.Config{UI: config.UIConfig{MinWidth: 100, MinHeight: 30}}
+	m = NewModel(cfg, nil)
+	newModel, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	if view := newModel.(Model).View(); !strings.Contains(view, "need at least 100x30") {
+		t.Errorf("configured minimum not applied, got %q", view)
+	}
+}
+
 func TestModelViewReady(t *testing.T) {
-	m := NewModel()
+	m := NewModel(nil, nil)
 	m.ready = true
 	m.width = 80
 	m.height = 24
@@ -141,3 +357,1627 @@ func TestModelViewReady(t *testing.T) {
 		t.Error("View should contain Ctrl+C instructions")
 	}
 }
+
+type fakeClient struct {
+	model  string
+	events []ai.StreamEvent
+	err    error
+	ctx    context.Context
+	req    ai.ChatRequest
+	calls  int
+	reply  string // returned by Complete
+}
+
+func (f *fakeClient) Complete(ctx context.Context, req ai.ChatRequest) (ai.ChatResponse, error) {
+	return ai.ChatResponse{Content: f.reply}, nil
+}
+
+func (f *fakeClient) Stream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamEvent, error) {
+	f.ctx = ctx
+	f.req = req
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	ch := make(chan ai.StreamEvent, len(f.events))
+	for _, e := range f.events {
+		ch <- e
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeClient) ListModels(ctx context.Context) ([]string, error) {
+	return nil, ai.ErrNotSupported
+}
+
+func (f *fakeClient) Model() string {
+	if f.model == "" {
+		return "fake-model"
+	}
+	return f.model
+}
+
+func (f *fakeClient) SetModel(model string) { f.model = model }
+func (f *fakeClient) Provider() string      { return "fake" }
+
+// sendInput types value and presses Enter, returning the resulting model and command.
+func sendInput(m Model, value string) (Model, tea.Cmd) {
+	m.input.SetValue(value)
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return newModel.(Model), cmd
+}
+
+// execCmd runs cmd and returns its message. Batches, including nested ones,
+// are unwrapped to the first message that isn't a spinner tick.
+func execCmd(cmd tea.Cmd) tea.Msg {
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return msg
+	}
+	for _, c := range batch {
+		if c == nil {
+			continue
+		}
+		if msg := execCmd(c); !isSpinnerTick(msg) {
+			return msg
+		}
+	}
+	return nil
+}
+
+func isSpinnerTick(msg tea.Msg) bool {
+	_, ok := msg.(spinner.TickMsg)
+	return ok
+}
+
+// finishCommand runs a background command started by sendInput and applies
+// its result
+func finishCommand(m Model, cmd tea.Cmd) Model {
+	if cmd == nil {
+		return m
+	}
+	msg, ok := execCmd(cmd).(commandDoneMsg)
+	if !ok {
+		return m
+	}
+	newModel, _ := m.Update(msg)
+	return newModel.(Model)
+}
+
+// runStream feeds command results back into the model until the stream settles.
+func runStream(m Model, cmd tea.Cmd) Model {
+	for cmd != nil {
+		msg := execCmd(cmd)
+		switch msg.(type) {
+		case streamStartedMsg, streamEventMsg:
+		default:
+			return m
+		}
+		var newModel tea.Model
+		newModel, cmd = m.Update(msg)
+		m = newModel.(Model)
+	}
+	return m
+}
+
+func TestModelStreamsAssistantResponse(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "Hello"},
+		{Type: ai.StreamEventChunk, Content: ", world"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "hi")
+	if !m.streaming {
+		t.Fatal("model should be streaming after Enter")
+	}
+	m = runStream(m, cmd)
+
+	if m.streaming {
+		t.Error("model should stop streaming after done")
+	}
+	items := m.messages.Items()
+	if len(items) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(items))
+	}
+	if items[1].Role != components.RoleAssistant || items[1].Content != "Hello, world" {
+		t.Errorf("unexpected assistant message: %+v", items[1])
+	}
+	if len(client.req.Messages) != 1 || client.req.Messages[0].Content != "hi" {
+		t.Errorf("unexpected request history: %+v", client.req.Messages)
+	}
+}
+
+func TestModelStreamsReasoningApart(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventReasoning, Content: "Two plus "},
+		{Type: ai.StreamEventReasoning, Content: "two."},
+		{Type: ai.StreamEventChunk, Content: "4"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "2+2?")
+	m = runStream(m, cmd)
+
+	items := m.messages.Items()
+	if len(items) != 2 {
+		t.Fatalf("reasoning and answer should share one message, got %+v", items)
+	}
+	if items[1].Reasoning != "Two plus two." || items[1].Content != "4" {
+		t.Errorf("unexpected assistant message: %+v", items[1])
+	}
+
+	view := m.messages.Render()
+	if !strings.Contains(view, "Reasoning (3 words)") || strings.Contains(view, "Two plus") {
+		t.Errorf("reasoning should be summarized by default, got:\n%s", view)
+	}
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if view := newModel.(Model).messages.Render(); !strings.Contains(view, "Two plus two.") {
+		t.Errorf("Ctrl+T should show the reasoning, got:\n%s", view)
+	}
+}
+
+func TestModelRemappedSendKey(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{{Type: ai.StreamEventDone}}}
+	cfg := &config.Config{Keybindings: config.KeybindingsConfig{
+		Send: []string{"ctrl+j"},
+		Quit: []string{"ctrl+c"},
+	}}
+	m := NewModel(cfg, client)
+
+	m, _ = sendInput(m, "hello")
+	if m.messages.Count() != 0 || client.calls != 0 {
+		t.Fatalf("Enter should no longer send, got %+v", m.messages.Items())
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	m = runStream(newModel.(Model), cmd)
+	items := m.messages.Items()
+	if len(items) == 0 || items[0].Role != components.RoleUser || !strings.Contains(items[0].Content, "hello") {
+		t.Errorf("Ctrl+J should send the message, got %+v", items)
+	}
+	if client.calls != 1 {
+		t.Errorf("expected one request, got %d", client.calls)
+	}
+}
+
+func TestModelLetterBindingsOnlyInViewport(t *testing.T) {
+	cfg := &config.Config{Keybindings: config.DefaultKeybindings()}
+	cfg.Keybindings.Clear = []string{"x"}
+	m := NewModel(cfg, nil)
+	m.messages.Add(components.RoleUser, "keep me")
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = newModel.(Model)
+	if m.messages.Count() != 1 || m.input.Value() != "x" {
+		t.Fatalf("x should type into the input, got input %q and %d messages", m.input.Value(), m.messages.Count())
+	}
+
+	m.focus = focusViewport
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if n := len(newModel.(Model).messages.Items()); n != 0 {
+		t.Errorf("x in the chat view should clear the chat, %d messages left", n)
+	}
+}
+
+func TestNewKeyMapKeepsSendAndQuit(t *testing.T) {
+	keys, errs := newKeyMap(config.KeybindingsConfig{
+		Send:     []string{},
+		ScrollUp: []string{"k", "ctrl+c"},
+	})
+	if len(errs) != 2 {
+		t.Errorf("expected errors for the empty send and the duplicate ctrl+c, got %v", errs)
+	}
+	if keys["enter"] != keySend || keys["ctrl+c"] != keyQuit || keys["k"] != keyScrollUp {
+		t.Errorf("unexpected key map: %v", keys)
+	}
+}
+
+func TestModelTempUsesConfiguredProvider(t *testing.T) {
+	cfg := &config.Config{
+		Provider: "work",
+		Providers: map[string]config.Provider{
+			"work": {BaseURL: "http://localhost:8080/v1", Temperature: 0.3},
+			"fake": {Temperature: 1.5},
+		},
+	}
+	m := NewModel(cfg, &fakeClient{})
+
+	m, _ = sendInput(m, "/temp")
+	items := m.messages.Items()
+	if last := items[len(items)-1].Content; last != "Temperature: 0.3 (from config)" {
+		t.Errorf("/temp should show the configured provider's temperature, got %q", last)
+	}
+}
+
+func TestModelTempCommand(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{{Type: ai.StreamEventDone}}}
+	m := NewModel(nil, client)
+
+	m, _ = sendInput(m, "/temp 0.7")
+	if m.temperature == nil || *m.temperature != 0.7 {
+		t.Fatalf("/temp 0.7 should set the temperature, got %v", m.temperature)
+	}
+
+	m, _ = sendInput(m, "/temp 9")
+	if *m.temperature != 0.7 {
+		t.Errorf("/temp 9 should be rejected, temperature is now %v", *m.temperature)
+	}
+	if last, _ := m.messages.LastContent(components.RoleError); !strings.HasPrefix(last, "Error: temperature must be") {
+		t.Errorf("expected a range error, got %q", last)
+	}
+
+	m, cmd := sendInput(m, "hello")
+	m = runStream(m, cmd)
+	if client.req.Temperature == nil || *client.req.Temperature != 0.7 {
+		t.Errorf("request temperature = %v, want 0.7", client.req.Temperature)
+	}
+
+	// 0 is a temperature of its own, not a reset
+	m, _ = sendInput(m, "/temp 0")
+	m, cmd = sendInput(m, "again")
+	m = runStream(m, cmd)
+	if client.req.Temperature == nil || *client.req.Temperature != 0 {
+		t.Errorf("/temp 0 should send 0, got %v", client.req.Temperature)
+	}
+
+	m, _ = sendInput(m, "/temp default")
+	m, cmd = sendInput(m, "once more")
+	runStream(m, cmd)
+	if client.req.Temperature != nil {
+		t.Errorf("/temp default should leave the temperature to the client, got %v", *client.req.Temperature)
+	}
+}
+
+func TestModelStreamError(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventError, Err: errors.New("boom")},
+	}}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "hi")
+	m = runStream(m, cmd)
+
+	items := m.messages.Items()
+	last := items[len(items)-1]
+	if last.Role != components.RoleError || !strings.Contains(last.Content, "boom") {
+		t.Errorf("expected error message, got %+v", last)
+	}
+}
+
+func TestModelCtrlCCancelsStream(t *testing.T) {
+	client := &fakeClient{}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "hi")
+	execCmd(cmd)
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = newModel.(Model)
+
+	if m.streaming {
+		t.Error("Ctrl+C should stop streaming")
+	}
+	if m.showExitPrompt {
+		t.Error("Ctrl+C during streaming should not show the exit prompt")
+	}
+	if client.ctx.Err() == nil {
+		t.Error("Ctrl+C should cancel the request context")
+	}
+}
+
+// slowClient sends one chunk, then holds the stream open until the request
+// is cancelled, closing the channel like the real clients do.
+type slowClient struct {
+	fakeClient
+	first  ai.StreamEvent // sent before holding; defaults to a "partial" chunk
+	events chan ai.StreamEvent
+}
+
+func (c *slowClient) Stream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamEvent, error) {
+	c.ctx = ctx
+	c.req = req
+	c.events = make(chan ai.StreamEvent)
+	first := c.first
+	if first.Type == "" {
+		first = ai.StreamEvent{Type: ai.StreamEventChunk, Content: "partial"}
+	}
+	go func(events chan ai.StreamEvent) {
+		defer close(events)
+		select {
+		case events <- first:
+		case <-ctx.Done():
+			return
+		}
+		<-ctx.Done()
+	}(c.events)
+	return c.events, nil
+}
+
+func TestModelEscCancelsStream(t *testing.T) {
+	client := &slowClient{}
+	m := NewModel(nil, client)
+
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	newModel, cmd := m.Update(esc)
+	if m = newModel.(Model); cmd != nil || m.messages.Count() != 0 {
+		t.Error("Esc should do nothing while idle")
+	}
+
+	m, cmd = sendInput(m, "hi")
+	m, cmd = m.handleStreamStarted(execCmd(cmd).(streamStartedMsg))
+	m, _ = m.handleStreamEvent(execCmd(cmd).(streamEventMsg))
+
+	newModel, _ = m.Update(esc)
+	m = newModel.(Model)
+
+	if m.streaming || client.ctx.Err() == nil {
+		t.Fatal("Esc should stop streaming and cancel the request context")
+	}
+	select {
+	case _, ok := <-client.events:
+		if ok {
+			t.Error("expected no more events after cancelling")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream channel was not closed after cancelling")
+	}
+
+	items := m.messages.Items()
+	last := items[len(items)-1]
+	if last.Role != components.RoleAssistant || last.Content != "partial" {
+		t.Fatalf("partial reply should be kept, got %+v", items)
+	}
+	if !last.Cancelled {
+		t.Errorf("the partial reply should be marked cancelled, got %+v", last)
+	}
+	if view := ansi.Strip(m.messages.Render()); !strings.Contains(view, "(cancelled)") {
+		t.Errorf("the cancelled reply should say so, got:\n%s", view)
+	}
+
+	m, cmd = sendInput(m, "next")
+	execCmd(cmd)
+	defer m.finishStream()
+	for _, msg := range client.req.Messages {
+		if strings.Contains(msg.Content, "(cancelled)") {
+			t.Errorf("the cancelled note should not be sent: %+v", client.req.Messages)
+		}
+	}
+}
+
+func TestModelCancelWhileReasoningDropsEmptyReply(t *testing.T) {
+	client := &slowClient{first: ai.StreamEvent{Type: ai.StreamEventReasoning, Content: "hmm"}}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "hi")
+	m, cmd = m.handleStreamStarted(execCmd(cmd).(streamStartedMsg))
+	m, _ = m.handleStreamEvent(execCmd(cmd).(streamEventMsg))
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(Model)
+
+	for _, msg := range m.messages.Items() {
+		if msg.Role == components.RoleAssistant {
+			t.Errorf("a reply cancelled while reasoning should be dropped, got %+v", msg)
+		}
+	}
+
+	m, cmd = sendInput(m, "again")
+	execCmd(cmd)
+	defer m.finishStream()
+	for _, msg := range client.req.Messages {
+		if msg.Role == "assistant" && msg.Content == "" {
+			t.Errorf("empty assistant turn sent in history: %+v", client.req.Messages)
+		}
+	}
+	if n := len(client.req.Messages); n == 0 || client.req.Messages[n-1].Content != "again" {
+		t.Errorf("expected the new message last, got %+v", client.req.Messages)
+	}
+}
+
+func TestModelPasteModeEnterInsertsNewline(t *testing.T) {
+	client := &fakeClient{}
+	m := NewModel(nil, client)
+
+	m, _ = sendInput(m, "/paste")
+	if !m.input.PasteMode() {
+		t.Fatal("/paste should turn paste mode on")
+	}
+	count := m.messages.Count()
+
+	m.input.SetValue("func main() {")
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	if m.messages.Count() != count || client.calls != 0 {
+		t.Fatal("Enter in paste mode should not send")
+	}
+	if m.input.Value() != "func main() {\n" {
+		t.Errorf("Enter should insert a newline, got %q", m.input.Value())
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = newModel.(Model)
+	if last := m.messages.Items()[m.messages.Count()-1]; last.Role != components.RoleUser || last.Content != "func main() {\n" {
+		t.Errorf("Ctrl+S should send the input, got %+v", last)
+	}
+
+	m.input.SetValue("/paste")
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if newModel.(Model).input.PasteMode() {
+		t.Error("/paste should turn paste mode off again")
+	}
+}
+
+func TestModelShowsTokenEstimateWhileTyping(t *testing.T) {
+	m := NewModel(&config.Config{UI: config.UIConfig{ShowTokens: true}}, &fakeClient{})
+	m.statusBar.SetWidth(200)
+	m.messages.Add(components.RoleUser, strings.Repeat("x", 400))
+
+	for _, r := range "hello there" {
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = newModel.(Model)
+	}
+	// 100 + 3 tokens of content plus the per-message overhead
+	if view := m.statusBar.View(); !strings.Contains(view, "~111 tok to send") {
+		t.Errorf("expected the conversation plus input estimate, got %q", view)
+	}
+
+	m.showTokens = false
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	m = newModel.(Model)
+	if view := m.statusBar.View(); strings.Contains(view, "tok to send") {
+		t.Errorf("estimate should be hidden when show_tokens is off, got %q", view)
+	}
+}
+
+func TestModelTabTogglesFocus(t *testing.T) {
+	m := NewModel(nil, nil)
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = newModel.(Model)
+	if m.focus != focusViewport {
+		t.Fatal("Tab should move focus to the viewport")
+	}
+	if m.input.Focused() {
+		t.Error("input should be blurred while the viewport is focused")
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = newModel.(Model)
+	if m.focus != focusInput {
+		t.Error("second Tab should return focus to the input")
+	}
+	if !m.input.Focused() {
+		t.Error("input should be focused again")
+	}
+}
+
+func TestModelScrollKeysRequireViewportFocus(t *testing.T) {
+	m := NewModel(nil, nil)
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 12})
+	m = newModel.(Model)
+	m.viewport.SetContent(strings.Repeat("line\n", 50))
+
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	newModel, _ = m.Update(down)
+	m = newModel.(Model)
+	if m.viewport.YOffset() != 0 {
+		t.Errorf("scroll key should not move the viewport while typing, offset %d", m.viewport.YOffset())
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = newModel.(Model)
+	newModel, _ = m.Update(down)
+	m = newModel.(Model)
+	if m.viewport.YOffset() != 1 {
+		t.Errorf("scroll key should move the focused viewport, offset %d", m.viewport.YOffset())
+	}
+}
+
+func TestModelPrependsSystemPrompt(t *testing.T) {
+	cfg := &config.Config{System: config.SystemConfig{Prompt: "Be terse."}}
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "ok"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(cfg, client)
+
+	m, cmd := sendInput(m, "first")
+	m = runStream(m, cmd)
+	m, cmd = sendInput(m, "second")
+	runStream(m, cmd)
+
+	msgs := client.req.Messages
+	if len(msgs) == 0 || msgs[0].Role != "system" || msgs[0].Content != "Be terse." {
+		t.Fatalf("first message should be the system prompt, got %+v", msgs)
+	}
+
+	systemCount := 0
+	for _, msg := range msgs {
+		if msg.Role == "system" {
+			systemCount++
+		}
+	}
+	if systemCount != 1 {
+		t.Errorf("system prompt should be sent once per request, got %d", systemCount)
+	}
+}
+
+func TestModelOmitsEmptySystemPrompt(t *testing.T) {
+	client := &fakeClient{}
+	m := NewModel(&config.Config{}, client)
+
+	_, cmd := sendInput(m, "hi")
+	execCmd(cmd)
+
+	if len(client.req.Messages) != 1 || client.req.Messages[0].Role != "user" {
+		t.Errorf("empty system prompt should be omitted, got %+v", client.req.Messages)
+	}
+}
+
+func TestModelEscCancelsBackgroundCommand(t *testing.T) {
+	m := NewModel(nil, &fakeClient{})
+	m.width = 80
+	cancelled := make(chan struct{})
+	m.commands.Register("slow", func(cmd *commands.Command) commands.CommandResult {
+		return commands.CommandResult{Async: func(ctx context.Context) commands.CommandResult {
+			<-ctx.Done()
+			close(cancelled)
+			return commands.CommandResult{Output: "finished"}
+		}}
+	})
+
+	m, cmd := sendInput(m, "/slow")
+	if cmd == nil || m.commandCancel == nil {
+		t.Fatal("/slow should run in the background")
+	}
+	if status := m.renderStatusBar(); !strings.Contains(status, "Running /slow") {
+		t.Errorf("status bar should show the running command, got %q", status)
+	}
+
+	m, _ = sendInput(m, "/slow")
+	items := m.messages.Items()
+	if last := items[len(items)-1].Content; !strings.Contains(last, "still running") {
+		t.Errorf("a second command should wait for the first, got %q", last)
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+	if m.commandCancel != nil {
+		t.Fatal("Esc should cancel the command")
+	}
+	items = m.messages.Items()
+	if last := items[len(items)-1]; last.Role != components.RoleNote || last.Content != "(cancelled)" {
+		t.Errorf("expected a cancelled note, got %+v", last)
+	}
+
+	// The work sees the cancellation and its result is dropped
+	count := m.messages.Count()
+	m = finishCommand(m, cmd)
+	select {
+	case <-cancelled:
+	default:
+		t.Error("the command's context should be cancelled")
+	}
+	if m.messages.Count() != count {
+		t.Errorf("a cancelled command's result should be dropped, got %+v", m.messages.Items()[count:])
+	}
+}
+
+func TestModelBackgroundCommandResult(t *testing.T) {
+	m := NewModel(nil, &fakeClient{})
+	m.commands.Register("fetch", func(cmd *commands.Command) commands.CommandResult {
+		return commands.CommandResult{Async: func(ctx context.Context) commands.CommandResult {
+			return commands.CommandResult{Output: "fetched text", AddToChat: true}
+		}}
+	})
+
+	m = finishCommand(sendInput(m, "/fetch"))
+	if m.commandCancel != nil {
+		t.Error("the command should be done")
+	}
+	items := m.messages.Items()
+	if len(items) != 2 || items[0].Content != "/fetch" || items[1].Content != "fetched text" {
+		t.Errorf("expected the command and its output in the chat, got %+v", items)
+	}
+}
+
+func TestModelCommandOutputRoles(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.commands.Register("attach", func(cmd *commands.Command) commands.CommandResult {
+		return commands.CommandResult{Output: "attached text", AddToChat: true}
+	})
+	m.commands.Register("fail", func(cmd *commands.Command) commands.CommandResult {
+		return commands.CommandResult{Error: errors.New("broken")}
+	})
+
+	m, _ = sendInput(m, "/help")
+	m, _ = sendInput(m, "/fail")
+	m, _ = sendInput(m, "/attach")
+
+	items := m.messages.Items()
+	want := []components.Role{components.RoleNote, components.RoleError, components.RoleUser, components.RoleSystem}
+	if len(items) != len(want) {
+		t.Fatalf("expected %d messages, got %+v", len(want), items)
+	}
+	for i, role := range want {
+		if items[i].Role != role {
+			t.Errorf("message %d (%q) has role %s, want %s", i, items[i].Content, items[i].Role, role)
+		}
+	}
+}
+
+func TestModelCommandSwitchesModel(t *testing.T) {
+	client := &fakeClient{}
+	m := NewModel(nil, client)
+	m.messages.Add(components.RoleUser, "earlier question")
+	m.messages.Add(components.RoleAssistant, "earlier answer")
+
+	m = finishCommand(sendInput(m, "/model gpt-4o-mini"))
+
+	if client.Model() != "gpt-4o-mini" {
+		t.Errorf("expected model to switch, got %q", client.Model())
+	}
+	items := m.messages.Items()
+	if items[0].Content != "earlier question" || items[1].Content != "earlier answer" {
+		t.Error("switching models should keep the conversation")
+	}
+
+	m, _ = sendInput(m, "/model")
+	items = m.messages.Items()
+	if !strings.Contains(items[len(items)-1].Content, "fake/gpt-4o-mini") {
+		t.Errorf("/model without args should show the current model, got %q", items[len(items)-1].Content)
+	}
+}
+
+func TestPersonaSwitchesSystemPrompt(t *testing.T) {
+	cfg := &config.Config{
+		System: config.SystemConfig{Prompt: "default prompt"},
+		Personas: map[string]string{
+			"reviewer": "You are a concise code reviewer.",
+			"tutor":    "You are a patient tutor.",
+		},
+	}
+	client := &fakeClient{}
+	m := NewModel(cfg, client)
+
+	m, _ = sendInput(m, "/persona tutor")
+	_, cmd := sendInput(m, "explain closures")
+	execCmd(cmd)
+	if got := client.req.Messages[0].Content; got != "You are a patient tutor." {
+		t.Errorf("expected tutor system prompt, got %q", got)
+	}
+
+	m, _ = sendInput(m, "/persona")
+	items := m.messages.Items()
+	if !strings.Contains(items[len(items)-1].Content, "tutor (active)") {
+		t.Errorf("persona list should mark the active persona, got %q", items[len(items)-1].Content)
+	}
+
+	m, _ = sendInput(m, "/persona reviewer")
+	_, cmd = sendInput(m, "review this")
+	execCmd(cmd)
+	if got := client.req.Messages[0].Content; got != "You are a concise code reviewer." {
+		t.Errorf("expected reviewer system prompt, got %q", got)
+	}
+}
+
+func TestModelRecordsTTFTAndTotalTime(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "a"},
+		{Type: ai.StreamEventChunk, Content: "b"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(nil, client)
+
+	clock := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return clock }
+
+	m, cmd := sendInput(m, "hi")
+
+	// Open the stream, then deliver events with controlled delays
+	newModel, cmd := m.Update(execCmd(cmd))
+	m = newModel.(Model)
+
+	delays := []time.Duration{800 * time.Millisecond, time.Second, 2400 * time.Millisecond}
+	for _, d := range delays {
+		clock = clock.Add(d)
+		newModel, cmd = m.Update(execCmd(cmd))
+		m = newModel.(Model)
+	}
+
+	if m.ttft != 800*time.Millisecond {
+		t.Errorf("expected TTFT 800ms, got %v", m.ttft)
+	}
+	if m.totalTime != 4200*time.Millisecond {
+		t.Errorf("expected total 4.2s, got %v", m.totalTime)
+	}
+
+	m.statusBar.SetWidth(120)
+	if view := m.statusBar.View(); !strings.Contains(view, "TTFT 0.8s · total 4.2s") {
+		t.Errorf("status bar should show timing, got %q", view)
+	}
+}
+
+func TestModelShowsStreamProgress(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "héllo"},
+		{Type: ai.StreamEventChunk, Content: ", world"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(nil, client)
+	m.statusBar.SetWidth(160)
+
+	clock := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return clock }
+
+	m, cmd := sendInput(m, "hi")
+	newModel, cmd := m.Update(execCmd(cmd))
+	m = newModel.(Model)
+
+	wants := []string{"↓ 5 chars · 1s", "↓ 12 chars · 3s"}
+	for _, want := range wants {
+		clock = clock.Add(1500 * time.Millisecond)
+		newModel, cmd = m.Update(execCmd(cmd))
+		m = newModel.(Model)
+		if view := m.statusBar.View(); !strings.Contains(view, want) {
+			t.Errorf("status bar should show %q, got %q", want, view)
+		}
+	}
+
+	// A quiet stretch still advances the elapsed time
+	clock = clock.Add(2 * time.Second)
+	newModel, _ = m.Update(progressTickMsg{id: m.streamID})
+	m = newModel.(Model)
+	if view := m.statusBar.View(); !strings.Contains(view, "↓ 12 chars · 5s") {
+		t.Errorf("tick should refresh elapsed time, got %q", view)
+	}
+
+	m = runStream(m, cmd)
+	if view := m.statusBar.View(); strings.Contains(view, "chars") {
+		t.Errorf("progress should clear when the stream ends, got %q", view)
+	}
+}
+
+func TestModelShowsStreamUsage(t *testing.T) {
+	usage := &ai.Usage{PromptTokens: 12, CompletionTokens: 30, TotalTokens: 42}
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "ok"},
+		{Type: ai.StreamEventDone, Usage: usage},
+	}}
+	cfg := &config.Config{UI: config.UIConfig{ShowTokens: true}}
+	m := NewModel(cfg, client)
+
+	m, cmd := sendInput(m, "hi")
+	m = runStream(m, cmd)
+
+	if !client.req.IncludeUsage {
+		t.Error("request should ask for usage when show_tokens is on")
+	}
+	m.statusBar.SetWidth(160)
+	if view := m.statusBar.View(); !strings.Contains(view, "12↑ 30↓ 42 tok") {
+		t.Errorf("status bar should show usage, got %q", view)
+	}
+}
+
+func TestModelHidesUsageWhenDisabled(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventDone, Usage: &ai.Usage{TotalTokens: 42}},
+	}}
+	m := NewModel(&config.Config{}, client)
+
+	m, cmd := sendInput(m, "hi")
+	m = runStream(m, cmd)
+
+	if client.req.IncludeUsage {
+		t.Error("request should not ask for usage when show_tokens is off")
+	}
+	if view := m.statusBar.View(); strings.Contains(view, "tok") {
+		t.Errorf("status bar should not show usage, got %q", view)
+	}
+}
+
+func TestModelSpinnerWhileWaiting(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "hi"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "hello")
+	if !m.spinner.Active() {
+		t.Fatal("spinner should be active after Enter")
+	}
+
+	// Ticks keep the spinner going while waiting
+	newModel, tick := m.Update(m.spinner.Tick())
+	m = newModel.(Model)
+	if tick == nil {
+		t.Error("spinner tick should schedule the next frame while waiting")
+	}
+
+	// Open the stream, then deliver the first chunk
+	newModel, cmd = m.Update(execCmd(cmd))
+	m = newModel.(Model)
+	if !m.spinner.Active() {
+		t.Error("spinner should stay active until content arrives")
+	}
+	newModel, _ = m.Update(execCmd(cmd))
+	m = newModel.(Model)
+
+	if m.spinner.Active() {
+		t.Error("spinner should stop on the first chunk")
+	}
+	if _, tick = m.Update(m.spinner.Tick()); tick != nil {
+		t.Error("stopped spinner should not schedule further ticks")
+	}
+}
+
+func TestModelSpinnerStopsOnError(t *testing.T) {
+	client := &fakeClient{err: errors.New("boom")}
+	m := NewModel(nil, client)
+
+	m, cmd := sendInput(m, "hello")
+	m = runStream(m, cmd)
+
+	if m.spinner.Active() {
+		t.Error("spinner should stop when the request fails")
+	}
+}
+
+func TestModelWarnsOnTruncatedResponse(t *testing.T) {
+	for _, tc := range []struct {
+		reason string
+		warn   bool
+	}{
+		{"stop", false},
+		{"length", true},
+	} {
+		client := &fakeClient{events: []ai.StreamEvent{
+			{Type: ai.StreamEventChunk, Content: "partial"},
+			{Type: ai.StreamEventDone, FinishReason: tc.reason},
+		}}
+		m := NewModel(nil, client)
+
+		m, cmd := sendInput(m, "hi")
+		m = runStream(m, cmd)
+
+		m.statusBar.SetWidth(160)
+		got := strings.Contains(m.statusBar.View(), "response truncated (length)")
+		if got != tc.warn {
+			t.Errorf("finish reason %q: expected warning=%v, status bar %q", tc.reason, tc.warn, m.statusBar.View())
+		}
+	}
+}
+
+func TestModelShowsConfiguredThinkingText(t *testing.T) {
+	cfg := &config.Config{UI: config.UIConfig{ThinkingText: "Pondering deeply", Spinner: "moon"}}
+	m := NewModel(cfg, &fakeClient{})
+	m.width = 120
+
+	m, _ = sendInput(m, "hi")
+
+	if view := m.renderStatusBar(); !strings.Contains(view, "Pondering deeply") {
+		t.Errorf("status bar should show thinking text while waiting, got %q", view)
+	}
+}
+
+func TestModelRejectsUnknownSpinner(t *testing.T) {
+	m := NewModel(&config.Config{UI: config.UIConfig{Spinner: "nope"}}, nil)
+
+	items := m.messages.Items()
+	if len(items) != 1 || !strings.Contains(items[0].Content, `unknown spinner "nope"`) {
+		t.Errorf("expected config error for unknown spinner, got %+v", items)
+	}
+}
+
+func TestModelUsesActiveFileAsDefaultTarget(t *testing.T) {
+	t.Setenv(ActiveFileEnv, "internal/app/app.go")
+	m := NewModel(nil, nil)
+
+	if m.CurrentFile() != "internal/app/app.go" {
+		t.Fatalf("expected current file from env, got %q", m.CurrentFile())
+	}
+
+	var got []string
+	m.commands.RegisterWithInfo(commands.CommandInfo{Name: "inspect", FileTarget: true}, func(cmd *commands.Command) commands.CommandResult {
+		got = cmd.Args
+		return commands.CommandResult{}
+	})
+	m.commands.RegisterWithInfo(commands.CommandInfo{Name: "plain"}, func(cmd *commands.Command) commands.CommandResult {
+		got = cmd.Args
+		return commands.CommandResult{}
+	})
+
+	m, _ = sendInput(m, "/inspect")
+	if len(got) != 1 || got[0] != "internal/app/app.go" {
+		t.Errorf("file command should default to the active file, got %v", got)
+	}
+
+	m, _ = sendInput(m, "/inspect other.go")
+	if len(got) != 1 || got[0] != "other.go" {
+		t.Errorf("explicit argument should win, got %v", got)
+	}
+
+	sendInput(m, "/plain")
+	if len(got) != 0 {
+		t.Errorf("non-file commands should not get the active file, got %v", got)
+	}
+}
+
+func TestModelAgainRepeatsLastCommand(t *testing.T) {
+	m := NewModel(nil, nil)
+
+	var calls [][]string
+	m.commands.Register("count", func(cmd *commands.Command) commands.CommandResult {
+		calls = append(calls, cmd.Args)
+		return commands.CommandResult{Output: "counted"}
+	})
+
+	// Nothing to repeat yet
+	m, _ = sendInput(m, "/again")
+	if len(calls) != 0 || m.messages.Count() != 0 {
+		t.Fatalf("/again without history should be a no-op, got calls %v and %d messages", calls, m.messages.Count())
+	}
+
+	m, _ = sendInput(m, "/count a b")
+	m, _ = sendInput(m, "/again")
+	m, _ = sendInput(m, "/again")
+
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(calls))
+	}
+	for _, args := range calls {
+		if strings.Join(args, " ") != "a b" {
+			t.Errorf("expected repeated args 'a b', got %v", args)
+		}
+	}
+}
+
+func TestModelScrollKeysWorkWhileTyping(t *testing.T) {
+	m := NewModel(nil, nil)
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = newModel.(Model)
+	m.viewport.SetContent(strings.Repeat("line\n", 100))
+
+	press := func(k tea.KeyMsg) {
+		newModel, _ := m.Update(k)
+		m = newModel.(Model)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyPgDown})
+	if m.viewport.YOffset() == 0 {
+		t.Fatal("PageDown should scroll the viewport while the input has focus")
+	}
+	if m.focus != focusInput {
+		t.Error("scrolling should not take focus from the input")
+	}
+
+	paged := m.viewport.YOffset()
+	press(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if m.viewport.YOffset() >= paged {
+		t.Errorf("Ctrl+U should scroll up, offset %d -> %d", paged, m.viewport.YOffset())
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEnd})
+	if !m.viewport.AtBottom() {
+		t.Error("End should jump to the bottom when the input is empty")
+	}
+	press(tea.KeyMsg{Type: tea.KeyHome})
+	if m.viewport.YOffset() != 0 {
+		t.Errorf("Home should jump to the top, offset %d", m.viewport.YOffset())
+	}
+	if !strings.Contains(m.renderStatusBar(), "↕ 0%") {
+		t.Errorf("status bar should show the scroll position, got %q", m.renderStatusBar())
+	}
+
+	// With text typed, Home/End stay with the input
+	m.input.SetValue("draft")
+	press(tea.KeyMsg{Type: tea.KeyEnd})
+	if m.viewport.YOffset() != 0 {
+		t.Error("End should move the cursor, not scroll, while typing")
+	}
+}
+
+func TestModelSearchJumpsBetweenMatches(t *testing.T) {
+	m := NewModel(nil, nil)
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = newModel.(Model)
+	for i := range 30 {
+		content := fmt.Sprintf("filler %d", i)
+		if i == 3 || i == 20 {
+			content = fmt.Sprintf("Needle %d", i)
+		}
+		m.messages.Add(components.RoleSystem, content)
+	}
+	m.refreshViewport()
+	m.input.SetValue("draft")
+
+	press := func(k tea.KeyMsg) {
+		newModel, _ := m.Update(k)
+		m = newModel.(Model)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyCtrlF})
+	if !m.searching || m.input.Value() != "" {
+		t.Fatalf("Ctrl+F should open an empty search prompt, got %q", m.input.Value())
+	}
+	m.input.SetValue("needle")
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.searching || m.input.Value() != "draft" {
+		t.Errorf("the draft should be restored after searching, got %q", m.input.Value())
+	}
+	if m.focus != focusViewport || !slices.Equal(m.matches, []int{3, 20}) {
+		t.Fatalf("expected matches [3 20] with the viewport focused, got %v", m.matches)
+	}
+	if m.viewport.YOffset() != m.messageLines[20] {
+		t.Errorf("should jump to the latest match at line %d, offset %d", m.messageLines[20], m.viewport.YOffset())
+	}
+	if !strings.Contains(m.renderStatusBar(), "2/2") {
+		t.Errorf("status bar should show the match position, got %q", m.renderStatusBar())
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.viewport.YOffset() != m.messageLines[3] {
+		t.Errorf("n should wrap to the first match at line %d, offset %d", m.messageLines[3], m.viewport.YOffset())
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	if m.matchIdx != 1 {
+		t.Errorf("N should go back to the last match, at %d", m.matchIdx)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if len(m.matches) != 0 || m.searchQuery != "" {
+		t.Errorf("Esc should clear the search, got %v", m.matches)
+	}
+}
+
+func TestModelMouseWheelScrolls(t *testing.T) {
+	m := NewModel(nil, nil)
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = newModel.(Model)
+	m.viewport.SetContent(strings.Repeat("line\n", 100))
+
+	newModel, _ = m.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	m = newModel.(Model)
+	if m.viewport.YOffset() == 0 {
+		t.Error("mouse wheel should scroll the viewport")
+	}
+}
+
+func TestModelCopyLastReply(t *testing.T) {
+	var copied string
+	orig := writeClipboard
+	writeClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	defer func() { writeClipboard = orig }()
+
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleAssistant, "# Title\n\nbody")
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	m = newModel.(Model)
+	if copied != "# Title\n\nbody" {
+		t.Errorf("expected raw markdown on the clipboard, got %q", copied)
+	}
+	if !strings.Contains(m.renderStatusBar(), "copied") {
+		t.Error("status bar should confirm the copy")
+	}
+	if cmd == nil {
+		t.Fatal("expected a command to clear the notice")
+	}
+
+	newModel, _ = m.Update(clearNoticeMsg{})
+	m = newModel.(Model)
+	if strings.Contains(m.renderStatusBar(), "copied") {
+		t.Error("notice should clear")
+	}
+}
+
+func TestModelEmptyResponseNote(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "  "},
+		{Type: ai.StreamEventDone, FinishReason: "stop"},
+	}}
+	m := NewModel(&config.Config{UI: config.UIConfig{EmptyResponse: config.EmptyResponseNote}}, client)
+
+	m, cmd := sendInput(m, "hi")
+	m = runStream(m, cmd)
+
+	if m.streaming {
+		t.Fatal("stream should have finished")
+	}
+	if client.calls != 1 {
+		t.Errorf("note mode should not retry, got %d requests", client.calls)
+	}
+	items := m.messages.Items()
+	last := items[len(items)-1]
+	if last.Role != components.RoleNote || last.Content != "(empty response)" {
+		t.Errorf("expected an empty-response note, got %s %q", last.Role, last.Content)
+	}
+	for _, msg := range items {
+		if msg.Role == components.RoleAssistant {
+			t.Errorf("the blank reply should be removed, got %+v", items)
+		}
+	}
+}
+
+func TestModelEmptyResponseRetryDropsBlankReply(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "\n "},
+		{Type: ai.StreamEventDone, FinishReason: "stop"},
+	}}
+	m := NewModel(&config.Config{UI: config.UIConfig{EmptyResponse: config.EmptyResponseRetry}}, client)
+
+	m, cmd := sendInput(m, "hi")
+	runStream(m, cmd)
+
+	if client.calls != 2 {
+		t.Fatalf("expected one retry, got %d requests", client.calls)
+	}
+	for _, msg := range client.req.Messages {
+		if msg.Role == "assistant" {
+			t.Errorf("the retried request should not resend the blank reply: %+v", client.req.Messages)
+		}
+	}
+}
+
+func TestModelEmptyResponseRetriesOnce(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventDone, FinishReason: "stop"},
+	}}
+	m := NewModel(&config.Config{UI: config.UIConfig{EmptyResponse: config.EmptyResponseRetry}}, client)
+
+	m, cmd := sendInput(m, "hi")
+	m = runStream(m, cmd)
+
+	if client.calls != 2 {
+		t.Errorf("expected one retry, got %d requests", client.calls)
+	}
+	if m.streaming {
+		t.Error("stream should settle after the retry")
+	}
+	notes := 0
+	for _, msg := range m.messages.Items() {
+		if msg.Content == "(empty response)" {
+			notes++
+		}
+	}
+	if notes != 1 {
+		t.Errorf("expected a single note after the retry also came back empty, got %d", notes)
+	}
+}
+
+func TestModelTabCompletesCommands(t *testing.T) {
+	m := NewModel(nil, nil)
+	press := func() {
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		m = newModel.(Model)
+	}
+
+	m.input.SetValue("/di")
+	press()
+	if got := m.input.Value(); got != "/diff" {
+		t.Errorf("first Tab should complete to /diff, got %q", got)
+	}
+	if m.focus != focusInput {
+		t.Error("completing should keep focus on the input")
+	}
+	if !strings.Contains(m.renderStatusBar(), "/difflast") {
+		t.Error("status bar should list the candidates")
+	}
+
+	press()
+	if got := m.input.Value(); got != "/difflast" {
+		t.Errorf("second Tab should cycle to /difflast, got %q", got)
+	}
+	press()
+	if got := m.input.Value(); got != "/diff" {
+		t.Errorf("third Tab should wrap to /diff, got %q", got)
+	}
+
+	m.input.SetValue("/bra")
+	press()
+	if got := m.input.Value(); got != "/branch " {
+		t.Errorf("a unique match should complete with a space, got %q", got)
+	}
+}
+
+func TestModelRecordsUsagePerTurn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "hi"},
+		{Type: ai.StreamEventDone, Usage: &ai.Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}},
+	}}
+	m := NewModel(&config.Config{Usage: config.UsageConfig{Log: true, Path: path}}, client)
+
+	for _, input := range []string{"one", "two"} {
+		var cmd tea.Cmd
+		m, cmd = sendInput(m, input)
+		m = runStream(m, cmd)
+		if !client.req.IncludeUsage {
+			t.Error("usage logging should request usage from the provider")
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per turn, got %q", data)
+	}
+	var entry usage.Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Provider != "fake" || entry.Model != "fake-model" || entry.TotalTokens != 7 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestModelTrimsHistoryToContextBudget(t *testing.T) {
+	cfg := &config.Config{
+		System:  config.SystemConfig{Prompt: "be helpful"},
+		Context: config.ContextConfig{MaxContextTokens: 100},
+	}
+	m := NewModel(cfg, nil)
+	for i := range 10 {
+		m.messages.Add(components.RoleUser, fmt.Sprintf("message %d %s", i, strings.Repeat("x", 80)))
+	}
+
+	history := m.buildHistory()
+	if history[0].Content != "be helpful" {
+		t.Errorf("system prompt should lead the history, got %+v", history[0])
+	}
+	if !strings.Contains(history[1].Content, "dropped") {
+		t.Errorf("expected a truncation note, got %+v", history[1])
+	}
+	if !strings.HasPrefix(history[len(history)-1].Content, "message 9") {
+		t.Error("latest message should be kept")
+	}
+	for _, msg := range history {
+		if strings.HasPrefix(msg.Content, "message 0") {
+			t.Error("oldest message should be dropped")
+		}
+	}
+}
+
+func TestModelMultipleSystemMessages(t *testing.T) {
+	cfg := &config.Config{
+		System:  config.SystemConfig{Prompt: "be helpful"},
+		Context: config.ContextConfig{MaxContextTokens: 120},
+	}
+	m := NewModel(cfg, nil)
+	m.SetSystemContext("project", "Project: flux")
+	m.SetSystemContext("git", "Branch: main")
+	for i := range 10 {
+		m.messages.Add(components.RoleUser, fmt.Sprintf("message %d %s", i, strings.Repeat("x", 80)))
+	}
+
+	history := m.buildHistory()
+	want := []string{"be helpful", "Project: flux", "Branch: main"}
+	for i, content := range want {
+		if history[i].Role != "system" || history[i].Content != content {
+			t.Errorf("message %d: expected system %q, got %s %q", i, content, history[i].Role, history[i].Content)
+		}
+	}
+	if !strings.Contains(history[len(want)].Content, "dropped") {
+		t.Errorf("system contributions should survive trimming ahead of the note, got %+v", history[len(want)])
+	}
+}
+
+func TestModelHistorySkipsDisplayOutput(t *testing.T) {
+	m := NewModel(nil, &fakeClient{})
+	m.commands.Register("attach", func(cmd *commands.Command) commands.CommandResult {
+		return commands.CommandResult{Output: "attached text", AddToChat: true}
+	})
+	m.messages.Add(components.RoleUser, "question")
+	m.messages.Add(components.RoleAssistant, "answer")
+	m, _ = sendInput(m, "/help")
+	m, _ = sendInput(m, "/model")
+	m, _ = sendInput(m, "/bogus")
+	m.messages.Add(components.RoleNote, "(cancelled)")
+	m, _ = sendInput(m, "/attach")
+
+	var got []string
+	for _, msg := range m.buildHistory() {
+		got = append(got, msg.Role+": "+msg.Content)
+	}
+	want := []string{"user: question", "assistant: answer", "user: /attach", "system: attached text"}
+	if !slices.Equal(got, want) {
+		t.Errorf("history = %q, want only chat turns and attached context %q", got, want)
+	}
+}
+
+func TestModelContextClearKeepsChat(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleUser, "/file main.go")
+	m.messages.Add(components.RoleSystem, "package main")
+	m.messages.Add(components.RoleUser, "what does this do?")
+	m.messages.Add(components.RoleAssistant, "It prints hello.")
+
+	m, _ = sendInput(m, "/context clear")
+	before := m.messages.Count()
+
+	m.messages.Add(components.RoleUser, "thanks")
+	history := m.buildHistory()
+
+	var contents []string
+	for _, msg := range history {
+		contents = append(contents, msg.Content)
+	}
+	joined := strings.Join(contents, "\n")
+	for _, dropped := range []string{"package main", "/file main.go"} {
+		if strings.Contains(joined, dropped) {
+			t.Errorf("cleared attachment %q should not be sent, history:\n%s", dropped, joined)
+		}
+	}
+	for _, kept := range []string{"what does this do?", "It prints hello.", "thanks"} {
+		if !strings.Contains(joined, kept) {
+			t.Errorf("chat message %q should still be sent, history:\n%s", kept, joined)
+		}
+	}
+
+	if !strings.Contains(m.messages.Items()[1].Content, "package main") || before < 5 {
+		t.Error("cleared attachments should stay in the rendered transcript")
+	}
+}
+
+func TestModelContextCountsOnlyAttachedOutput(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.commands.Register("attach", func(cmd *commands.Command) commands.CommandResult {
+		return commands.CommandResult{Output: strings.Repeat("x", 40), AddToChat: true}
+	})
+	m, _ = sendInput(m, "/help")
+	m, _ = sendInput(m, "/bogus")
+	m, _ = sendInput(m, "/attach")
+
+	m, _ = sendInput(m, "/context")
+	items := m.messages.Items()
+	if last := items[len(items)-1].Content; !strings.HasPrefix(last, "1 context attachment(s), about 10 tokens,") {
+		t.Errorf("/context should count only the attached output, got %q", last)
+	}
+}
+
+func TestModelContextAttachedAfterClearIsSent(t *testing.T) {
+	m := NewModel(nil, nil)
+	m.messages.Add(components.RoleSystem, "old context")
+	m, _ = sendInput(m, "/context clear")
+	m.messages.Add(components.RoleSystem, "new context")
+
+	history := m.buildHistory()
+	if last := history[len(history)-1]; last.Content != "new context" {
+		t.Errorf("context attached after clearing should be sent, got %+v", last)
+	}
+}
+
+func TestModelRetryRegeneratesLastResponse(t *testing.T) {
+	client := &fakeClient{events: []ai.StreamEvent{
+		{Type: ai.StreamEventChunk, Content: "second try"},
+		{Type: ai.StreamEventDone},
+	}}
+	m := NewModel(nil, client)
+	m.messages.Add(components.RoleUser, "question")
+	m.messages.Add(components.RoleAssistant, "first try")
+
+	m, cmd := sendInput(m, "/retry")
+	if !m.streaming {
+		t.Fatal("/retry should start a new stream")
+	}
+	m = runStream(m, cmd)
+
+	for _, msg := range client.req.Messages {
+		if msg.Content == "first try" {
+			t.Error("the discarded reply should not be sent")
+		}
+	}
+	items := m.messages.Items()
+	if last := items[len(items)-1]; last.Role != components.RoleAssistant || last.Content != "second try" {
+		t.Errorf("expected the regenerated reply last, got %+v", last)
+	}
+	if items[len(items)-2].Content != "question" {
+		t.Errorf("expected the user turn before the new reply, got %+v", items[len(items)-2])
+	}
+}
+
+func TestModelRetryNeedsResponse(t *testing.T) {
+	client := &fakeClient{}
+	m := NewModel(nil, client)
+	m.messages.Add(components.RoleUser, "question")
+
+	m, _ = sendInput(m, "/retry")
+	if m.streaming || client.calls != 0 {
+		t.Error("/retry should not send when the last message is from the user")
+	}
+	items := m.messages.Items()
+	if !strings.Contains(items[len(items)-1].Content, "nothing to retry") {
+		t.Errorf("expected an explanation, got %q", items[len(items)-1].Content)
+	}
+}
+
+// listingClient is a fakeClient that can enumerate models
+type listingClient struct {
+	fakeClient
+	models []string
+}
+
+func (c *listingClient) ListModels(ctx context.Context) ([]string, error) {
+	return c.models, nil
+}
+
+func TestModelCommandFuzzyMatches(t *testing.T) {
+	client := &listingClient{models: []string{"gpt-4o", "gpt-4o-mini", "o3-mini"}}
+	m := NewModel(nil, client)
+
+	m = finishCommand(sendInput(m, "/model o3"))
+	if client.Model() != "o3-mini" {
+		t.Errorf("expected the unique match o3-mini, got %q", client.Model())
+	}
+
+	m = finishCommand(sendInput(m, "/model mini"))
+	items := m.messages.Items()
+	if last := items[len(items)-1].Content; !strings.Contains(last, "ambiguous") || !strings.Contains(last, "gpt-4o-mini") {
+		t.Errorf("expected an ambiguity error listing candidates, got %q", last)
+	}
+	if client.Model() != "o3-mini" {
+		t.Error("an ambiguous name should not switch models")
+	}
+}
+
+func TestProviderCommandFuzzyMatches(t *testing.T) {
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"ollama":     {Model: "llama3"},
+		"openai":     {BaseURL: "https://api.openai.com/v1", Model: "gpt-4o"},
+		"openrouter": {Model: "meta/llama"},
+	}}
+	m := NewModel(cfg, &fakeClient{})
+
+	m, _ = sendInput(m, "/provider route")
+	if m.client.Provider() != "openrouter" {
+		t.Errorf("expected a switch to openrouter, got %q", m.client.Provider())
+	}
+
+	for _, tt := range []struct{ query, want string }{
+		{"open", "ambiguous"},
+		{"zzz", "no match"},
+	} {
+		m, _ = sendInput(m, "/provider "+tt.query)
+		items := m.messages.Items()
+		if last := items[len(items)-1].Content; !strings.Contains(last, tt.want) {
+			t.Errorf("/provider %s: expected %q, got %q", tt.query, tt.want, last)
+		}
+		if m.client.Provider() != "openrouter" {
+			t.Errorf("/provider %s should not switch providers", tt.query)
+		}
+	}
+}
+
+func TestModelCommandKeepsUnmatchedName(t *testing.T) {
+	client := &listingClient{models: []string{"gpt-4o", "o3-mini"}}
+	m := NewModel(nil, client)
+
+	m = finishCommand(sendInput(m, "/model my-finetune"))
+	if client.Model() != "my-finetune" {
+		t.Errorf("a name matching no listed model should be used as typed, got %q", client.Model())
+	}
+}
+
+func TestModelsCommandListsModels(t *testing.T) {
+	client := &listingClient{models: []string{"gpt-4o-mini", "gpt-4o"}}
+	client.model = "gpt-4o"
+	m := NewModel(nil, client)
+
+	m = finishCommand(sendInput(m, "/models"))
+	items := m.messages.Items()
+	out := items[len(items)-1].Content
+	if !strings.Contains(out, "gpt-4o (active)") || !strings.Contains(out, "gpt-4o-mini") {
+		t.Errorf("expected the model list with the active one marked, got %q", out)
+	}
+
+	m = NewModel(nil, &fakeClient{})
+	m = finishCommand(sendInput(m, "/models"))
+	items = m.messages.Items()
+	if out := items[len(items)-1].Content; !strings.Contains(out, "not supported") {
+		t.Errorf("expected a not supported error, got %q", out)
+	}
+}
+
+func TestModelRebuildClientKeepsConversation(t *testing.T) {
+	cfg := &config.Config{
+		Provider:  "stub",
+		Providers: map[string]config.Provider{"stub": {Model: "first"}},
+	}
+	old := &fakeClient{}
+	m := NewModel(cfg, old)
+	m.registry.Register("stub", func(p config.Provider, _ *http.Client) (ai.Client, error) {
+		return &fakeClient{model: p.Model}, nil
+	})
+	m.messages.Add(components.RoleUser, "earlier question")
+	m.messages.Add(components.RoleAssistant, "earlier answer")
+	count := m.messages.Count()
+
+	if err := m.RebuildClient(cfg); err != nil {
+		t.Fatalf("RebuildClient() error: %v", err)
+	}
+	if m.client == old {
+		t.Error("client should be replaced")
+	}
+	if m.messages.Count() != count {
+		t.Errorf("messages changed from %d to %d", count, m.messages.Count())
+	}
+
+	// /model rebuilds too, recording the model in the provider config
+	m = finishCommand(sendInput(m, "/model second"))
+	if m.client.Model() != "second" || m.cfg.Providers["stub"].Model != "second" {
+		t.Errorf("expected rebuilt client on model second, got %q", m.client.Model())
+	}
+	if cfg.Providers["stub"].Model != "first" {
+		t.Error("the original config should not be modified")
+	}
+	if items := m.messages.Items(); items[0].Content != "earlier question" {
+		t.Error("switching models should keep the conversation")
+	}
+
+	if err := m.RebuildClient(&config.Config{Provider: "missing"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if m.client.Model() != "second" {
+		t.Error("a failed rebuild should keep the current client")
+	}
+}
+
+func TestConfigCommandReplacesLoadedConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	loaded, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	t.Cleanup(func() { config.Replace(nil) })
+	m := NewModel(loaded, nil)
+
+	m, _ = sendInput(m, "/config set search.max_matches 5")
+	if got := config.Get().Search.MaxMatches; got != 5 {
+		t.Errorf("commands should see the new setting, got %d", got)
+	}
+	if loaded.Search.MaxMatches == 5 {
+		t.Error("the loaded config should be replaced, not modified")
+	}
+}
+
+func TestConfigCommandSetsTheme(t *testing.T) {
+	t.Cleanup(func() { ApplyTheme(DarkTheme) })
+
+	cfg := &config.Config{UI: config.UIConfig{Theme: "dark"}}
+	m := NewModel(cfg, nil)
+
+	m, _ = sendInput(m, "/config set ui.theme light")
+	if m.cfg.UI.Theme != "light" || PrimaryColor != LightTheme.Primary {
+		t.Errorf("theme not applied: config %q, primary %s", m.cfg.UI.Theme, PrimaryColor)
+	}
+	if cfg.UI.Theme != "dark" {
+		t.Error("the original config should not be modified")
+	}
+
+	m, _ = sendInput(m, "/config")
+	if last := m.messages.Items()[m.messages.Count()-1]; !strings.Contains(last.Content, `ui.theme: "light"`) {
+		t.Errorf("expected /config to show the new theme, got %q", last.Content)
+	}
+
+	m, _ = sendInput(m, "/config set ui.theme neon")
+	if last := m.messages.Items()[m.messages.Count()-1]; !strings.Contains(last.Content, "unknown theme") {
+		t.Errorf("expected an error for an unknown theme, got %+v", last)
+	}
+	if m.cfg.UI.Theme != "light" || PrimaryColor != LightTheme.Primary {
+		t.Errorf("a rejected theme should keep the current one, got %q", m.cfg.UI.Theme)
+	}
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startSearch turns the input into a search prompt, setting aside any
+// message being typed until the search ends.
+func (m *Model) startSearch() tea.Cmd {
+	if m.searching {
+		return nil
+	}
+	m.searching = true
+	m.searchDraft = m.input.Value()
+	m.input.Reset()
+	m.input.SetSearchMode(true)
+	m.statusBar.SetEstimate(0)
+	m.focus = focusInput
+	return m.input.Focus()
+}
+
+// endSearchPrompt restores the input to the message being typed
+func (m *Model) endSearchPrompt() {
+	m.searching = false
+	m.input.SetSearchMode(false)
+	m.input.SetValue(m.searchDraft)
+	m.searchDraft = ""
+}
+
+// runSearch finds the messages matching the query in the input and jumps to
+// the most recent one. The viewport takes focus so n and N move between
+// matches.
+func (m Model) runSearch() (tea.Model, tea.Cmd) {
+	query := strings.TrimSpace(m.input.Value())
+	m.endSearchPrompt()
+	if query == "" {
+		return m, nil
+	}
+
+	matches := m.messages.Search(query)
+	if len(matches) == 0 {
+		m.statusBar.SetNotice(fmt.Sprintf("no messages match %q", query))
+		return m, tea.Tick(noticeTimeout, func(t time.Time) tea.Msg {
+			return clearNoticeMsg{}
+		})
+	}
+
+	m.searchQuery = query
+	m.matches = matches
+	m.matchIdx = len(matches) - 1
+	m.focus = focusViewport
+	m.input.Blur()
+	m.showMatch()
+	return m, nil
+}
+
+// nextMatch moves delta matches through the results, wrapping at either end
+func (m *Model) nextMatch(delta int) {
+	n := len(m.matches)
+	m.matchIdx = ((m.matchIdx+delta)%n + n) % n
+	m.showMatch()
+}
+
+// showMatch marks the current match and scrolls it to the top of the view
+func (m *Model) showMatch() {
+	m.renderMessages()
+	if len(m.matches) == 0 {
+		return
+	}
+	m.viewport.ScrollTo(m.messageLines[m.matches[m.matchIdx]])
+}
+
+// clearSearch drops the search results and their marks
+func (m *Model) clearSearch() {
+	m.searchQuery = ""
+	m.matches = nil
+	m.matchIdx = 0
+	m.renderMessages()
+}
+
+// searchStatus describes the search results for the status bar
+func (m Model) searchStatus() string {
+	return fmt.Sprintf("%q %d/%d  n/N next/prev • Esc clear", m.searchQuery, m.matchIdx+1, len(m.matches))
+}
@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kbesada/flux-code-cli/internal/ai"
+	"github.com/kbesada/flux-code-cli/internal/commands"
+	"github.com/kbesada/flux-code-cli/internal/ui/components"
+	"github.com/kbesada/flux-code-cli/internal/usage"
+)
+
+// streamStartedMsg is sent once the client has opened a stream (or failed to).
+type streamStartedMsg struct {
+	id     int
+	events <-chan ai.StreamEvent
+	err    error
+}
+
+// streamEventMsg carries a single event read from an open stream.
+type streamEventMsg struct {
+	events <-chan ai.StreamEvent
+	event  ai.StreamEvent
+	closed bool
+}
+
+// progressTickMsg refreshes the received/elapsed indicator of stream id.
+type progressTickMsg struct {
+	id int
+}
+
+// progressTick schedules the next progress refresh for stream id.
+func progressTick(id int) tea.Cmd {
+	return tea.Tick(progressInterval, func(time.Time) tea.Msg {
+		return progressTickMsg{id: id}
+	})
+}
+
+// startStream opens a streaming completion for the current conversation.
+func (m *Model) startStream() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.streamID++
+	m.streaming = true
+	m.streamBuf = ""
+	m.replyStarted = false
+	m.streamChars = 0
+	m.cancel = cancel
+	m.streamStart = m.now()
+	m.firstChunkAt = time.Time{}
+	m.statusBar.SetWarning("")
+
+	id := m.streamID
+	client := m.client
+	req := ai.ChatRequest{
+		Messages:     m.buildHistory(),
+		Stream:       true,
+		Temperature:  m.temperature,
+		IncludeUsage: m.showTokens || m.usageLog != nil,
+	}
+
+	return func() tea.Msg {
+		events, err := client.Stream(ctx, req)
+		return streamStartedMsg{id: id, events: events, err: err}
+	}
+}
+
+// waitForStreamEvent blocks until the next event arrives on the stream.
+func waitForStreamEvent(events <-chan ai.StreamEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		return streamEventMsg{events: events, event: event, closed: !ok}
+	}
+}
+
+// buildHistory converts the chat transcript into request messages, led by
+// the system contributions in order. Only user and assistant turns and
+// context a command attached are sent; errors and notes, including the
+// output of commands such as /help, are for the user alone. Older messages
+// are dropped once the history exceeds the context budget; the system
+// contributions are kept.
+func (m Model) buildHistory() []ai.ChatMessage {
+	history := m.system.Messages()
+
+	for i, msg := range m.messages.Items() {
+		if i < m.contextFrom && isAttachment(msg) {
+			continue
+		}
+		if !sentToModel(msg.Role) {
+			continue
+		}
+		history = append(history, ai.ChatMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+			Images:  msg.Images,
+		})
+	}
+
+	history, _ = ai.TrimHistory(history, m.maxContext, nil)
+	return history
+}
+
+// sentToModel reports whether messages with role are part of the
+// conversation the model sees. System messages hold context a command added
+// to the chat; see showResult.
+func sentToModel(role components.Role) bool {
+	switch role {
+	case components.RoleUser, components.RoleAssistant, components.RoleSystem:
+		return true
+	}
+	return false
+}
+
+// isAttachment reports whether msg is command context rather than chat: the
+// output of a command that added it as context, which is the only command
+// output kept as a system message, or the echoed command line above it
+func isAttachment(msg components.Message) bool {
+	switch msg.Role {
+	case components.RoleSystem:
+		return true
+	case components.RoleUser:
+		return commands.IsCommand(msg.Content)
+	}
+	return false
+}
+
+func (m Model) handleStreamStarted(msg streamStartedMsg) (Model, tea.Cmd) {
+	if msg.id != m.streamID || !m.streaming {
+		// The request was cancelled before the stream opened
+		if msg.events != nil {
+			return m, drainStream(msg.events)
+		}
+		return m, nil
+	}
+
+	if msg.err != nil {
+		m.finishStream()
+		m.addError(msg.err)
+		return m, nil
+	}
+
+	m.stream = msg.events
+	m.updateProgress()
+	return m, tea.Batch(waitForStreamEvent(msg.events), progressTick(msg.id))
+}
+
+// handleProgressTick refreshes the progress indicator so elapsed time keeps
+// moving during quiet stretches, until the stream ends.
+func (m Model) handleProgressTick(msg progressTickMsg) (Model, tea.Cmd) {
+	if msg.id != m.streamID || !m.streaming {
+		return m, nil
+	}
+	m.updateProgress()
+	return m, progressTick(msg.id)
+}
+
+// updateProgress shows characters received and time elapsed on the stream.
+func (m *Model) updateProgress() {
+	m.statusBar.SetProgress(m.streamChars, m.now().Sub(m.streamStart))
+}
+
+func (m Model) handleStreamEvent(msg streamEventMsg) (Model, tea.Cmd) {
+	if msg.events != m.stream {
+		// Stale event from a cancelled stream; keep reading so the producer can exit
+		if msg.closed {
+			return m, nil
+		}
+		return m, drainStream(msg.events)
+	}
+
+	if msg.closed {
+		return m, m.completeResponse()
+	}
+
+	switch msg.event.Type {
+	case ai.StreamEventChunk, ai.StreamEventReasoning:
+		if m.firstChunkAt.IsZero() {
+			m.firstChunkAt = m.now()
+		}
+		m.spinner.Stop()
+		if !m.replyStarted {
+			m.messages.Add(components.RoleAssistant, "")
+			m.replyStarted = true
+		}
+		if msg.event.Type == ai.StreamEventReasoning {
+			m.messages.AppendReasoning(msg.event.Content)
+		} else {
+			m.messages.AppendToLast(msg.event.Content)
+			m.streamBuf += msg.event.Content
+		}
+		m.streamChars += utf8.RuneCountInString(msg.event.Content)
+		m.updateProgress()
+		m.refreshViewport()
+		return m, waitForStreamEvent(msg.events)
+	case ai.StreamEventError:
+		m.dropEmptyReply()
+		m.finishStream()
+		m.addError(msg.event.Err)
+		return m, drainStream(msg.events)
+	case ai.StreamEventDone:
+		if m.showTokens && msg.event.Usage != nil {
+			m.statusBar.SetUsage(*msg.event.Usage)
+		}
+		if msg.event.FinishReason == ai.FinishReasonLength {
+			m.statusBar.SetWarning("response truncated (length)")
+		}
+		m.recordUsage(msg.event.Usage)
+		return m, tea.Batch(m.completeResponse(), drainStream(msg.events))
+	}
+
+	return m, waitForStreamEvent(msg.events)
+}
+
+// completeResponse post-processes the finished assistant message and ends the
+// stream. An empty reply is retried once when configured, otherwise noted.
+func (m *Model) completeResponse() tea.Cmd {
+	if strings.TrimSpace(m.streamBuf) == "" {
+		m.dropEmptyReply()
+		m.finishStream()
+		if m.retryEmpty && !m.retriedEmpty {
+			m.retriedEmpty = true
+			return tea.Batch(m.startStream(), m.spinner.Start())
+		}
+		m.messages.Add(components.RoleNote, "(empty response)")
+		m.refreshViewport()
+		return nil
+	}
+
+	if len(m.postProc) > 0 {
+		m.messages.SetLastContent(m.postProc.Apply(m.streamBuf))
+	}
+	m.finishStream()
+	return nil
+}
+
+// recordUsage appends the finished turn to the usage log when enabled.
+// Providers that report no usage are logged with zero token counts.
+func (m *Model) recordUsage(u *ai.Usage) {
+	if m.usageLog == nil || m.client == nil {
+		return
+	}
+
+	now := m.now()
+	entry := usage.Entry{
+		Timestamp:  now,
+		Provider:   m.client.Provider(),
+		Model:      m.client.Model(),
+		DurationMS: now.Sub(m.streamStart).Milliseconds(),
+	}
+	if u != nil {
+		entry.PromptTokens = u.PromptTokens
+		entry.CompletionTokens = u.CompletionTokens
+		entry.TotalTokens = u.TotalTokens
+	}
+	if err := m.usageLog.Record(entry); err != nil {
+		m.statusBar.SetWarning(err.Error())
+	}
+}
+
+// cancelStream stops the in-flight response at the user's request. Whatever
+// arrived is kept and marked as cut short; with nothing kept, a note says
+// the reply was cancelled.
+func (m *Model) cancelStream() {
+	m.dropEmptyReply()
+	if m.replyStarted {
+		m.messages.MarkLastCancelled()
+	} else {
+		m.messages.Add(components.RoleNote, "(cancelled)")
+	}
+	m.finishStream()
+}
+
+// dropEmptyReply removes the streaming reply's message when no answer text
+// has arrived, only whitespace or reasoning, so a blank assistant turn isn't
+// sent with later requests
+func (m *Model) dropEmptyReply() {
+	if m.replyStarted && strings.TrimSpace(m.streamBuf) == "" {
+		m.messages.PopAssistant()
+		m.replyStarted = false
+	}
+}
+
+// finishStream releases the in-flight request. Any partial response is kept.
+func (m *Model) finishStream() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = nil
+	m.stream = nil
+	m.streaming = false
+	m.streamBuf = ""
+	m.replyStarted = false
+	m.spinner.Stop()
+	m.statusBar.ClearProgress()
+	m.recordTiming()
+	m.refreshViewport()
+}
+
+// recordTiming captures time-to-first-token and total time for the finished stream.
+func (m *Model) recordTiming() {
+	if m.streamStart.IsZero() {
+		return
+	}
+
+	m.totalTime = m.now().Sub(m.streamStart)
+	m.ttft = 0
+	if !m.firstChunkAt.IsZero() {
+		m.ttft = m.firstChunkAt.Sub(m.streamStart)
+	}
+	m.streamStart = time.Time{}
+	m.statusBar.SetTiming(m.ttft, m.totalTime)
+}
+
+func (m *Model) addError(err error) {
+	m.messages.Add(components.RoleError, "Error: "+err.Error())
+	m.refreshViewport()
+}
+
+func (m *Model) refreshViewport() {
+	m.renderMessages()
+	m.viewport.GotoBottom()
+}
+
+// renderMessages sets the viewport content, re-running any search so its
+// marks follow messages that were added or removed.
+func (m *Model) renderMessages() {
+	current := -1
+	if m.searchQuery != "" {
+		m.matches = m.messages.Search(m.searchQuery)
+		m.matchIdx = min(m.matchIdx, len(m.matches)-1)
+		if m.matchIdx >= 0 {
+			current = m.matches[m.matchIdx]
+		} else {
+			// Every match was cleared away; end the search
+			m.searchQuery = ""
+			m.matchIdx = 0
+		}
+	}
+	m.messages.SetMatches(m.matches, current)
+
+	content, lines := m.messages.RenderWithOffsets()
+	m.messageLines = lines
+	m.viewport.SetContent(content)
+}
+
+// drainStream consumes any remaining events so the producing goroutine exits.
+func drainStream(events <-chan ai.StreamEvent) tea.Cmd {
+	return func() tea.Msg {
+		for range events {
+		}
+		return nil
+	}
+}
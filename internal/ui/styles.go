@@ -1,42 +1,129 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"slices"
+	"strings"
 
-// Color palette
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a color palette for the UI chrome.
+type Theme struct {
+	Name      string
+	Primary   lipgloss.Color
+	Secondary lipgloss.Color
+	Error     lipgloss.Color
+	Warning   lipgloss.Color
+	Muted     lipgloss.Color
+	Text      lipgloss.Color
+	Bg        lipgloss.Color
+}
+
+// Built-in themes, selected by ui.theme
+var (
+	DarkTheme = Theme{
+		Name:      "dark",
+		Primary:   lipgloss.Color("#7D56F4"), // Purple
+		Secondary: lipgloss.Color("#00D4AA"), // Teal
+		Error:     lipgloss.Color("#FF6B6B"), // Red
+		Warning:   lipgloss.Color("#FFB86C"), // Orange
+		Muted:     lipgloss.Color("#626262"), // Gray
+		Text:      lipgloss.Color("#FAFAFA"), // White
+		Bg:        lipgloss.Color("#1E1E1E"), // Dark
+	}
+
+	LightTheme = Theme{
+		Name:      "light",
+		Primary:   lipgloss.Color("#5A3FC0"), // Deep purple
+		Secondary: lipgloss.Color("#00806A"), // Dark teal
+		Error:     lipgloss.Color("#C62828"), // Dark red
+		Warning:   lipgloss.Color("#B35C00"), // Burnt orange
+		Muted:     lipgloss.Color("#8A8A8A"), // Gray
+		Text:      lipgloss.Color("#1E1E1E"), // Near black
+		Bg:        lipgloss.Color("#FAFAFA"), // Light
+	}
+)
+
+var themes = map[string]Theme{
+	DarkTheme.Name:  DarkTheme,
+	LightTheme.Name: LightTheme,
+}
+
+// ThemeByName returns the named theme. An empty name selects the dark theme.
+func ThemeByName(name string) (Theme, error) {
+	if name == "" {
+		return DarkTheme, nil
+	}
+	theme, ok := themes[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(themes))
+		for n := range themes {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return DarkTheme, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return theme, nil
+}
+
+// Color palette of the active theme
 var (
-	PrimaryColor   = lipgloss.Color("#7D56F4") // Purple
-	SecondaryColor = lipgloss.Color("#00D4AA") // Teal
-	ErrorColor     = lipgloss.Color("#FF6B6B") // Red
-	WarningColor   = lipgloss.Color("#FFB86C") // Orange
-	MutedColor     = lipgloss.Color("#626262") // Gray
-	TextColor      = lipgloss.Color("#FAFAFA") // White
-	BgColor        = lipgloss.Color("#1E1E1E") // Dark
+	PrimaryColor   lipgloss.Color
+	SecondaryColor lipgloss.Color
+	ErrorColor     lipgloss.Color
+	WarningColor   lipgloss.Color
+	MutedColor     lipgloss.Color
+	TextColor      lipgloss.Color
+	BgColor        lipgloss.Color
 )
 
 // Component styles
 var (
+	HeaderStyle      lipgloss.Style
+	MessageAreaStyle lipgloss.Style
+	InputStyle       lipgloss.Style
+	StatusBarStyle   lipgloss.Style
+	ExitPromptStyle  lipgloss.Style
+	LogoStyle        lipgloss.Style
+)
+
+func init() {
+	ApplyTheme(DarkTheme)
+}
+
+// ApplyTheme sets the palette and rebuilds the component styles from it.
+func ApplyTheme(t Theme) {
+	PrimaryColor = t.Primary
+	SecondaryColor = t.Secondary
+	ErrorColor = t.Error
+	WarningColor = t.Warning
+	MutedColor = t.Muted
+	TextColor = t.Text
+	BgColor = t.Bg
+
 	HeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(PrimaryColor).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(PrimaryColor).
+		Padding(0, 1)
 
 	MessageAreaStyle = lipgloss.NewStyle().
-				Padding(1, 2)
+		Padding(1, 2)
 
 	InputStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(SecondaryColor).
-			Padding(0, 1)
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(SecondaryColor).
+		Padding(0, 1)
 
 	StatusBarStyle = lipgloss.NewStyle().
-			Foreground(MutedColor).
-			Padding(0, 1)
+		Foreground(MutedColor).
+		Padding(0, 1)
 
 	ExitPromptStyle = lipgloss.NewStyle().
-			Foreground(WarningColor).
-			Bold(true)
+		Foreground(WarningColor).
+		Bold(true)
 
 	LogoStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(PrimaryColor)
-)
+		Bold(true).
+		Foreground(PrimaryColor)
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kbesada/flux-code-cli/internal/config"
 )
 
 func TestColorsDefined(t *testing.T) {
@@ -41,3 +43,41 @@ func TestStylesRender(t *testing.T) {
 		}
 	}
 }
+
+func TestLightThemeDiffersFromDark(t *testing.T) {
+	pairs := map[string][2]lipgloss.Color{
+		"Primary":   {DarkTheme.Primary, LightTheme.Primary},
+		"Secondary": {DarkTheme.Secondary, LightTheme.Secondary},
+		"Text":      {DarkTheme.Text, LightTheme.Text},
+		"Bg":        {DarkTheme.Bg, LightTheme.Bg},
+	}
+	for name, pair := range pairs {
+		if pair[0] == pair[1] {
+			t.Errorf("%s should differ between dark and light, both %s", name, pair[0])
+		}
+	}
+}
+
+func TestThemeByName(t *testing.T) {
+	if theme, err := ThemeByName("Light"); err != nil || theme.Name != "light" {
+		t.Errorf("ThemeByName(Light) = %q, %v", theme.Name, err)
+	}
+	if theme, err := ThemeByName(""); err != nil || theme.Name != "dark" {
+		t.Errorf("empty theme should default to dark, got %q, %v", theme.Name, err)
+	}
+	if theme, err := ThemeByName("neon"); err == nil || theme.Name != "dark" {
+		t.Errorf("unknown theme should fall back to dark with an error, got %q, %v", theme.Name, err)
+	}
+}
+
+func TestModelAppliesConfiguredTheme(t *testing.T) {
+	t.Cleanup(func() { ApplyTheme(DarkTheme) })
+
+	NewModel(&config.Config{UI: config.UIConfig{Theme: "light"}}, nil)
+	if PrimaryColor != LightTheme.Primary || TextColor != LightTheme.Text {
+		t.Errorf("light theme not applied: primary %s, text %s", PrimaryColor, TextColor)
+	}
+	if fg := HeaderStyle.GetForeground(); fg != LightTheme.Primary {
+		t.Errorf("styles should be rebuilt from the theme, header foreground %v", fg)
+	}
+}
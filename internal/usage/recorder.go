@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultFile is the usage log's file name inside the data directory
+const DefaultFile = "usage.jsonl"
+
+// Entry is one completed turn in the usage log
+type Entry struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	DurationMS       int64     `json:"duration_ms"`
+}
+
+// Recorder appends usage entries to a JSONL file, one object per line
+type Recorder struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewRecorder creates a recorder writing to path. The file and its
+// directory are created on the first Record.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Path returns the log file location
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Record appends e as a single JSON line
+func (r *Recorder) Record(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("usage log: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("usage log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("usage log: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorderAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", DefaultFile)
+	r := NewRecorder(path)
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []Entry{
+		{Timestamp: ts, Provider: "openai", Model: "gpt-4o", PromptTokens: 12, CompletionTokens: 30, TotalTokens: 42, DurationMS: 1500},
+		{Timestamp: ts.Add(time.Minute), Provider: "ollama", Model: "llama3", DurationMS: 800},
+	}
+	for _, e := range entries {
+		if err := r.Record(e); err != nil {
+			t.Fatalf("Record() error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+
+	if len(got) != len(entries) {
+		t.Fatalf("expected %d lines, got %d", len(entries), len(got))
+	}
+	for i := range entries {
+		if got[i] != entries[i] {
+			t.Errorf("line %d: expected %+v, got %+v", i, entries[i], got[i])
+		}
+	}
+}
+
+func TestRecorderFieldNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := NewRecorder(path).Record(Entry{Provider: "p", Model: "m", TotalTokens: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"timestamp", "provider", "model", "prompt_tokens", "completion_tokens", "total_tokens", "duration_ms"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("missing field %q in %s", key, data)
+		}
+	}
+}